@@ -24,13 +24,16 @@ func generateAst(outputDir string) {
 		outputDir,
 		"Expr,any",
 		[]string{
-			"Assign   : Name scanner.Token, Value Expr",
-			"Binary   : Left Expr, Operator scanner.Token, Right Expr",
-			"Grouping : Expression Expr",
-			"Literal  : Value any",
-			"Logical  : Left Expr, Operator scanner.Token, Right Expr",
-			"Unary    : Operator scanner.Token, Right Expr",
-			"Variable : Name scanner.Token",
+			"Assign      : Name scanner.Token, Value Expr; Local *Slot",
+			"Binary      : Left Expr, Operator scanner.Token, Right Expr",
+			"Call        : Callee Expr, Paren scanner.Token, Arguments []Expr",
+			"Coalesce    : Left Expr, Operator scanner.Token, Right Expr",
+			"Conditional : Condition Expr, ThenBranch Expr, ElseBranch Expr",
+			"Grouping    : Expression Expr",
+			"Literal     : Value any",
+			"Logical     : Left Expr, Operator scanner.Token, Right Expr",
+			"Unary       : Operator scanner.Token, Right Expr",
+			"Variable    : Name scanner.Token; Local *Slot",
 		},
 	)
 
@@ -38,11 +41,13 @@ func generateAst(outputDir string) {
 		outputDir,
 		"Stmt",
 		[]string{
-			"Block      : Statements []Stmt ",
+			"Block      : Statements []Stmt; Scope *Scope",
 			"Expression : Expression Expr",
+			"Function   : Name scanner.Token, Params []scanner.Token, Body []Stmt; Local *Slot, Scope *Scope",
 			"If         : Condition Expr, ThenBranch Stmt, ElseBranch Stmt",
 			"Print      : Expression Expr",
-			"Var        : Name scanner.Token, Initializer Expr",
+			"Return     : Keyword scanner.Token, Value Expr",
+			"Var        : Name scanner.Token, Initializer Expr; Local *Slot",
 			"While      : Condition Expr, Body Stmt",
 		},
 	)
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/conformance"
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: conformance [flags] <suite directory>")
+		flag.PrintDefaults()
+	}
+	verbose := flag.Bool("v", false, "print passed and skipped tests too")
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(64)
+	}
+
+	results, err := conformance.CheckSuite(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	passed, failed, skipped := 0, 0, 0
+	for _, result := range results {
+		switch {
+		case result.Skipped:
+			skipped++
+			if *verbose {
+				fmt.Println("SKIP", result.Path)
+			}
+		case result.Passed():
+			passed++
+			if *verbose {
+				fmt.Println("PASS", result.Path)
+			}
+		default:
+			failed++
+			fmt.Println("FAIL", result.Path)
+			for _, failure := range result.Failures {
+				fmt.Println("    " + failure)
+			}
+		}
+	}
+
+	total := passed + failed
+	percent := 0.0
+	if total > 0 {
+		percent = float64(passed) * 100 / float64(total)
+	}
+	fmt.Printf("%d passed, %d failed, %d skipped: %.1f%% compatible\n", passed, failed, skipped, percent)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
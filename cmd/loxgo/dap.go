@@ -0,0 +1,25 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/dap"
+)
+
+// runDAP implements "loxgo dap" serving the Debug Adapter Protocol over standard input and output.
+func runDAP(args []string) int {
+	flags := flag.NewFlagSet("dap", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: loxgo dap")
+		fmt.Fprintln(flags.Output(), "Serves the Debug Adapter Protocol over standard input and output.")
+	}
+	_ = flags.Parse(args)
+
+	if err := dap.NewServer(os.Stdin, os.Stdout).Run(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
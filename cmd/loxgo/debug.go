@@ -0,0 +1,31 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/debugshell"
+)
+
+// runDebug implements "loxgo debug" debugging a script with commands read from standard input.
+func runDebug(args []string) int {
+	flags := flag.NewFlagSet("debug", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: loxgo debug script [args...]")
+		fmt.Fprintln(flags.Output(), "Debugs the script interactively, type help at the prompt for commands.")
+	}
+	_ = flags.Parse(args)
+	if flags.NArg() < 1 {
+		flags.Usage()
+		return 2
+	}
+
+	path := flags.Arg(0)
+	sources, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return debugshell.NewShell(os.Stdin, os.Stdout).Run(path, string(sources), flags.Args()[1:])
+}
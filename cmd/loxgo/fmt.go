@@ -0,0 +1,110 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/formatter"
+)
+
+// runFmt implements "loxgo fmt [--check|--write] [paths...]" and returns the process exit code.
+// Without paths it formats standard input to standard output.
+func runFmt(args []string) int {
+	flags := flag.NewFlagSet("fmt", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: loxgo fmt [flags] [paths...]")
+		flags.PrintDefaults()
+	}
+	check := flags.Bool("check", false, "list files whose formatting differs and exit with non-zero code")
+	write := flags.Bool("write", false, "write formatted sources back to files")
+	_ = flags.Parse(args)
+	if *check && *write {
+		fmt.Fprintln(os.Stderr, "--check and --write are mutually exclusive")
+		return 64
+	}
+
+	if flags.NArg() == 0 {
+		sources, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		formatted, err := formatter.Format(string(sources))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 65
+		}
+		fmt.Print(formatted)
+		return 0
+	}
+
+	files, err := loxFiles(flags.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	code := 0
+	for _, file := range files {
+		sources, err := os.ReadFile(file)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		formatted, err := formatter.Format(string(sources))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", file, err)
+			code = 65
+			continue
+		}
+		switch {
+		case *check:
+			if formatted != string(sources) {
+				fmt.Println(file)
+				code = max(code, 1)
+			}
+		case *write:
+			if formatted != string(sources) {
+				if err := os.WriteFile(file, []byte(formatted), 0o644); err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					return 1
+				}
+			}
+		default:
+			fmt.Print(formatted)
+		}
+	}
+	return code
+}
+
+// loxFiles returns files given directly and .lox files found in directories recursively.
+func loxFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		err = filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !entry.IsDir() && strings.HasSuffix(file, ".lox") {
+				files = append(files, file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/lint"
+)
+
+// defaultLintConfig is used when it exists in the working directory and -config is not set.
+const defaultLintConfig = ".loxlint.json"
+
+// runLint implements "loxgo lint [-config file] [paths...]" and returns the process exit code.
+// Without paths it checks standard input.
+func runLint(args []string) int {
+	flags := flag.NewFlagSet("lint", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: loxgo lint [flags] [paths...]")
+		flags.PrintDefaults()
+		fmt.Fprintln(flags.Output(), "Rules:")
+		for _, rule := range lint.Rules() {
+			fmt.Fprintf(flags.Output(), "  %s\n    \t%s\n", rule.ID(), rule.Description())
+		}
+	}
+	configPath := flags.String("config", "", "JSON config enabling rules, "+defaultLintConfig+" by default")
+	_ = flags.Parse(args)
+
+	config, err := lintConfig(*configPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 64
+	}
+	linter := lint.NewLinter(config)
+
+	if flags.NArg() == 0 {
+		sources, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		return lintSources(linter, "<stdin>", string(sources))
+	}
+
+	files, err := loxFiles(flags.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	code := 0
+	for _, file := range files {
+		sources, err := os.ReadFile(file)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		code = max(code, lintSources(linter, file, string(sources)))
+	}
+	return code
+}
+
+func lintConfig(path string) (lint.Config, error) {
+	if path != "" {
+		return lint.LoadConfig(path)
+	}
+	config, err := lint.LoadConfig(defaultLintConfig)
+	if errors.Is(err, fs.ErrNotExist) {
+		return lint.Config{}, nil
+	}
+	return config, err
+}
+
+func lintSources(linter *lint.Linter, name, sources string) int {
+	diagnostics, err := linter.Lint(sources)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", name, err)
+		return 65
+	}
+	for _, diagnostic := range diagnostics {
+		fmt.Printf("%s:%s\n", name, diagnostic)
+	}
+	if len(diagnostics) > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/lsp"
+)
+
+// runLSP implements "loxgo lsp" serving the Language Server Protocol over standard input and output.
+func runLSP(args []string) int {
+	flags := flag.NewFlagSet("lsp", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: loxgo lsp")
+		fmt.Fprintln(flags.Output(), "Serves the Language Server Protocol over standard input and output.")
+	}
+	_ = flags.Parse(args)
+
+	if err := lsp.NewServer(os.Stdin, os.Stdout).Run(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
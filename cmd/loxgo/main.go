@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 
@@ -8,13 +9,67 @@ import (
 )
 
 func main() {
-	if len(os.Args) > 2 {
-		fmt.Println("Usage: loxgo [script]")
-		os.Exit(64)
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: loxgo [flags] [script [args...]]")
+		fmt.Fprintln(flag.CommandLine.Output(), "       loxgo test [flags] [paths...]")
+		fmt.Fprintln(flag.CommandLine.Output(), "       loxgo fmt [flags] [paths...]")
+		fmt.Fprintln(flag.CommandLine.Output(), "       loxgo lint [flags] [paths...]")
+		fmt.Fprintln(flag.CommandLine.Output(), "       loxgo lsp")
+		fmt.Fprintln(flag.CommandLine.Output(), "       loxgo dap")
+		fmt.Fprintln(flag.CommandLine.Output(), "       loxgo debug script [args...]")
+		flag.PrintDefaults()
 	}
+	seed := flag.Int64("seed", 0, "seed for random natives, runs with the same seed are reproducible")
+	trace := flag.Bool("trace", false, "print an execution trace with lines and values to standard error")
+	profile := flag.String("profile", "", "write a pprof profile of the script to the file and report hot spots to standard error")
+	optimize := flag.Bool("O", false, "fold constant expressions and remove dead branches before running")
+	sandbox := flag.String("sandbox", string(interpreter.SandboxFull), "natives scripts may use: pure, read-only-fs or full")
+	flag.Parse()
+
+	switch flag.Arg(0) {
+	case "test":
+		os.Exit(runTests(flag.Args()[1:]))
+	case "fmt":
+		os.Exit(runFmt(flag.Args()[1:]))
+	case "lint":
+		os.Exit(runLint(flag.Args()[1:]))
+	case "lsp":
+		os.Exit(runLSP(flag.Args()[1:]))
+	case "dap":
+		os.Exit(runDAP(flag.Args()[1:]))
+	case "debug":
+		os.Exit(runDebug(flag.Args()[1:]))
+	}
+
 	lox := interpreter.New()
-	if len(os.Args) == 2 {
-		lox.RunFile(os.Args[1])
+	if err := lox.SetSandbox(interpreter.SandboxProfile(*sandbox)); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if *optimize {
+		lox.EnableOptimizations()
+	}
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			lox.SetSeed(*seed)
+		}
+	})
+	if flag.NArg() >= 1 {
+		lox.SetArgs(flag.Args()[1:])
+	}
+	// After SetArgs, so the trace starts with the script.
+	if *trace {
+		lox.SetHooks(interpreter.NewTracer(os.Stderr))
+	}
+	if *profile != "" {
+		if flag.NArg() < 1 || *trace {
+			fmt.Fprintln(os.Stderr, "--profile needs a script and can't be used with --trace")
+			os.Exit(2)
+		}
+		os.Exit(runProfiled(lox, flag.Arg(0), *profile))
+	}
+	if flag.NArg() >= 1 {
+		lox.RunFile(flag.Arg(0))
 		return
 	}
 	lox.RunPrompt()
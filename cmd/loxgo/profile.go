@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/interpreter"
+)
+
+// runProfiled runs the script like LoxGo.RunFile, then writes the hot spots report
+// to standard error and the pprof profile to the output file.
+func runProfiled(lox *interpreter.LoxGo, script string, output string) int {
+	sources, err := os.ReadFile(script)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	profiler := interpreter.NewProfiler(script)
+	lox.SetHooks(profiler)
+	lox.Run(string(sources))
+
+	if err := profiler.WriteReport(os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	file, err := os.Create(output)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if err := profiler.WriteProfile(file); err != nil {
+		_ = file.Close()
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if err := file.Close(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return lox.ExitCode()
+}
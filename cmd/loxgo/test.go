@@ -0,0 +1,69 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/interpreter"
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/loxtest"
+)
+
+// runTests implements "loxgo test [-v] [paths...]" and returns the process exit code.
+func runTests(args []string) int {
+	flags := flag.NewFlagSet("test", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: loxgo test [flags] [paths...]")
+		flags.PrintDefaults()
+	}
+	verbose := flags.Bool("v", false, "print passed tests too")
+	coverProfile := flags.String("coverprofile", "", "write LCOV coverage of test files to the file")
+	coverHTML := flags.String("coverhtml", "", "write test files annotated with coverage to the HTML file")
+	_ = flags.Parse(args)
+
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	runner := loxtest.NewRunner(os.Stdout, *verbose)
+	if *coverProfile != "" || *coverHTML != "" {
+		runner.EnableCoverage()
+	}
+	summary, err := runner.Run(paths)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	for _, report := range []struct {
+		path  string
+		write func(io.Writer, ...*interpreter.Coverage) error
+	}{
+		{*coverProfile, interpreter.WriteLCOV},
+		{*coverHTML, interpreter.WriteCoverageHTML},
+	} {
+		if report.path == "" {
+			continue
+		}
+		if err := writeCoverage(report.path, report.write, runner.Coverage()); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+	if summary.Failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+func writeCoverage(path string, write func(io.Writer, ...*interpreter.Coverage) error, coverage []*interpreter.Coverage) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(file, coverage...); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
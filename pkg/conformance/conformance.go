@@ -0,0 +1,252 @@
+// Package conformance checks loxgo against test files annotated in the
+// Crafting Interpreters test suite format.
+package conformance
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/interpreter"
+)
+
+const (
+	exitCompileError = 65
+	exitRuntimeError = 70
+)
+
+var (
+	// Annotations, the same as the official test runner uses for jlox.
+	expectedOutputPattern       = regexp.MustCompile(`// expect: ?(.*)`)
+	expectedErrorPattern        = regexp.MustCompile(`// (Error.*)`)
+	errorLinePattern            = regexp.MustCompile(`// \[((java|c) )?line (\d+)\] (Error.*)`)
+	expectedRuntimeErrorPattern = regexp.MustCompile(`// expect runtime error: (.+)`)
+	nonTestPattern              = regexp.MustCompile(`// nontest`)
+
+	// loxgo errors output.
+	compileErrorPattern = regexp.MustCompile(`^\[line\s*(\d+)\s*\] (Error.*)$`)
+	runtimeErrorPattern = regexp.MustCompile(`^\[line\s*(\d+)\s*\] Runtime error: (.*)$`)
+	stackTracePattern   = regexp.MustCompile(`^\[line \d+\] in `)
+)
+
+// Expectations are annotations found in a test file.
+type Expectations struct {
+	Output       []string
+	CompileError []string
+	RuntimeError string
+	// RuntimeErrorLine is the line of expect runtime error annotation.
+	RuntimeErrorLine int
+	NonTest          bool
+}
+
+// ExitCode is the exit code the test expects.
+func (e Expectations) ExitCode() int {
+	if len(e.CompileError) > 0 {
+		return exitCompileError
+	}
+	if e.RuntimeError != "" {
+		return exitRuntimeError
+	}
+	return 0
+}
+
+// ParseExpectations collects annotations, lines of compile errors are normalized to "[line N] Error...".
+func ParseExpectations(sources string) Expectations {
+	expectations := Expectations{}
+	for index, line := range strings.Split(sources, "\n") {
+		lineNumber := index + 1
+		if nonTestPattern.MatchString(line) {
+			expectations.NonTest = true
+		}
+		if match := expectedOutputPattern.FindStringSubmatch(line); match != nil {
+			expectations.Output = append(expectations.Output, match[1])
+			continue
+		}
+		if match := errorLinePattern.FindStringSubmatch(line); match != nil {
+			// Errors only clox reports are not applicable to a tree walk interpreter.
+			if match[2] != "c" {
+				expectations.CompileError = append(
+					expectations.CompileError,
+					fmt.Sprintf("[line %s] %s", match[3], match[4]),
+				)
+			}
+			continue
+		}
+		if match := expectedErrorPattern.FindStringSubmatch(line); match != nil {
+			expectations.CompileError = append(
+				expectations.CompileError,
+				fmt.Sprintf("[line %d] %s", lineNumber, match[1]),
+			)
+			continue
+		}
+		if match := expectedRuntimeErrorPattern.FindStringSubmatch(line); match != nil {
+			expectations.RuntimeError = match[1]
+			expectations.RuntimeErrorLine = lineNumber
+		}
+	}
+	return expectations
+}
+
+// Result of checking a single test file, it is passed when there are no failures.
+type Result struct {
+	Path     string
+	Skipped  bool
+	Failures []string
+}
+
+func (r Result) Passed() bool {
+	return !r.Skipped && len(r.Failures) == 0
+}
+
+// Check runs sources through LoxGo with captured output and compares it with annotations.
+func Check(path string, sources string) Result {
+	result := Result{Path: path}
+	expectations := ParseExpectations(sources)
+	if expectations.NonTest {
+		result.Skipped = true
+		return result
+	}
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	lox := interpreter.New()
+	lox.SetInput(strings.NewReader(""))
+	lox.SetOutput(stdout)
+	lox.SetErrorOutput(stderr)
+	lox.Run(sources)
+
+	result.Failures = append(result.Failures, checkErrors(expectations, stderr.String())...)
+	result.Failures = append(result.Failures, checkOutput(expectations, stdout.String())...)
+	if code := lox.ExitCode(); code != expectations.ExitCode() {
+		result.Failures = append(
+			result.Failures,
+			fmt.Sprintf("Expected return code %d and got %d.", expectations.ExitCode(), code),
+		)
+	}
+	return result
+}
+
+// CheckFile reads and checks a single test file.
+func CheckFile(path string) (Result, error) {
+	sources, err := os.ReadFile(path)
+	if err != nil {
+		return Result{}, err
+	}
+	return Check(path, string(sources)), nil
+}
+
+// CheckSuite checks all .lox files found in the directory recursively in lexical order.
+func CheckSuite(dir string) ([]Result, error) {
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() && strings.HasSuffix(path, ".lox") {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	results := make([]Result, 0, len(paths))
+	for _, path := range paths {
+		result, err := CheckFile(path)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func checkErrors(expectations Expectations, stderr string) []string {
+	var failures []string
+	var compileErrors []string
+	runtimeErrorFound := false
+	for _, line := range strings.Split(strings.TrimRight(stderr, "\n"), "\n") {
+		if line == "" || stackTracePattern.MatchString(line) {
+			continue
+		}
+		if match := runtimeErrorPattern.FindStringSubmatch(line); match != nil {
+			runtimeErrorFound = true
+			if expectations.RuntimeError == "" {
+				failures = append(failures, fmt.Sprintf("Unexpected runtime error %q.", match[2]))
+				continue
+			}
+			if match[2] != expectations.RuntimeError {
+				failures = append(
+					failures,
+					fmt.Sprintf("Expected runtime error %q and got %q.", expectations.RuntimeError, match[2]),
+				)
+			}
+			errorLine, _ := strconv.Atoi(match[1])
+			if errorLine != expectations.RuntimeErrorLine {
+				failures = append(
+					failures,
+					fmt.Sprintf("Expected runtime error on line %d but was on line %d.", expectations.RuntimeErrorLine, errorLine),
+				)
+			}
+			continue
+		}
+		if match := compileErrorPattern.FindStringSubmatch(line); match != nil {
+			compileErrors = append(compileErrors, fmt.Sprintf("[line %s] %s", match[1], match[2]))
+			continue
+		}
+		failures = append(failures, fmt.Sprintf("Unexpected output on stderr: %q.", line))
+	}
+	if expectations.RuntimeError != "" && !runtimeErrorFound {
+		failures = append(failures, fmt.Sprintf("Expected runtime error %q and got none.", expectations.RuntimeError))
+	}
+
+	expected := make(map[string]bool, len(expectations.CompileError))
+	for _, compileError := range expectations.CompileError {
+		expected[compileError] = true
+	}
+	found := make(map[string]bool, len(compileErrors))
+	for _, compileError := range compileErrors {
+		found[compileError] = true
+		if !expected[compileError] {
+			failures = append(failures, fmt.Sprintf("Unexpected error: %q.", compileError))
+		}
+	}
+	for _, compileError := range expectations.CompileError {
+		if !found[compileError] {
+			failures = append(failures, fmt.Sprintf("Missing expected error: %q.", compileError))
+		}
+	}
+	return failures
+}
+
+func checkOutput(expectations Expectations, stdout string) []string {
+	var failures []string
+	lines := strings.Split(strings.TrimSuffix(stdout, "\n"), "\n")
+	if stdout == "" {
+		lines = nil
+	}
+	for index, line := range lines {
+		if index >= len(expectations.Output) {
+			failures = append(failures, fmt.Sprintf("Got output %q when none was expected.", line))
+			continue
+		}
+		if line != expectations.Output[index] {
+			failures = append(
+				failures,
+				fmt.Sprintf("Expected output %q on line %d and got %q.", expectations.Output[index], index+1, line),
+			)
+		}
+	}
+	for index := len(lines); index < len(expectations.Output); index++ {
+		failures = append(failures, fmt.Sprintf("Missing expected output %q.", expectations.Output[index]))
+	}
+	return failures
+}
@@ -0,0 +1,104 @@
+package conformance
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseExpectations(t *testing.T) {
+	sources := `print 1; // expect: 1
+print "";  // expect:
+var a = ; // Error at ';': Expect expression.
+// [line 7] Error at end: Expect '}' after block.
+// [c line 8] Error: only clox reports it.
+print a; // expect runtime error: Undefined variable 'a'.
+`
+	got := ParseExpectations(sources)
+
+	want := Expectations{
+		Output: []string{"1", ""},
+		CompileError: []string{
+			"[line 3] Error at ';': Expect expression.",
+			"[line 7] Error at end: Expect '}' after block.",
+		},
+		RuntimeError:     "Undefined variable 'a'.",
+		RuntimeErrorLine: 6,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseExpectations() = %#v, want %#v", got, want)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name         string
+		sources      string
+		wantFailures []string
+	}{
+		{
+			name:    "Passed",
+			sources: "print 1; // expect: 1",
+		},
+		{
+			name:    "Wrong output",
+			sources: "print 1; // expect: 2",
+			wantFailures: []string{
+				`Expected output "2" on line 1 and got "1".`,
+			},
+		},
+		{
+			name:    "Missing output",
+			sources: "print 1; // expect: 1\n// expect: 2",
+			wantFailures: []string{
+				`Missing expected output "2".`,
+			},
+		},
+		{
+			name:    "Unexpected runtime error",
+			sources: "print -nil;",
+			wantFailures: []string{
+				`Unexpected runtime error "invalid type for operator MINUS given, must be number.".`,
+				"Expected return code 0 and got 70.",
+			},
+		},
+		{
+			name:    "Runtime error on wrong line",
+			sources: "// expect runtime error: Undefined variable 'a'.\nprint a;",
+			wantFailures: []string{
+				"Expected runtime error on line 1 but was on line 2.",
+			},
+		},
+		{
+			name:    "Missing compile error",
+			sources: "print 1; // Error at '1': Something.\n// expect: 1",
+			wantFailures: []string{
+				`Missing expected error: "[line 1] Error at '1': Something.".`,
+				"Expected return code 65 and got 0.",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Check(tt.name, tt.sources)
+
+			if !reflect.DeepEqual(got.Failures, tt.wantFailures) {
+				t.Errorf("Check() failures = %#v, want %#v", got.Failures, tt.wantFailures)
+			}
+		})
+	}
+}
+
+func TestCheckSuite(t *testing.T) {
+	results, err := CheckSuite("testdata")
+	if err != nil {
+		t.Fatalf("CheckSuite() returned error: %s", err)
+	}
+	if len(results) == 0 {
+		t.Fatal("CheckSuite() found no test files")
+	}
+	for _, result := range results {
+		if !result.Passed() {
+			t.Errorf("%s failed: %v", result.Path, result.Failures)
+		}
+	}
+}
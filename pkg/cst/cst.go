@@ -0,0 +1,142 @@
+// Package cst builds a lossless concrete syntax tree: ast nodes with tokens
+// and trivia they were parsed from, so sources can be reproduced byte-for-byte.
+package cst
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/errors"
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/parser"
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/parser/ast"
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/scanner"
+)
+
+// Node covers tokens from First to Last, both included, with their trivia.
+type Node struct {
+	// AST is an ast.Stmt or an ast.Expr, it is nil for the root node.
+	AST      any
+	First    int
+	Last     int
+	Children []*Node
+
+	tree *Tree
+}
+
+// Tree is a lossless tree over the whole sources.
+type Tree struct {
+	Root       *Node
+	Statements []ast.Stmt
+
+	tokens []scanner.Token
+	trivia []scanner.TokenTrivia
+	nodes  map[any]*Node
+}
+
+// Parse scans sources in lossless mode and parses them, errors are reported
+// to errReporter, but the tree still reproduces sources.
+func Parse(sources string, errReporter errors.Reporter) *Tree {
+	if errReporter == nil {
+		errReporter = func(int, string) {}
+	}
+	scannr := scanner.NewLosslessScanner(sources, errReporter)
+	tokens := scannr.ScanTokens()
+	parsr := parser.NewParser(tokens, errReporter)
+	statements := parsr.Parse()
+
+	tree := &Tree{
+		Statements: statements,
+		tokens:     tokens,
+		trivia:     scannr.Trivia(),
+		nodes:      make(map[any]*Node),
+	}
+	tree.Root = &Node{First: 0, Last: len(tokens) - 1, tree: tree}
+	tree.build(parsr.Spans())
+	return tree
+}
+
+// build nests nodes by their spans, a node is a child of the closest node covering it.
+func (t *Tree) build(spans map[any]parser.Span) {
+	nodes := make([]*Node, 0, len(spans))
+	for astNode, span := range spans {
+		if astNode == nil || span.Last < span.First {
+			continue
+		}
+		node := &Node{AST: astNode, First: span.First, Last: span.Last, tree: t}
+		nodes = append(nodes, node)
+		t.nodes[astNode] = node
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].First != nodes[j].First {
+			return nodes[i].First < nodes[j].First
+		}
+		if nodes[i].Last != nodes[j].Last {
+			return nodes[i].Last > nodes[j].Last
+		}
+		// Statements wrap expressions with the same span.
+		_, isStmt := nodes[i].AST.(ast.Stmt)
+		return isStmt
+	})
+
+	stack := []*Node{t.Root}
+	for _, node := range nodes {
+		for stack[len(stack)-1].Last < node.Last {
+			stack = stack[:len(stack)-1]
+		}
+		parent := stack[len(stack)-1]
+		parent.Children = append(parent.Children, node)
+		stack = append(stack, node)
+	}
+}
+
+// NodeOf returns the tree node of an ast node or nil for nodes without sources,
+// e.g. synthesized by desugaring for loops.
+func (t *Tree) NodeOf(astNode any) *Node {
+	return t.nodes[astNode]
+}
+
+// Tokens are all tokens, EOF included.
+func (t *Tree) Tokens() []scanner.Token {
+	return t.tokens
+}
+
+// Trivia returns trivia of the token by its index.
+func (t *Tree) Trivia(index int) scanner.TokenTrivia {
+	return t.trivia[index]
+}
+
+// String reproduces the original sources.
+func (t *Tree) String() string {
+	return t.Root.Text()
+}
+
+// Tokens are tokens covered by the node.
+func (n *Node) Tokens() []scanner.Token {
+	return n.tree.tokens[n.First : n.Last+1]
+}
+
+// Text is the node sources with leading trivia of the first token and
+// trailing trivia of the last one.
+func (n *Node) Text() string {
+	builder := strings.Builder{}
+	for index := n.First; index <= n.Last; index++ {
+		builder.WriteString(n.tree.trivia[index].Text(n.tree.tokens[index]))
+	}
+	return builder.String()
+}
+
+// TrimmedText is the node sources without leading and trailing trivia.
+func (n *Node) TrimmedText() string {
+	builder := strings.Builder{}
+	for index := n.First; index <= n.Last; index++ {
+		trivia := n.tree.trivia[index]
+		if index == n.First {
+			trivia.Leading = nil
+		}
+		if index == n.Last {
+			trivia.Trailing = nil
+		}
+		builder.WriteString(trivia.Text(n.tree.tokens[index]))
+	}
+	return builder.String()
+}
@@ -0,0 +1,91 @@
+package cst
+
+import (
+	"testing"
+
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/parser/ast"
+)
+
+func TestParse_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		sources string
+	}{
+		{
+			name:    "Empty sources",
+			sources: "",
+		},
+		{
+			name: "Comments and blank lines",
+			sources: `// header comment
+var a   =  1;  // trailing
+
+
+fun add(x,y)  {
+	return x+y;   }
+// footer
+`,
+		},
+		{
+			name:    "Windows line endings and no final newline",
+			sources: "print 1;\r\n\r\n  print  2;",
+		},
+		{
+			name:    "For loop desugaring",
+			sources: "for (var i = 0; i < 3; i = i + 1)\n  print i;\n",
+		},
+		{
+			name:    "Scanner and parser errors",
+			sources: "var @ x = ;\nprint # 1;\n\"unterminated\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree := Parse(tt.sources, nil)
+
+			if got := tree.String(); got != tt.sources {
+				t.Errorf("String() = %q, want %q", got, tt.sources)
+			}
+		})
+	}
+}
+
+func TestParse_Nodes(t *testing.T) {
+	sources := `var a = 1; // one
+// the function
+fun add(x, y) {
+  return x + (y * 2);
+}
+`
+	tree := Parse(sources, nil)
+
+	if len(tree.Root.Children) != 2 {
+		t.Fatalf("Root.Children has %d nodes, want 2", len(tree.Root.Children))
+	}
+	varNode := tree.Root.Children[0]
+	if got, want := varNode.Text(), "var a = 1; // one"; got != want {
+		t.Errorf("var Text() = %q, want %q", got, want)
+	}
+	funNode := tree.Root.Children[1]
+	if got, want := funNode.TrimmedText(), "fun add(x, y) {\n  return x + (y * 2);\n}"; got != want {
+		t.Errorf("fun TrimmedText() = %q, want %q", got, want)
+	}
+	if got, want := funNode.Text()[:len("\n// the function\n")], "\n// the function\n"; got != want {
+		t.Errorf("fun Text() starts with %q, want leading newline and comment %q", got, want)
+	}
+
+	function := tree.Statements[1].(*ast.Function)
+	returnStmt := function.Body[0].(*ast.Return)
+	binary := returnStmt.Value.(*ast.Binary)
+	if got, want := tree.NodeOf(binary).TrimmedText(), "x + (y * 2)"; got != want {
+		t.Errorf("NodeOf(binary) TrimmedText() = %q, want %q", got, want)
+	}
+	grouping := binary.Right.(*ast.Grouping)
+	if got, want := tree.NodeOf(grouping.Expression).TrimmedText(), "y * 2"; got != want {
+		t.Errorf("NodeOf(grouping expression) TrimmedText() = %q, want %q", got, want)
+	}
+	returnNode := tree.NodeOf(returnStmt)
+	if len(returnNode.Children) != 1 || returnNode.Children[0].AST != binary {
+		t.Errorf("return node children = %v, want the binary expression only", returnNode.Children)
+	}
+}
@@ -0,0 +1,298 @@
+// Package dap implements a Debug Adapter Protocol server debugging a Lox program
+// over a byte stream, usually standard input and output of "loxgo dap".
+package dap
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/framing"
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/interpreter"
+)
+
+// scopesPerFrame splits variables references into frame ids and scope indexes.
+const scopesPerFrame = 1000
+
+type Server struct {
+	conn *framing.Conn
+
+	mu  sync.Mutex
+	seq int
+
+	debugger *interpreter.Debugger
+	lox      *interpreter.LoxGo
+	program  string
+	sources  string
+	started  bool
+}
+
+func NewServer(in io.Reader, out io.Writer) *Server {
+	s := &Server{conn: framing.NewConn(in, out)}
+	s.debugger = interpreter.NewDebugger(func(stop interpreter.Stop) {
+		s.sendEvent("stopped", stoppedEventBody{
+			Reason:            string(stop.Reason),
+			Description:       stop.Description,
+			ThreadID:          threadID,
+			AllThreadsStopped: true,
+		})
+	})
+	return s
+}
+
+// Run serves requests until disconnect or the end of input.
+func (s *Server) Run() error {
+	for {
+		body, err := s.conn.Read()
+		if err == io.EOF {
+			s.debugger.Terminate()
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			return fmt.Errorf("invalid message: %w", err)
+		}
+		result, err := s.handle(req)
+		resp := response{
+			Type:       "response",
+			RequestSeq: req.Seq,
+			Success:    err == nil,
+			Command:    req.Command,
+			Body:       result,
+		}
+		if err != nil {
+			resp.Message = err.Error()
+		}
+		if err := s.send(&resp.Seq, &resp); err != nil {
+			return err
+		}
+		switch req.Command {
+		case "initialize":
+			s.sendEvent("initialized", nil)
+		case "disconnect":
+			return nil
+		}
+	}
+}
+
+func (s *Server) handle(req request) (any, error) {
+	switch req.Command {
+	case "initialize":
+		return map[string]any{
+			"supportsConfigurationDoneRequest": true,
+			"supportsConditionalBreakpoints":   true,
+			"supportsEvaluateForHovers":        true,
+			"supportsTerminateRequest":         true,
+		}, nil
+	case "launch":
+		var args launchArguments
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return nil, s.launch(args)
+	case "setBreakpoints":
+		var args setBreakpointsArguments
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return map[string]any{"breakpoints": s.setBreakpoints(args)}, nil
+	case "configurationDone":
+		return nil, s.start()
+	case "threads":
+		return map[string]any{"threads": []thread{{ID: threadID, Name: "main"}}}, nil
+	case "stackTrace":
+		return s.stackTrace()
+	case "scopes":
+		var args scopesArguments
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return s.scopes(args.FrameID)
+	case "variables":
+		var args variablesArguments
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return s.variables(args.VariablesReference)
+	case "evaluate":
+		var args evaluateArguments
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		result, err := s.debugger.Evaluate(frameIndex(args.FrameID), args.Expression)
+		if rErr, ok := err.(*interpreter.RuntimeError); ok {
+			err = fmt.Errorf("%s", rErr.Message())
+		}
+		return map[string]any{"result": result, "variablesReference": 0}, err
+	case "continue":
+		return map[string]any{"allThreadsContinued": true}, s.debugger.Continue()
+	case "next":
+		return nil, s.debugger.StepOver()
+	case "stepIn":
+		return nil, s.debugger.StepIn()
+	case "stepOut":
+		return nil, s.debugger.StepOut()
+	case "pause":
+		s.debugger.Pause()
+		return nil, nil
+	case "terminate", "disconnect":
+		s.debugger.Terminate()
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unsupported command %q", req.Command)
+}
+
+func (s *Server) launch(args launchArguments) error {
+	sources, err := os.ReadFile(args.Program)
+	if err != nil {
+		return err
+	}
+	s.program = args.Program
+	s.sources = string(sources)
+	s.lox = interpreter.New()
+	s.lox.SetArgs(args.Args)
+	// Standard input carries the protocol, programs read nothing.
+	s.lox.SetInput(strings.NewReader(""))
+	s.lox.SetOutput(outputWriter{server: s, category: "stdout"})
+	s.lox.SetErrorOutput(outputWriter{server: s, category: "stderr"})
+	s.lox.SetDebugger(s.debugger)
+	if args.StopOnEntry {
+		s.debugger.StopOnEntry()
+	}
+	return nil
+}
+
+func (s *Server) setBreakpoints(args setBreakpointsArguments) []breakpoint {
+	breakpoints := make([]breakpoint, 0, len(args.Breakpoints))
+	sameProgram := s.program == "" || samePath(args.Source.Path, s.program)
+	if sameProgram {
+		s.debugger.ClearBreakpoints()
+	}
+	for _, requested := range args.Breakpoints {
+		bp := breakpoint{Verified: sameProgram, Line: requested.Line}
+		if !sameProgram {
+			bp.Message = "Only the launched program can have breakpoints."
+		} else if err := s.debugger.SetBreakpoint(requested.Line, requested.Condition); err != nil {
+			bp.Verified = false
+			bp.Message = err.Error()
+		}
+		breakpoints = append(breakpoints, bp)
+	}
+	return breakpoints
+}
+
+func samePath(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
+
+// start runs the launched program in its own goroutine, the program reports its end with events.
+func (s *Server) start() error {
+	if s.lox == nil {
+		return fmt.Errorf("no program is launched")
+	}
+	if s.started {
+		return nil
+	}
+	s.started = true
+	go func() {
+		s.lox.Run(s.sources)
+		s.sendEvent("exited", exitedEventBody{ExitCode: s.lox.ExitCode()})
+		s.sendEvent("terminated", nil)
+	}()
+	return nil
+}
+
+// Frame ids are stack trace indexes plus one, variables references combine
+// frame ids and scope indexes plus one, zero is not a valid reference.
+
+func frameIndex(frameID int) int {
+	if frameID <= 0 {
+		return 0
+	}
+	return frameID - 1
+}
+
+func (s *Server) stackTrace() (any, error) {
+	frames, err := s.debugger.StackTrace()
+	if err != nil {
+		return nil, err
+	}
+	stackFrames := make([]stackFrame, 0, len(frames))
+	for index, frame := range frames {
+		stackFrames = append(stackFrames, stackFrame{
+			ID:     index + 1,
+			Name:   frame.Name,
+			Source: source{Name: filepath.Base(s.program), Path: s.program},
+			Line:   frame.Line,
+			Column: 1,
+		})
+	}
+	return map[string]any{"stackFrames": stackFrames, "totalFrames": len(stackFrames)}, nil
+}
+
+func (s *Server) scopes(frameID int) (any, error) {
+	scopes, err := s.debugger.Scopes(frameIndex(frameID))
+	if err != nil {
+		return nil, err
+	}
+	result := make([]scope, 0, len(scopes))
+	for index, sc := range scopes {
+		result = append(result, scope{
+			Name:               sc.Name,
+			VariablesReference: (frameIndex(frameID)+1)*scopesPerFrame + index + 1,
+		})
+	}
+	return map[string]any{"scopes": result}, nil
+}
+
+func (s *Server) variables(reference int) (any, error) {
+	frame, index := reference/scopesPerFrame-1, reference%scopesPerFrame-1
+	scopes, err := s.debugger.Scopes(frame)
+	if err != nil {
+		return nil, err
+	}
+	if index < 0 || index >= len(scopes) {
+		return nil, fmt.Errorf("invalid variables reference %d", reference)
+	}
+	variables := make([]variable, 0, len(scopes[index].Variables))
+	for _, v := range scopes[index].Variables {
+		variables = append(variables, variable{Name: v.Name, Value: v.Value})
+	}
+	return map[string]any{"variables": variables}, nil
+}
+
+// send assigns the next sequence number to the message and writes it,
+// messages are written in the order of their numbers.
+func (s *Server) send(seq *int, msg any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	*seq = s.seq
+	return s.conn.Write(msg)
+}
+
+// sendEvent ignores write errors, they are noticed by the request loop.
+func (s *Server) sendEvent(name string, body any) {
+	e := event{Type: "event", Event: name, Body: body}
+	_ = s.send(&e.Seq, &e)
+}
+
+// outputWriter sends program output as output events.
+type outputWriter struct {
+	server   *Server
+	category string
+}
+
+func (w outputWriter) Write(p []byte) (int, error) {
+	w.server.sendEvent("output", outputEventBody{Category: w.category, Output: string(p)})
+	return len(p), nil
+}
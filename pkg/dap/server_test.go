@@ -0,0 +1,190 @@
+package dap
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/framing"
+)
+
+type client struct {
+	t        *testing.T
+	conn     *framing.Conn
+	seq      int
+	messages chan map[string]any
+	// events received while waiting for responses.
+	events []map[string]any
+}
+
+// connect starts a server in a goroutine and returns a client talking to it.
+func connect(t *testing.T) *client {
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+	go func() {
+		_ = NewServer(serverIn, serverOut).Run()
+		_ = serverOut.Close()
+	}()
+	c := &client{t: t, conn: framing.NewConn(clientIn, clientOut), messages: make(chan map[string]any, 100)}
+	go func() {
+		defer close(c.messages)
+		for {
+			body, err := c.conn.Read()
+			if err != nil {
+				return
+			}
+			var msg map[string]any
+			_ = json.Unmarshal(body, &msg)
+			c.messages <- msg
+		}
+	}()
+	return c
+}
+
+func (c *client) next() map[string]any {
+	c.t.Helper()
+	select {
+	case msg, ok := <-c.messages:
+		if !ok {
+			c.t.Fatalf("connection closed")
+		}
+		return msg
+	case <-time.After(5 * time.Second):
+		c.t.Fatalf("timeout waiting for a message")
+	}
+	return nil
+}
+
+// request sends a request and returns the body of its successful response.
+func (c *client) request(command string, arguments any) map[string]any {
+	c.t.Helper()
+	c.seq++
+	_ = c.conn.Write(map[string]any{"seq": c.seq, "type": "request", "command": command, "arguments": arguments})
+	for {
+		msg := c.next()
+		if msg["type"] == "event" {
+			c.events = append(c.events, msg)
+			continue
+		}
+		if msg["request_seq"] != float64(c.seq) || msg["success"] != true {
+			c.t.Fatalf("%s response = %v", command, msg)
+		}
+		body, _ := msg["body"].(map[string]any)
+		return body
+	}
+}
+
+// event returns the body of the next event with the name, skipping others.
+func (c *client) event(name string) map[string]any {
+	c.t.Helper()
+	for len(c.events) > 0 {
+		msg := c.events[0]
+		c.events = c.events[1:]
+		if msg["event"] == name {
+			body, _ := msg["body"].(map[string]any)
+			return body
+		}
+	}
+	for {
+		msg := c.next()
+		if msg["event"] == name {
+			body, _ := msg["body"].(map[string]any)
+			return body
+		}
+	}
+}
+
+func TestServer_DebugSession(t *testing.T) {
+	program := filepath.Join(t.TempDir(), "main.lox")
+	sources := "var a = 1;\nfun f(x) {\n  var y = x * 2;\n  return y;\n}\nprint f(a);\n"
+	if err := os.WriteFile(program, []byte(sources), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c := connect(t)
+
+	capabilities := c.request("initialize", map[string]any{"adapterID": "loxgo"})
+	if capabilities["supportsConditionalBreakpoints"] != true {
+		t.Errorf("capabilities = %v", capabilities)
+	}
+	c.event("initialized")
+	c.request("launch", map[string]any{"program": program})
+	breakpoints := c.request("setBreakpoints", map[string]any{
+		"source":      map[string]any{"path": program},
+		"breakpoints": []any{map[string]any{"line": 3, "condition": "x == 1"}, map[string]any{"line": 4, "condition": "x =="}},
+	})["breakpoints"].([]any)
+	if breakpoints[0].(map[string]any)["verified"] != true || breakpoints[1].(map[string]any)["verified"] != false {
+		t.Errorf("breakpoints = %v", breakpoints)
+	}
+	c.request("configurationDone", nil)
+
+	if stopped := c.event("stopped"); stopped["reason"] != "breakpoint" {
+		t.Errorf("stopped = %v", stopped)
+	}
+	frames := c.request("stackTrace", map[string]any{"threadId": 1})["stackFrames"].([]any)
+	var got []any
+	for _, frame := range frames {
+		frame := frame.(map[string]any)
+		got = append(got, frame["name"], frame["line"])
+	}
+	if want := []any{"f", float64(3), "<script>", float64(6)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("stack frames = %v, want %v", got, want)
+	}
+	scopes := c.request("scopes", map[string]any{"frameId": 1})["scopes"].([]any)
+	locals := scopes[0].(map[string]any)
+	variables := c.request("variables", map[string]any{"variablesReference": locals["variablesReference"]})["variables"].([]any)
+	if len(variables) != 1 || variables[0].(map[string]any)["name"] != "x" || variables[0].(map[string]any)["value"] != "1" {
+		t.Errorf("locals = %v", variables)
+	}
+	if result := c.request("evaluate", map[string]any{"expression": "x + a", "frameId": 1})["result"]; result != "2" {
+		t.Errorf("evaluate = %v, want 2", result)
+	}
+
+	c.request("next", map[string]any{"threadId": 1})
+	c.event("stopped")
+	if line := c.request("stackTrace", nil)["stackFrames"].([]any)[0].(map[string]any)["line"]; line != float64(4) {
+		t.Errorf("line after next = %v, want 4", line)
+	}
+	c.request("continue", map[string]any{"threadId": 1})
+	if output := c.event("output"); output["output"] != "2\n" || output["category"] != "stdout" {
+		t.Errorf("output = %v", output)
+	}
+	if exited := c.event("exited"); exited["exitCode"] != float64(0) {
+		t.Errorf("exited = %v", exited)
+	}
+	c.event("terminated")
+	c.request("disconnect", nil)
+}
+
+func TestServer_StackTraceAfterContinue(t *testing.T) {
+	program := filepath.Join(t.TempDir(), "main.lox")
+	if err := os.WriteFile(program, []byte("var a = 1;\nprint a;\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c := connect(t)
+	c.request("initialize", map[string]any{"adapterID": "loxgo"})
+	c.request("launch", map[string]any{"program": program})
+	c.request("setBreakpoints", map[string]any{
+		"source":      map[string]any{"path": program},
+		"breakpoints": []any{map[string]any{"line": 1}},
+	})
+	c.request("configurationDone", nil)
+	c.event("stopped")
+
+	// The stack trace is sent without waiting for the continue response, it must not block requests.
+	_ = c.conn.Write(map[string]any{"seq": c.seq + 1, "type": "request", "command": "continue", "arguments": map[string]any{"threadId": 1}})
+	_ = c.conn.Write(map[string]any{"seq": c.seq + 2, "type": "request", "command": "stackTrace", "arguments": map[string]any{"threadId": 1}})
+	c.seq += 2
+	for responses := 0; responses < 2; {
+		if msg := c.next(); msg["type"] == "response" {
+			responses++
+		} else {
+			c.events = append(c.events, msg)
+		}
+	}
+	c.event("terminated")
+	c.request("disconnect", nil)
+}
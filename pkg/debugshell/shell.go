@@ -0,0 +1,252 @@
+// Package debugshell is an interactive command-line debugger for Lox programs
+// with gdb-style commands.
+package debugshell
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/interpreter"
+)
+
+const prompt = "(loxdb) "
+
+const help = `Commands:
+  break <line> [if <condition>]  stop at the line, optionally only when the condition is truthy
+  watch <name>                   stop when an assignment changes the variable
+  run, continue, c               start or continue the program
+  step, s                        run to the next line, entering functions
+  next, n                        run to the next line of the current function
+  finish                         run until the current function returns
+  print, p <expression>          evaluate the expression in the current frame
+  locals                         list variables of the current function or block
+  backtrace, bt                  list call frames
+  quit, q                        stop debugging
+An empty line repeats the previous command.
+`
+
+// Shell reads commands from in and writes their results and the program output to out.
+// The program reads nothing from standard input, in carries debugger commands.
+type Shell struct {
+	in  *bufio.Scanner
+	out io.Writer
+
+	lines    []string
+	lox      *interpreter.LoxGo
+	debugger *interpreter.Debugger
+	stops    chan interpreter.Stop
+	done     chan struct{}
+	started  bool
+}
+
+func NewShell(in io.Reader, out io.Writer) *Shell {
+	return &Shell{
+		in:  bufio.NewScanner(in),
+		out: out,
+	}
+}
+
+// Run debugs sources until the program ends or the user quits,
+// it returns the program exit code, which is 0 after quitting.
+func (s *Shell) Run(name string, sources string, args []string) int {
+	s.lines = strings.Split(sources, "\n")
+	s.stops = make(chan interpreter.Stop)
+	s.done = make(chan struct{})
+	s.debugger = interpreter.NewDebugger(func(stop interpreter.Stop) {
+		s.stops <- stop
+	})
+	s.lox = interpreter.New()
+	s.lox.SetArgs(args)
+	s.lox.SetInput(strings.NewReader(""))
+	s.lox.SetOutput(s.out)
+	s.lox.SetErrorOutput(s.out)
+	s.lox.SetDebugger(s.debugger)
+
+	s.printf("Debugging %s, type help for commands.\n", name)
+	previous := ""
+	for {
+		s.printf(prompt)
+		if !s.in.Scan() {
+			s.printf("\n")
+			return s.quit()
+		}
+		line := strings.TrimSpace(s.in.Text())
+		if line == "" {
+			line = previous
+		}
+		previous = line
+		command, argument, _ := strings.Cut(line, " ")
+		argument = strings.TrimSpace(argument)
+
+		var err error
+		resumed := false
+		switch command {
+		case "":
+		case "help", "h":
+			s.printf(help)
+		case "break", "b":
+			err = s.setBreakpoint(argument)
+		case "watch":
+			err = s.watch(argument)
+		case "run", "continue", "c":
+			resumed, err = true, s.resume(s.debugger.Continue, false)
+		case "step", "s":
+			resumed, err = true, s.resume(s.debugger.StepIn, true)
+		case "next", "n":
+			resumed, err = true, s.resume(s.debugger.StepOver, true)
+		case "finish":
+			resumed, err = true, s.resume(s.debugger.StepOut, true)
+		case "print", "p":
+			err = s.print(argument)
+		case "locals":
+			err = s.locals()
+		case "backtrace", "bt":
+			err = s.backtrace()
+		case "quit", "q":
+			return s.quit()
+		default:
+			err = fmt.Errorf("unknown command %q, type help for commands", command)
+		}
+		if errors.Is(err, interpreter.ErrNotStopped) {
+			err = errors.New("the program is not stopped")
+		}
+		if err != nil {
+			s.printf("Error: %s\n", err)
+			continue
+		}
+		if resumed && s.wait() {
+			code := s.lox.ExitCode()
+			s.printf("Program exited with code %d.\n", code)
+			return code
+		}
+	}
+}
+
+func (s *Shell) printf(format string, args ...any) {
+	fmt.Fprintf(s.out, format, args...)
+}
+
+func (s *Shell) setBreakpoint(argument string) error {
+	lineText, condition, _ := strings.Cut(argument, " if ")
+	line, err := strconv.Atoi(strings.TrimSpace(lineText))
+	if err != nil || line < 1 || line > len(s.lines) {
+		return fmt.Errorf("invalid line %q", lineText)
+	}
+	if err := s.debugger.SetBreakpoint(line, condition); err != nil {
+		return err
+	}
+	s.printf("Breakpoint at line %d.\n", line)
+	return nil
+}
+
+func (s *Shell) watch(name string) error {
+	if name == "" || strings.ContainsAny(name, " \t") {
+		return fmt.Errorf("invalid variable name %q", name)
+	}
+	s.debugger.Watch(name)
+	s.printf("Watching %s.\n", name)
+	return nil
+}
+
+// resume starts the program on the first command, stepping commands stop on its first line.
+func (s *Shell) resume(command func() error, stepping bool) error {
+	if s.started {
+		return command()
+	}
+	s.started = true
+	if stepping {
+		s.debugger.StopOnEntry()
+	}
+	go func() {
+		defer close(s.done)
+		s.lox.Run(strings.Join(s.lines, "\n"))
+	}()
+	return nil
+}
+
+// wait blocks until the program stops or ends, it reports whether the program ended.
+func (s *Shell) wait() bool {
+	select {
+	case stop := <-s.stops:
+		switch stop.Reason {
+		case interpreter.StopBreakpoint:
+			s.printf("Breakpoint at line %d.\n", stop.Line)
+		case interpreter.StopWatch:
+			s.printf("Watchpoint %s at line %d.\n", stop.Description, stop.Line)
+		}
+		s.printSourceLine(stop.Line)
+		return false
+	case <-s.done:
+		return true
+	}
+}
+
+func (s *Shell) printSourceLine(line int) {
+	if line < 1 || line > len(s.lines) {
+		return
+	}
+	s.printf("%d | %s\n", line, strings.TrimSpace(s.lines[line-1]))
+}
+
+func (s *Shell) print(expression string) error {
+	if expression == "" {
+		return errors.New("expression is expected")
+	}
+	value, err := s.debugger.Evaluate(0, expression)
+	if rErr, ok := err.(*interpreter.RuntimeError); ok {
+		return errors.New(rErr.Message())
+	}
+	if err != nil {
+		return err
+	}
+	s.printf("%s\n", value)
+	return nil
+}
+
+// locals lists scopes up to globals, at the top level it lists globals.
+func (s *Shell) locals() error {
+	scopes, err := s.debugger.Scopes(0)
+	if err != nil {
+		return err
+	}
+	if len(scopes) > 1 {
+		scopes = scopes[:len(scopes)-1]
+	}
+	count := 0
+	for _, scope := range scopes {
+		for _, variable := range scope.Variables {
+			s.printf("%s = %s\n", variable.Name, variable.Value)
+			count++
+		}
+	}
+	if count == 0 {
+		s.printf("No locals.\n")
+	}
+	return nil
+}
+
+func (s *Shell) backtrace() error {
+	frames, err := s.debugger.StackTrace()
+	if err != nil {
+		return err
+	}
+	for index, frame := range frames {
+		s.printf("#%d %s at line %d\n", index, frame.Name, frame.Line)
+	}
+	return nil
+}
+
+// quit terminates the running program and waits for it.
+func (s *Shell) quit() int {
+	if !s.started {
+		return 0
+	}
+	s.lox.SetErrorOutput(io.Discard)
+	s.debugger.Terminate()
+	<-s.done
+	return 0
+}
@@ -0,0 +1,109 @@
+package debugshell
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const sources = `var total = 0;
+fun add(n) {
+  total = total + n;
+  return total;
+}
+for (var i = 1; i <= 3; i = i + 1) {
+  add(i);
+}
+print total;
+`
+
+func TestShell_Run(t *testing.T) {
+	tests := []struct {
+		name     string
+		commands string
+		want     string
+		code     int
+	}{
+		{
+			name:     "Breakpoints and inspection",
+			commands: "break 3 if n == 2\nrun\nbt\nlocals\np n * 10\np missing\n\ncontinue\n",
+			want: `(loxdb) Breakpoint at line 3.
+(loxdb) Breakpoint at line 3.
+3 | total = total + n;
+(loxdb) #0 add at line 3
+#1 <script> at line 7
+(loxdb) n = 2
+(loxdb) 20
+(loxdb) Error: Undefined variable 'missing'.
+(loxdb) Error: Undefined variable 'missing'.
+(loxdb) 6
+Program exited with code 0.
+`,
+		},
+		{
+			name:     "Stepping",
+			commands: "step\nnext\nnext\nstep\nstep\nfinish\nlocals\nquit\n",
+			want: `(loxdb) 1 | var total = 0;
+(loxdb) 2 | fun add(n) {
+(loxdb) 6 | for (var i = 1; i <= 3; i = i + 1) {
+(loxdb) 7 | add(i);
+(loxdb) 3 | total = total + n;
+(loxdb) 7 | add(i);
+(loxdb) i = 2
+(loxdb) `,
+		},
+		{
+			name:     "Watchpoints",
+			commands: "watch total\nc\nc\nprint total\nc\nc\n",
+			want: `(loxdb) Watching total.
+(loxdb) Watchpoint total: 0 -> 1 at line 3.
+3 | total = total + n;
+(loxdb) Watchpoint total: 1 -> 3 at line 3.
+3 | total = total + n;
+(loxdb) 3
+(loxdb) Watchpoint total: 3 -> 6 at line 3.
+3 | total = total + n;
+(loxdb) 6
+Program exited with code 0.
+`,
+		},
+		{
+			name:     "Errors",
+			commands: "print total\nbreak 100\nbreak 3 if n ==\nwatch\nfoo\n",
+			want: `(loxdb) Error: the program is not stopped
+(loxdb) Error: invalid line "100"
+(loxdb) Error: invalid expression "n ==": at ';'. Message: Expect expression.
+(loxdb) Error: invalid variable name ""
+(loxdb) Error: unknown command "foo", type help for commands
+(loxdb) 
+`,
+		},
+		{
+			name:     "Runtime errors",
+			commands: "break 4\nrun\nprint -total\nprint -nil\nc\n",
+			want: `(loxdb) Breakpoint at line 4.
+(loxdb) Breakpoint at line 4.
+4 | return total;
+(loxdb) -1
+(loxdb) Error: invalid type for operator MINUS given, must be number.
+(loxdb) Breakpoint at line 4.
+4 | return total;
+(loxdb) 
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := bytes.Buffer{}
+			code := NewShell(strings.NewReader(tt.commands), &out).Run("sum.lox", sources, nil)
+
+			got := strings.TrimPrefix(out.String(), "Debugging sum.lox, type help for commands.\n")
+			if got != tt.want {
+				t.Errorf("output =\n%s\nwant\n%s", got, tt.want)
+			}
+			if code != tt.code {
+				t.Errorf("exit code = %d, want %d", code, tt.code)
+			}
+		})
+	}
+}
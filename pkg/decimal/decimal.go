@@ -0,0 +1,234 @@
+// Package decimal implements exact fixed-point decimal numbers of Lox decimal literals, e.g. 1.10d.
+package decimal
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+)
+
+// DivisionDigits is the smallest scale quotients are rounded to.
+const DivisionDigits = 16
+
+const (
+	// MaxBits is the largest bit length of unscaled values made by Mul, Quo and Pow.
+	MaxBits = 1 << 24
+	// MaxScale is the largest scale made by Mul, Quo and Pow, 10^MaxScale has about MaxBits bits.
+	MaxScale = MaxBits * 3 / 10
+)
+
+var (
+	ErrDivisionByZero = errors.New("division by zero")
+	ErrTooLarge       = errors.New("result is too large")
+)
+
+// Decimal is unscaled / 10^scale. It keeps its scale, so 1.10 stays 1.10, results of
+// addition and subtraction have the larger scale of operands, products the sum of them,
+// quotients are rounded, see Quo.
+// Decimals are immutable, the zero Decimal is 0.
+type Decimal struct {
+	unscaled *big.Int
+	scale    int
+}
+
+// Parse parses an optionally signed decimal without exponent, e.g. "-12.50".
+func Parse(text string) (Decimal, error) {
+	integer, fraction, _ := strings.Cut(text, ".")
+	unscaled, ok := new(big.Int).SetString(integer+fraction, 10)
+	if !ok || strings.ContainsAny(fraction, "+-") {
+		return Decimal{}, errors.New("invalid decimal " + text)
+	}
+	return Decimal{unscaled: unscaled, scale: len(fraction)}, nil
+}
+
+// FromInt is the integer as a decimal of scale 0.
+func FromInt(integer *big.Int) Decimal {
+	return Decimal{unscaled: new(big.Int).Set(integer)}
+}
+
+func (d Decimal) value() *big.Int {
+	if d.unscaled == nil {
+		return new(big.Int)
+	}
+	return d.unscaled
+}
+
+// Scale is the number of digits after the decimal point.
+func (d Decimal) Scale() int {
+	return d.scale
+}
+
+func (d Decimal) Sign() int {
+	return d.value().Sign()
+}
+
+// rescaled is the unscaled value at a scale not smaller than d's one.
+func (d Decimal) rescaled(scale int) *big.Int {
+	return new(big.Int).Mul(d.value(), pow10(scale-d.scale))
+}
+
+// aligned are unscaled values of both decimals at the larger scale.
+func aligned(a, b Decimal) (*big.Int, *big.Int, int) {
+	scale := max(a.scale, b.scale)
+	return a.rescaled(scale), b.rescaled(scale), scale
+}
+
+func (d Decimal) Add(other Decimal) Decimal {
+	a, b, scale := aligned(d, other)
+	return Decimal{unscaled: a.Add(a, b), scale: scale}
+}
+
+func (d Decimal) Sub(other Decimal) Decimal {
+	a, b, scale := aligned(d, other)
+	return Decimal{unscaled: a.Sub(a, b), scale: scale}
+}
+
+// Mul fails with ErrTooLarge before computing products above MaxBits or MaxScale.
+func (d Decimal) Mul(other Decimal) (Decimal, error) {
+	if d.scale+other.scale > MaxScale || d.value().BitLen()+other.value().BitLen() > MaxBits {
+		return Decimal{}, ErrTooLarge
+	}
+	return Decimal{unscaled: new(big.Int).Mul(d.value(), other.value()), scale: d.scale + other.scale}, nil
+}
+
+// Pow raises the decimal to a non-negative power, the scale is multiplied by it.
+// It fails with ErrTooLarge before computing results above MaxBits or MaxScale.
+func (d Decimal) Pow(exponent int64) (Decimal, error) {
+	if ExceedsBits(d.value(), exponent) || (d.scale > 0 && exponent > MaxScale/int64(d.scale)) {
+		return Decimal{}, ErrTooLarge
+	}
+	unscaled := new(big.Int).Exp(d.value(), big.NewInt(exponent), nil)
+	return Decimal{unscaled: unscaled, scale: d.scale * int(exponent)}, nil
+}
+
+// ExceedsBits tells whether the integer raised to the non-negative power has more than MaxBits bits.
+// Powers of integers having n bits have at least (n-1)*exponent bits, so results are at most twice larger.
+func ExceedsBits(integer *big.Int, exponent int64) bool {
+	bits := int64(integer.BitLen() - 1)
+	return bits > 0 && exponent > MaxBits/bits
+}
+
+func (d Decimal) Neg() Decimal {
+	return Decimal{unscaled: new(big.Int).Neg(d.value()), scale: d.scale}
+}
+
+// Quo divides rounding half to even to the larger scale of operands but at least DivisionDigits,
+// so repeated divisions don't grow the scale. Trailing zeros past the larger scale of operands
+// are dropped, so exact quotients like 1.00 / 4 = 0.25 stay short. It fails with ErrTooLarge
+// before computing quotients above MaxBits or MaxScale.
+func (d Decimal) Quo(other Decimal) (Decimal, error) {
+	if other.Sign() == 0 {
+		return Decimal{}, ErrDivisionByZero
+	}
+	scale := max(d.scale, other.scale)
+	target := max(scale, DivisionDigits)
+	// d / other = (d.unscaled * 10^(target-d.scale+other.scale) / other.unscaled) / 10^target
+	exponent := target - d.scale + other.scale
+	if target > MaxScale || d.value().BitLen()+pow10Bits(exponent)-other.value().BitLen() > MaxBits {
+		return Decimal{}, ErrTooLarge
+	}
+	numerator := new(big.Int).Mul(d.value(), pow10(exponent))
+	quotient, remainder := new(big.Int).QuoRem(numerator, other.value(), new(big.Int))
+	if roundsUp(quotient, remainder, other.value()) {
+		if numerator.Sign()*other.Sign() < 0 {
+			quotient.Sub(quotient, big.NewInt(1))
+		} else {
+			quotient.Add(quotient, big.NewInt(1))
+		}
+	}
+	return Decimal{unscaled: quotient, scale: target}.trimmed(scale), nil
+}
+
+// roundsUp tells whether the truncated quotient is rounded away from zero, half to even.
+func roundsUp(quotient, remainder, divisor *big.Int) bool {
+	twice := new(big.Int).Abs(remainder)
+	twice.Lsh(twice, 1)
+	switch twice.CmpAbs(divisor) {
+	case 1:
+		return true
+	case 0:
+		return quotient.Bit(0) == 1
+	}
+	return false
+}
+
+// QuoInt is the quotient truncated to an integer.
+func (d Decimal) QuoInt(other Decimal) (*big.Int, error) {
+	if other.Sign() == 0 {
+		return nil, ErrDivisionByZero
+	}
+	a, b, _ := aligned(d, other)
+	return a.Quo(a, b), nil
+}
+
+// Rem is the remainder of the truncated division, it has the sign of d.
+func (d Decimal) Rem(other Decimal) (Decimal, error) {
+	if other.Sign() == 0 {
+		return Decimal{}, ErrDivisionByZero
+	}
+	a, b, scale := aligned(d, other)
+	return Decimal{unscaled: a.Rem(a, b), scale: scale}, nil
+}
+
+// trimmed drops trailing zeros past the scale.
+func (d Decimal) trimmed(scale int) Decimal {
+	unscaled := new(big.Int).Set(d.value())
+	ten := big.NewInt(10)
+	remainder := new(big.Int)
+	for d.scale > scale {
+		quotient, _ := new(big.Int).QuoRem(unscaled, ten, remainder)
+		if remainder.Sign() != 0 {
+			break
+		}
+		unscaled = quotient
+		d.scale--
+	}
+	return Decimal{unscaled: unscaled, scale: d.scale}
+}
+
+func (d Decimal) Cmp(other Decimal) int {
+	a, b, _ := aligned(d, other)
+	return a.Cmp(b)
+}
+
+// Rat is the exact value.
+func (d Decimal) Rat() *big.Rat {
+	return new(big.Rat).SetFrac(d.value(), pow10(d.scale))
+}
+
+// Int is the value truncated to an integer.
+func (d Decimal) Int() *big.Int {
+	return new(big.Int).Quo(d.value(), pow10(d.scale))
+}
+
+// Float64 is the nearest float.
+func (d Decimal) Float64() float64 {
+	f, _ := d.Rat().Float64()
+	return f
+}
+
+// String formats the decimal with all digits of its scale, e.g. 1.10.
+func (d Decimal) String() string {
+	digits := new(big.Int).Abs(d.value()).String()
+	sign := ""
+	if d.Sign() < 0 {
+		sign = "-"
+	}
+	if d.scale == 0 {
+		return sign + digits
+	}
+	if len(digits) <= d.scale {
+		digits = strings.Repeat("0", d.scale-len(digits)+1) + digits
+	}
+	point := len(digits) - d.scale
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+// pow10Bits is at least the bit length of 10^exponent, log2(10) < 3.322.
+func pow10Bits(exponent int) int {
+	return exponent*3322/1000 + 1
+}
+
+func pow10(exponent int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exponent)), nil)
+}
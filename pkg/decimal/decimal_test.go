@@ -0,0 +1,178 @@
+package decimal
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func mustParse(t *testing.T, text string) Decimal {
+	t.Helper()
+	d, err := Parse(text)
+	if err != nil {
+		t.Fatalf("Parse(%q) error = %v", text, err)
+	}
+	return d
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    string
+		wantErr bool
+	}{
+		{text: "1.10", want: "1.10"},
+		{text: "0.05", want: "0.05"},
+		{text: "-12.500", want: "-12.500"},
+		{text: "42", want: "42"},
+		{text: "123456789012345678901234567890.1", want: "123456789012345678901234567890.1"},
+		{text: "", wantErr: true},
+		{text: "1.x", wantErr: true},
+		{text: "1.-5", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got, err := Parse(tt.text)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got.String() != tt.want {
+				t.Errorf("Parse() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecimal_Arithmetic(t *testing.T) {
+	tests := []struct {
+		name      string
+		operation func(a, b Decimal) (Decimal, error)
+		a, b      string
+		want      string
+	}{
+		{name: "Add keeps larger scale", operation: ok(Decimal.Add), a: "0.10", b: "0.2", want: "0.30"},
+		{name: "Sub", operation: ok(Decimal.Sub), a: "1.00", b: "1.10", want: "-0.10"},
+		{name: "Mul adds scales", operation: Decimal.Mul, a: "1.10", b: "1.10", want: "1.2100"},
+		{name: "Exact quotient", operation: Decimal.Quo, a: "1.00", b: "4", want: "0.25"},
+		{name: "Quotient keeps scale", operation: Decimal.Quo, a: "10.00", b: "2", want: "5.00"},
+		{name: "Rounded quotient", operation: Decimal.Quo, a: "2", b: "3", want: "0.6666666666666667"},
+		{name: "Negative rounded quotient", operation: Decimal.Quo, a: "-2", b: "3", want: "-0.6666666666666667"},
+		{name: "Half to even down", operation: Decimal.Quo, a: "1", b: "20000000000000000", want: "0"},
+		{name: "Half to even up", operation: Decimal.Quo, a: "3", b: "20000000000000000", want: "0.0000000000000002"},
+		{name: "Quotient of rounded quotient", operation: Decimal.Quo, a: "0.1111111111111111", b: "3", want: "0.0370370370370370"},
+		{name: "Quotient of larger scale", operation: Decimal.Quo, a: "1.00000000000000000000", b: "3", want: "0.33333333333333333333"},
+		{name: "Rem", operation: Decimal.Rem, a: "-7.5", b: "2", want: "-1.5"},
+		{name: "Pow", operation: pow, a: "1.5", b: "3", want: "3.375"},
+		{name: "Pow of zero", operation: pow, a: "1.50", b: "0", want: "1"},
+		{name: "Pow of one", operation: pow, a: "1", b: "1000000000000000000", want: "1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.operation(mustParse(t, tt.a), mustParse(t, tt.b))
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("%s %s = %s, want %s", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func ok(operation func(a, b Decimal) Decimal) func(a, b Decimal) (Decimal, error) {
+	return func(a, b Decimal) (Decimal, error) {
+		return operation(a, b), nil
+	}
+}
+
+func pow(a, b Decimal) (Decimal, error) {
+	return a.Pow(b.Int().Int64())
+}
+
+func TestDecimal_PowTooLarge(t *testing.T) {
+	tests := []struct {
+		d        string
+		exponent int64
+	}{
+		{d: "2", exponent: MaxBits + 1},
+		{d: "123456789", exponent: 1 << 40},
+		{d: "1.5", exponent: MaxScale + 1},
+		{d: "0.0000001", exponent: 1 << 60},
+	}
+	for _, tt := range tests {
+		if _, err := mustParse(t, tt.d).Pow(tt.exponent); !errors.Is(err, ErrTooLarge) {
+			t.Errorf("Pow(%s, %d) error = %v, want ErrTooLarge", tt.d, tt.exponent, err)
+		}
+	}
+}
+
+func TestDecimal_RepeatedQuo(t *testing.T) {
+	x, three := mustParse(t, "1"), mustParse(t, "3")
+	for n := 0; n < 20; n++ {
+		var err error
+		if x, err = x.Quo(three); err != nil {
+			t.Fatalf("Quo() error = %v", err)
+		}
+	}
+	if got, want := x.String(), "0.0000000002867972"; got != want {
+		t.Errorf("1 / 3^20 = %s, want %s", got, want)
+	}
+}
+
+func TestDecimal_TooLarge(t *testing.T) {
+	large, err := mustParse(t, "2").Pow(MaxBits - 1)
+	if err != nil {
+		t.Fatalf("Pow() error = %v", err)
+	}
+	tiny := mustParse(t, "0."+strings.Repeat("0", MaxScale)+"1")
+	tests := []struct {
+		name      string
+		operation func(a, b Decimal) (Decimal, error)
+		a, b      Decimal
+	}{
+		{name: "Mul bits", operation: Decimal.Mul, a: large, b: large},
+		{name: "Mul scale", operation: Decimal.Mul, a: tiny, b: mustParse(t, "0.1")},
+		{name: "Quo bits", operation: Decimal.Quo, a: large, b: mustParse(t, "0.0000000001")},
+		{name: "Quo scale", operation: Decimal.Quo, a: tiny, b: mustParse(t, "3")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.operation(tt.a, tt.b); !errors.Is(err, ErrTooLarge) {
+				t.Errorf("error = %v, want ErrTooLarge", err)
+			}
+		})
+	}
+}
+
+func TestDecimal_DivisionByZero(t *testing.T) {
+	one, zero := mustParse(t, "1"), mustParse(t, "0.00")
+	if _, err := one.Quo(zero); !errors.Is(err, ErrDivisionByZero) {
+		t.Errorf("Quo() error = %v, want ErrDivisionByZero", err)
+	}
+	if _, err := one.QuoInt(zero); !errors.Is(err, ErrDivisionByZero) {
+		t.Errorf("QuoInt() error = %v, want ErrDivisionByZero", err)
+	}
+	if _, err := one.Rem(zero); !errors.Is(err, ErrDivisionByZero) {
+		t.Errorf("Rem() error = %v, want ErrDivisionByZero", err)
+	}
+}
+
+func TestDecimal_Conversions(t *testing.T) {
+	d := mustParse(t, "-7.90")
+	if got := d.Cmp(mustParse(t, "-7.9")); got != 0 {
+		t.Errorf("Cmp() = %d, want 0", got)
+	}
+	if got := d.Int(); got.Int64() != -7 {
+		t.Errorf("Int() = %s, want -7", got)
+	}
+	if got := d.Float64(); got != -7.9 {
+		t.Errorf("Float64() = %v, want -7.9", got)
+	}
+	if got := FromInt(big.NewInt(3)).String(); got != "3" {
+		t.Errorf("FromInt() = %s, want 3", got)
+	}
+	if got := (Decimal{}).String(); got != "0" {
+		t.Errorf("zero Decimal = %s, want 0", got)
+	}
+}
@@ -0,0 +1,262 @@
+// Package formatter reprints Lox sources in the canonical style keeping comments.
+package formatter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/parser"
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/scanner"
+)
+
+const indentUnit = "  "
+
+// SyntaxError is returned for sources that cannot be parsed, they are never reformatted.
+type SyntaxError struct {
+	Messages []string
+}
+
+func (se *SyntaxError) Error() string {
+	return "syntax error: " + strings.Join(se.Messages, "; ")
+}
+
+// Format parses sources with parser.Parser and reprints them with consistent
+// indentation, spacing and line breaking: one statement per line, blocks braces
+// on the statement line, at most one blank line between statements.
+func Format(sources string) (string, error) {
+	syntaxErr := &SyntaxError{}
+	reporter := func(line int, message string) {
+		syntaxErr.Messages = append(syntaxErr.Messages, fmt.Sprintf("[line %d] %s", line, message))
+	}
+	scannr := scanner.NewScanner(sources, reporter)
+	tokens := scannr.ScanTokens()
+	parser.NewParser(tokens, reporter).Parse()
+	if len(syntaxErr.Messages) > 0 {
+		return "", syntaxErr
+	}
+
+	p := newPrinter(tokens, scannr.Comments())
+	return p.print(), nil
+}
+
+// printer walks tokens, the parser is used only for validation, so desugared
+// constructs like for loops are printed as written.
+type printer struct {
+	tokens   []scanner.Token
+	comments []scanner.Comment
+	out      strings.Builder
+
+	indent     int
+	braceDepth int
+	// parens holds true for if, while and for header parentheses.
+	parens []bool
+	// bodies are brace depths of unbraced statement bodies, e.g. if (x) print x;
+	bodies []int
+
+	lineStart      bool
+	pendingNewline bool
+	blankAllowed   bool
+	lastLine       int
+	// commented is set when comments were printed since the last token.
+	commented bool
+	// continued is set when a comment broke a statement, its next lines get extra indentation.
+	continued bool
+}
+
+func newPrinter(tokens []scanner.Token, comments []scanner.Comment) *printer {
+	return &printer{
+		tokens:    tokens,
+		comments:  comments,
+		lineStart: true,
+	}
+}
+
+func (p *printer) print() string {
+	comment := 0
+	for index, token := range p.tokens {
+		for comment < len(p.comments) && p.comments[comment].Next() <= index {
+			p.printComment(p.comments[comment])
+			comment++
+		}
+		if token.Kind() == scanner.EOF {
+			break
+		}
+		p.printToken(index)
+	}
+	if !p.lineStart {
+		p.out.WriteString("\n")
+	}
+	return p.out.String()
+}
+
+func (p *printer) printComment(comment scanner.Comment) {
+	text := strings.TrimRight(comment.Text(), " \t\r")
+	p.commented = true
+	if !p.lineStart && !p.pendingNewline {
+		p.continued = true
+	}
+	if !p.lineStart && comment.Line() == p.lastLine {
+		// Trailing comment stays on the line of the previous token.
+		p.out.WriteString(" " + text)
+		p.pendingNewline = true
+		return
+	}
+	p.pendingNewline = true
+	p.flushNewline(comment.Line())
+	p.out.WriteString(text)
+	p.lineStart = false
+	p.lastLine = comment.Line()
+	p.pendingNewline = true
+	p.blankAllowed = true
+}
+
+//gocyclo:ignore
+func (p *printer) printToken(index int) {
+	token := p.tokens[index]
+	next := p.tokens[index+1].Kind()
+
+	if token.Kind() == scanner.RIGHTBRACE {
+		p.indent--
+		p.braceDepth--
+		if p.previous(index) == scanner.LEFTBRACE && p.pendingNewline && !p.commented {
+			// Empty block is printed as {}.
+			p.pendingNewline = false
+		} else if !p.lineStart {
+			p.pendingNewline = true
+			p.blankAllowed = false
+		}
+	}
+
+	if p.pendingNewline {
+		p.flushNewline(startLine(token))
+	} else if !p.lineStart && NeedsSpace(p.previous(index), p.beforePrevious(index), token.Kind()) {
+		p.out.WriteString(" ")
+	}
+	p.out.WriteString(token.Lexeme())
+	p.lineStart = false
+	p.lastLine = token.Line()
+	p.commented = false
+
+	switch token.Kind() {
+	case scanner.LEFTBRACE:
+		p.braceDepth++
+		p.indent++
+		p.newlineAfter(false)
+	case scanner.RIGHTBRACE:
+		p.closeBodies()
+		if next != scanner.ELSE {
+			p.newlineAfter(true)
+		}
+	case scanner.SEMICOLON:
+		if len(p.parens) == 0 {
+			p.closeBodies()
+			p.newlineAfter(true)
+		}
+	case scanner.LEFTPAREN:
+		previous := p.previous(index)
+		p.parens = append(p.parens, previous == scanner.IF || previous == scanner.WHILE || previous == scanner.FOR)
+	case scanner.RIGHTPAREN:
+		if len(p.parens) == 0 {
+			return
+		}
+		header := p.parens[len(p.parens)-1]
+		p.parens = p.parens[:len(p.parens)-1]
+		if header && next != scanner.LEFTBRACE {
+			p.openBody()
+		}
+	case scanner.ELSE:
+		if next != scanner.LEFTBRACE && next != scanner.IF {
+			p.openBody()
+		}
+	}
+}
+
+// openBody starts an unbraced statement body on the next line with extra indentation.
+func (p *printer) openBody() {
+	p.bodies = append(p.bodies, p.braceDepth)
+	p.indent++
+	p.newlineAfter(false)
+}
+
+// closeBodies ends unbraced bodies finished by the statement just printed.
+func (p *printer) closeBodies() {
+	for len(p.bodies) > 0 && p.bodies[len(p.bodies)-1] == p.braceDepth {
+		p.bodies = p.bodies[:len(p.bodies)-1]
+		p.indent--
+	}
+}
+
+func (p *printer) newlineAfter(blankAllowed bool) {
+	p.pendingNewline = true
+	p.blankAllowed = blankAllowed
+	p.continued = false
+}
+
+// flushNewline starts a new line, keeping one blank line if sources had any before line.
+func (p *printer) flushNewline(line int) {
+	if !p.lineStart {
+		p.out.WriteString("\n")
+		if p.blankAllowed && line-p.lastLine > 1 {
+			p.out.WriteString("\n")
+		}
+	}
+	indent := p.indent
+	if p.continued {
+		indent++
+	}
+	p.out.WriteString(strings.Repeat(indentUnit, indent))
+	p.pendingNewline = false
+	p.lineStart = true
+}
+
+func (p *printer) previous(index int) scanner.TokenType {
+	if index == 0 {
+		return scanner.EOF
+	}
+	return p.tokens[index-1].Kind()
+}
+
+func (p *printer) beforePrevious(index int) scanner.TokenType {
+	if index < 2 {
+		return scanner.EOF
+	}
+	return p.tokens[index-2].Kind()
+}
+
+// NeedsSpace decides spacing between tokens on the same line, beforePrevious is scanner.EOF
+// at the start. Tools printing code on one line, e.g. the tracer, space tokens with it too.
+func NeedsSpace(previous scanner.TokenType, beforePrevious scanner.TokenType, current scanner.TokenType) bool {
+	switch current {
+	case scanner.RIGHTPAREN, scanner.COMMA, scanner.SEMICOLON, scanner.DOT:
+		return false
+	case scanner.RIGHTBRACE:
+		return previous != scanner.LEFTBRACE
+	}
+	switch previous {
+	case scanner.LEFTPAREN, scanner.DOT, scanner.BANG, scanner.TILDE:
+		return false
+	case scanner.MINUS:
+		// No space after unary minus.
+		return endsOperand(beforePrevious)
+	}
+	if current == scanner.LEFTPAREN {
+		// Calls and function declarations have no space before arguments.
+		return previous != scanner.IDENTIFIER && previous != scanner.RIGHTPAREN
+	}
+	return true
+}
+
+// endsOperand reports whether an operator after the token is binary.
+func endsOperand(kind scanner.TokenType) bool {
+	switch kind {
+	case scanner.NUMBER, scanner.STRING, scanner.IDENTIFIER, scanner.RIGHTPAREN,
+		scanner.TRUE, scanner.FALSE, scanner.NIL, scanner.THIS, scanner.SUPER:
+		return true
+	}
+	return false
+}
+
+// startLine is the first line of the token, multi-line strings report the last one.
+func startLine(token scanner.Token) int {
+	return token.Line() - strings.Count(token.Lexeme(), "\n")
+}
@@ -0,0 +1,86 @@
+package formatter
+
+import (
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		sources string
+		want    string
+	}{
+		{
+			name:    "Spacing around operators",
+			sources: "var   a=1;var b = -a*(2+3)-  -1 ;print !(a==b) ;",
+			want:    "var a = 1;\nvar b = -a * (2 + 3) - -1;\nprint !(a == b);\n",
+		},
+		{
+			name:    "Functions and calls",
+			sources: "fun add(x,y){return x+y;}fun empty( ){ }print add( 1 , 2 )( );",
+			want:    "fun add(x, y) {\n  return x + y;\n}\nfun empty() {}\nprint add(1, 2)();\n",
+		},
+		{
+			name:    "Unbraced bodies are indented",
+			sources: "if(a>0)print \"pos\";else if (a<0) print \"neg\"; else { print 0; }\nwhile (a) a = a - 1;",
+			want: "if (a > 0)\n  print \"pos\";\nelse if (a < 0)\n  print \"neg\";\nelse {\n  print 0;\n}\n" +
+				"while (a)\n  a = a - 1;\n",
+		},
+		{
+			name:    "For loops are kept",
+			sources: "for(var i=0;i<3;i=i+1){print i;}for (;;) {}",
+			want:    "for (var i = 0; i < 3; i = i + 1) {\n  print i;\n}\nfor (;;) {}\n",
+		},
+		{
+			name: "Comments are preserved",
+			sources: `// header
+var a = 1;   // trailing
+{
+      // inside
+  print a;
+}
+// footer`,
+			want: "// header\nvar a = 1; // trailing\n{\n  // inside\n  print a;\n}\n// footer\n",
+		},
+		{
+			name:    "Blocks with only comments are not empty",
+			sources: "{\n  // only comment\n}\nfun f() { // trailing\n}\nwhile (a) {\n\n// spaced\n\n}",
+			want:    "{\n  // only comment\n}\nfun f() { // trailing\n}\nwhile (a) {\n  // spaced\n}\n",
+		},
+		{
+			name:    "Statements broken by comments are continued",
+			sources: "{\nvar a = 1 + // one\n2 +\n// two\n3;\nprint a;\n}",
+			want:    "{\n  var a = 1 + // one\n    2 +\n    // two\n    3;\n  print a;\n}\n",
+		},
+		{
+			name:    "Blank lines are collapsed",
+			sources: "print 1;\n\n\n\nprint 2;\n{\n\nprint 3;\n\n}",
+			want:    "print 1;\n\nprint 2;\n{\n  print 3;\n}\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Format(tt.sources)
+			if err != nil {
+				t.Fatalf("Format() returned error: %s", err)
+			}
+			if got != tt.want {
+				t.Errorf("Format() = %q, want %q", got, tt.want)
+			}
+
+			again, err := Format(got)
+			if err != nil || again != got {
+				t.Errorf("Format() is not idempotent: %q, error %v", again, err)
+			}
+		})
+	}
+
+	t.Run("Invalid sources are not formatted", func(t *testing.T) {
+		_, err := Format("var = 1;")
+
+		want := "syntax error: [line 1]  at '='. Message: Expect variable name."
+		if err == nil || err.Error() != want {
+			t.Errorf("Format() error = %v, want %s", err, want)
+		}
+	})
+}
@@ -0,0 +1,41 @@
+package framing
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestConn_RoundTrip(t *testing.T) {
+	buffer := bytes.Buffer{}
+	writer := NewConn(nil, &buffer)
+	_ = writer.Write(map[string]int{"a": 1})
+	_ = writer.Write([]string{"ü"})
+
+	reader := NewConn(&buffer, nil)
+	for _, want := range []string{`{"a":1}`, `["ü"]`} {
+		body, err := reader.Read()
+		if err != nil || string(body) != want {
+			t.Errorf("Read() = %s, %v, want %s", body, err, want)
+		}
+	}
+	if _, err := reader.Read(); err != io.EOF {
+		t.Errorf("Read() error = %v, want io.EOF", err)
+	}
+}
+
+func TestConn_InvalidHeader(t *testing.T) {
+	for _, input := range []string{
+		"Content-Type: json\r\n\r\n{}",
+		"Content-Length: -1\r\n\r\n",
+		"Content-Length: 99999999999\r\n\r\n",
+		"Content-Length: 67108865\r\n\r\n",
+		"garbage\r\n\r\n",
+		"Content-Length: 10\r\n\r\n{}",
+	} {
+		if _, err := NewConn(strings.NewReader(input), nil).Read(); err == nil {
+			t.Errorf("Read(%q) expected error", input)
+		}
+	}
+}
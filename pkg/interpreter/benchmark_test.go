@@ -0,0 +1,71 @@
+package interpreter
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/parser"
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/scanner"
+)
+
+var benchmarks = []struct {
+	name    string
+	sources string
+}{
+	{
+		name: "Loop locals",
+		sources: `{
+  var total = 0;
+  for (var i = 0; i < 10000; i = i + 1) {
+    var square = i * i;
+    total = total + square;
+  }
+}`,
+	},
+	{
+		name: "Loop globals",
+		sources: `var total = 0;
+for (var i = 0; i < 10000; i = i + 1) {
+  total = total + i;
+}`,
+	},
+	{
+		name: "Recursion",
+		sources: `fun fib(n) {
+  if (n < 2) return n;
+  return fib(n - 1) + fib(n - 2);
+}
+fib(15);`,
+	},
+	{
+		name: "Closures",
+		sources: `fun counter() {
+  var count = 0;
+  fun increment() {
+    count = count + 1;
+    return count;
+  }
+  return increment;
+}
+var next = counter();
+for (var i = 0; i < 10000; i = i + 1) next();`,
+	},
+}
+
+func BenchmarkInterpreter_Interpret(b *testing.B) {
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			statements := parser.NewParser(scanner.NewScanner(bm.sources, nil).ScanTokens(), nil).Parse()
+			interp := NewInterpreter()
+			interp.stdout = io.Discard
+			b.ReportAllocs()
+			b.ResetTimer()
+			for n := 0; n < b.N; n++ {
+				if err := interp.Interpret(context.Background(), statements); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
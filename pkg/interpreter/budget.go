@@ -0,0 +1,133 @@
+package interpreter
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/scanner"
+)
+
+// Limits bound what a run may use, zero values are unlimited.
+type Limits struct {
+	// MaxSteps is the number of statements a run may execute.
+	MaxSteps int
+	// MaxDuration is the wall time of a run.
+	MaxDuration time.Duration
+	// MaxCallDepth is the number of Lox function calls in progress. It can only lower
+	// the "Stack overflow." depth, see LoxGo.SetMaxCallDepth.
+	MaxCallDepth int
+	// MaxCollectionSize is the number of elements of a list or a map made by natives,
+	// or bytes of a string read by natives or made by concatenation.
+	MaxCollectionSize int
+}
+
+// Limit names what a run ran out of.
+type Limit string
+
+const (
+	LimitSteps          = Limit("steps")
+	LimitDuration       = Limit("duration")
+	LimitCallDepth      = Limit("call depth")
+	LimitCollectionSize = Limit("collection size")
+	// LimitContext means the context of the run was canceled or reached its deadline.
+	LimitContext = Limit("context")
+)
+
+// LimitError stops a run exceeding its Limits or outliving its context,
+// Lox code can't catch it. For LimitContext it wraps the context error.
+type LimitError struct {
+	Limit Limit
+	// token is the call or the operator making a too large collection, other limits have no token.
+	token *scanner.Token
+	cause error
+}
+
+func (le *LimitError) Error() string {
+	return "Execution stopped: " + le.Message()
+}
+
+func (le *LimitError) Unwrap() error {
+	return le.cause
+}
+
+// Line is the source line of the call exceeding the limit, 0 if unknown.
+func (le *LimitError) Line() int {
+	if le.token == nil {
+		return 0
+	}
+	return le.token.Line()
+}
+
+// Message describes the exceeded limit.
+func (le *LimitError) Message() string {
+	if le.Limit == LimitContext {
+		return fmt.Sprintf("%s.", le.cause)
+	}
+	return fmt.Sprintf("%s limit exceeded.", le.Limit)
+}
+
+// contextCheckInterval is how many steps pass between context and wall time checks.
+const contextCheckInterval = 256
+
+// budget counts what a run used, it is shared by copies of the interpreter.
+type budget struct {
+	limits   Limits
+	ctx      context.Context
+	deadline time.Time
+	steps    int
+}
+
+// start begins counting a run.
+func (b *budget) start(ctx context.Context) {
+	b.ctx = ctx
+	b.steps = 0
+	b.deadline = time.Time{}
+	if b.limits.MaxDuration > 0 {
+		b.deadline = time.Now().Add(b.limits.MaxDuration)
+	}
+}
+
+// step counts an executed statement and checks the run may go on.
+func (b *budget) step() {
+	b.steps++
+	if b.limits.MaxSteps > 0 && b.steps > b.limits.MaxSteps {
+		panic(&LimitError{Limit: LimitSteps})
+	}
+	if b.steps%contextCheckInterval != 0 {
+		return
+	}
+	if err := b.ctx.Err(); err != nil {
+		panic(&LimitError{Limit: LimitContext, cause: err})
+	}
+	if !b.deadline.IsZero() && time.Now().After(b.deadline) {
+		panic(&LimitError{Limit: LimitDuration})
+	}
+}
+
+// checkCollection checks sizes of lists and maps returned by natives.
+// Natives making large collections check them while making them too, see collectionLimit.
+func (b *budget) checkCollection(paren scanner.Token, value Value) {
+	switch value := value.AsObject().(type) {
+	case *loxList:
+		b.checkSize(paren, len(value.elements))
+	case *loxMap:
+		b.checkSize(paren, len(value.keys))
+	}
+}
+
+// checkSize checks the size of a collection or a string made at the token.
+func (b *budget) checkSize(token scanner.Token, size int) {
+	if b.limits.MaxCollectionSize > 0 && size > b.limits.MaxCollectionSize {
+		panic(&LimitError{Limit: LimitCollectionSize, token: &token})
+	}
+}
+
+// collectionLimit is how many elements or bytes natives make at most before checkSize,
+// one more than the limit to tell exceeding it. It is -1 when sizes are unlimited.
+func (b *budget) collectionLimit() int {
+	if b.limits.MaxCollectionSize <= 0 {
+		return -1
+	}
+	return b.limits.MaxCollectionSize + 1
+}
@@ -0,0 +1,163 @@
+package interpreter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoxGo_RunContextLimits(t *testing.T) {
+	tests := []struct {
+		name     string
+		limits   Limits
+		sources  string
+		input    string
+		want     Limit
+		wantLine int
+	}{
+		{
+			name:    "Steps",
+			limits:  Limits{MaxSteps: 100},
+			sources: "while (true) {}",
+			want:    LimitSteps,
+		},
+		{
+			name:    "Duration",
+			limits:  Limits{MaxDuration: 10 * time.Millisecond},
+			sources: "var i = 0; while (true) { i = i + 1; }",
+			want:    LimitDuration,
+		},
+		{
+			name:    "Call depth",
+			limits:  Limits{MaxCallDepth: 50},
+			sources: "fun f(n) { return f(n + 1); } f(0);",
+			want:    LimitCallDepth,
+		},
+		{
+			name:     "Collection size",
+			limits:   Limits{MaxCollectionSize: 3},
+			sources:  "var small = list(1, 2, 3);\nvar large = list(1, 2, 3, 4);",
+			want:     LimitCollectionSize,
+			wantLine: 2,
+		},
+		{
+			name:     "Collection size of matches",
+			limits:   Limits{MaxCollectionSize: 3},
+			sources:  "var text = \"aaaa\";\nregexFindAll(\"a\", text);",
+			want:     LimitCollectionSize,
+			wantLine: 2,
+		},
+		{
+			name:     "Collection size of read input",
+			limits:   Limits{MaxCollectionSize: 3},
+			sources:  "print 1;\nreadAll();",
+			input:    "abcd",
+			want:     LimitCollectionSize,
+			wantLine: 2,
+		},
+		{
+			name:     "Collection size of read line",
+			limits:   Limits{MaxCollectionSize: 3},
+			sources:  "print readLine();\nreadLine();",
+			input:    "abc\r\nabcd\n",
+			want:     LimitCollectionSize,
+			wantLine: 2,
+		},
+		{
+			name:     "Collection size of replacement",
+			limits:   Limits{MaxCollectionSize: 3},
+			sources:  "print regexReplace(\".\", \"ab\", \"$0\");\nregexReplace(\".\", \"ab\", \"$0$0\");",
+			want:     LimitCollectionSize,
+			wantLine: 2,
+		},
+		{
+			name:     "Collection size of concatenation",
+			limits:   Limits{MaxCollectionSize: 3},
+			sources:  "var s = \"ab\";\ns = s + \"c\";\ns = s + \"d\";",
+			want:     LimitCollectionSize,
+			wantLine: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errorOutput := bytes.Buffer{}
+			lox := New()
+			lox.SetOutput(&bytes.Buffer{})
+			lox.SetErrorOutput(&errorOutput)
+			lox.SetInput(strings.NewReader(tt.input))
+			lox.SetLimits(tt.limits)
+
+			err := lox.RunContext(context.Background(), tt.sources)
+
+			var lErr *LimitError
+			if !errors.As(err, &lErr) || lErr.Limit != tt.want {
+				t.Fatalf("RunContext() error = %v, want %s limit error", err, tt.want)
+			}
+			if lErr.Line() != tt.wantLine {
+				t.Errorf("LimitError.Line() = %d, want %d", lErr.Line(), tt.wantLine)
+			}
+			if lox.ExitCode() != 70 {
+				t.Errorf("ExitCode() = %d, want 70", lox.ExitCode())
+			}
+			if errorOutput.Len() == 0 {
+				t.Errorf("RunContext() didn't report the error")
+			}
+		})
+	}
+
+	t.Run("Long lines are not read whole", func(t *testing.T) {
+		input := strings.NewReader(strings.Repeat("a", 1<<20) + "\n")
+		lox := New()
+		lox.SetErrorOutput(&bytes.Buffer{})
+		lox.SetInput(input)
+		lox.SetLimits(Limits{MaxCollectionSize: 10})
+
+		err := lox.RunContext(context.Background(), "readLine();")
+
+		var lErr *LimitError
+		if !errors.As(err, &lErr) || lErr.Limit != LimitCollectionSize {
+			t.Fatalf("RunContext() error = %v, want collection size limit error", err)
+		}
+		if read := input.Size() - int64(input.Len()); read > 1<<16 {
+			t.Errorf("readLine() read %d bytes of the line", read)
+		}
+	})
+
+	t.Run("Within limits", func(t *testing.T) {
+		lox := New()
+		lox.SetOutput(&bytes.Buffer{})
+		lox.SetLimits(Limits{MaxSteps: 100, MaxDuration: time.Second, MaxCallDepth: 3, MaxCollectionSize: 3})
+
+		err := lox.RunContext(context.Background(), `fun f(n) { if (n > 0) f(n - 1); } f(2); print list(1, 2, 3);
+print regexFindAll("a", "aaa"); print "ab" + "c";`)
+
+		if err != nil {
+			t.Errorf("RunContext() error = %v", err)
+		}
+	})
+}
+
+func TestLoxGo_RunContextCancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	lox := New()
+	lox.SetErrorOutput(&bytes.Buffer{})
+
+	err := lox.RunContext(ctx, "while (true) {}")
+
+	var lErr *LimitError
+	if !errors.As(err, &lErr) || lErr.Limit != LimitContext || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("RunContext() error = %v, want context deadline limit error", err)
+	}
+	if want := "Execution stopped: context deadline exceeded."; err.Error() != want {
+		t.Errorf("RunContext() error = %q, want %q", err.Error(), want)
+	}
+
+	// Limits apply to every run anew.
+	if err := lox.RunContext(context.Background(), "var done = true;"); err != nil {
+		t.Errorf("RunContext() after a stopped run error = %v", err)
+	}
+}
@@ -0,0 +1,166 @@
+package interpreter
+
+import (
+	"fmt"
+
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/parser/ast"
+)
+
+// variadicArity marks callables accepting any number of arguments.
+const variadicArity = -1
+
+type loxCallable interface {
+	arity() int
+	call(interpreter Interpreter, arguments []Value) (Value, error)
+}
+
+// callError is a runtime error without a token, the call site token is
+// attached to it by the interpreter.
+type callError struct {
+	message string
+}
+
+func newCallError(format string, args ...any) error {
+	return &callError{message: fmt.Sprintf(format, args...)}
+}
+
+func (ce *callError) Error() string {
+	return ce.message
+}
+
+// nativeFunction is a callable implemented in Go.
+// Errors returned by fn are reported as runtime errors at the call site.
+type nativeFunction struct {
+	name      string
+	argsCount int
+	fn        func(interpreter Interpreter, arguments []Value) (Value, error)
+}
+
+func newNativeFunction(
+	name string,
+	argsCount int,
+	fn func(interpreter Interpreter, arguments []Value) (Value, error),
+) *nativeFunction {
+	return &nativeFunction{
+		name:      name,
+		argsCount: argsCount,
+		fn:        fn,
+	}
+}
+
+func (n *nativeFunction) arity() int {
+	return n.argsCount
+}
+
+func (n *nativeFunction) call(interpreter Interpreter, arguments []Value) (Value, error) {
+	return n.fn(interpreter, arguments)
+}
+
+func (n *nativeFunction) String() string {
+	return "<native fn " + n.name + ">"
+}
+
+func defineNatives(env *Environment, natives ...*nativeFunction) {
+	for _, native := range natives {
+		env.define(native.name, ObjectValue(native))
+	}
+}
+
+// Argument helpers for natives, they fail with the same message for every native.
+
+func argumentError(native string, position int, expected string) error {
+	return newCallError("%s() argument %d must be %s.", native, position+1, expected)
+}
+
+func stringArgument(native string, arguments []Value, position int) (string, error) {
+	value, ok := arguments[position].AsString()
+	if !ok {
+		return "", argumentError(native, position, "a string")
+	}
+	return value, nil
+}
+
+func numberArgument(native string, arguments []Value, position int) (float64, error) {
+	value, ok := arguments[position].AsNumber()
+	if !ok {
+		return 0, argumentError(native, position, "a number")
+	}
+	return value, nil
+}
+
+func listArgument(native string, arguments []Value, position int) (*loxList, error) {
+	value, ok := arguments[position].AsObject().(*loxList)
+	if !ok {
+		return nil, argumentError(native, position, "a list")
+	}
+	return value, nil
+}
+
+// returnValue unwinds the interpreter stack from return statement to the function call.
+type returnValue struct {
+	value Value
+}
+
+// loxFunction is a user-defined function with the environment it was declared in.
+type loxFunction struct {
+	declaration *ast.Function
+	closure     *Environment
+}
+
+func newLoxFunction(declaration *ast.Function, closure *Environment) *loxFunction {
+	return &loxFunction{
+		declaration: declaration,
+		closure:     closure,
+	}
+}
+
+func (f *loxFunction) arity() int {
+	return len(f.declaration.Params)
+}
+
+func (f *loxFunction) call(interpreter Interpreter, arguments []Value) (result Value, err error) {
+	environment := newLocalEnvironment(f.closure, f.declaration.Scope)
+	for j, index := range f.declaration.Scope.Params {
+		environment.defineAt(index, arguments[j])
+	}
+	interpreter.calls.push(f.declaration.Name.Lexeme(), interpreter.budget.limits.MaxCallDepth)
+	if interpreter.debugger != nil {
+		interpreter.debugger.enterFunction(f)
+		defer interpreter.debugger.exitFunction()
+	}
+
+	defer func() {
+		recovered := recover()
+		if recovered == nil {
+			interpreter.calls.pop()
+			return
+		}
+		returned, ok := recovered.(returnValue)
+		if !ok {
+			panic(recovered)
+		}
+		interpreter.calls.pop()
+		result = returned.value
+	}()
+	interpreter.executeBlock(f.declaration.Body, environment)
+	return Nil, nil
+}
+
+func (f *loxFunction) String() string {
+	return "<fn " + f.declaration.Name.Lexeme() + ">"
+}
+
+// callCallable calls a Lox value from natives, e.g. callbacks passed as arguments.
+func callCallable(interpreter Interpreter, native string, callee Value, arguments ...Value) (Value, error) {
+	function, ok := callee.AsObject().(loxCallable)
+	if !ok {
+		return Nil, newCallError("%s() callback must be a function.", native)
+	}
+	if function.arity() != variadicArity && function.arity() != len(arguments) {
+		return Nil, newCallError(
+			"%s() callback must accept %d arguments, but accepts %d.",
+			native, len(arguments), function.arity(),
+		)
+	}
+	return function.call(interpreter, arguments)
+}
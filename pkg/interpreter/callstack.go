@@ -0,0 +1,71 @@
+package interpreter
+
+import (
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/scanner"
+)
+
+// DefaultMaxCallDepth bounds recursion well below the Go stack limit,
+// a Lox call takes a few kilobytes of it.
+const DefaultMaxCallDepth = 10000
+
+// StackFrame is a Lox function in progress when a runtime error happened,
+// Line is the line it was executing.
+type StackFrame struct {
+	Function string
+	Line     int
+}
+
+// callStack tracks Lox function calls, it is shared by copies of the interpreter.
+type callStack struct {
+	maxDepth int
+	// frames are calls in progress, the outermost first.
+	frames []callFrame
+	// paren is the call site of the call being made, natives calling callbacks share theirs.
+	paren scanner.Token
+}
+
+type callFrame struct {
+	function string
+	paren    scanner.Token
+}
+
+// push starts a call of the function at the current call site. A positive limit,
+// Limits.MaxCallDepth, lowers maxDepth and calls beyond it fail with a *LimitError.
+func (s *callStack) push(function string, limit int) {
+	if limit > 0 && limit <= s.maxDepth && len(s.frames) >= limit {
+		panic(&LimitError{Limit: LimitCallDepth})
+	}
+	if len(s.frames) >= s.maxDepth {
+		panic(NewRuntimeError(s.paren, "Stack overflow."))
+	}
+	s.frames = append(s.frames, callFrame{function: function, paren: s.paren})
+}
+
+// pop ends the innermost call, calls ended by runtime errors are not popped,
+// so the stack is there to be traced.
+func (s *callStack) pop() {
+	s.frames = s.frames[:len(s.frames)-1]
+}
+
+// trace is the stack from the innermost frame executing the line to the top level code.
+func (s *callStack) trace(line int) []StackFrame {
+	trace := make([]StackFrame, 0, len(s.frames)+1)
+	for index := len(s.frames) - 1; index >= 0; index-- {
+		trace = append(trace, StackFrame{Function: s.frames[index].function, Line: line})
+		line = s.frames[index].paren.Line()
+	}
+	return append(trace, StackFrame{Function: scriptFunction, Line: line})
+}
+
+func (s *callStack) reset() {
+	s.frames = s.frames[:0]
+}
+
+// traceRuntimeError attaches the Lox stack to the error ending the run and resets the stack.
+// It must be deferred before catchRuntimeError, so it runs after it.
+func (i Interpreter) traceRuntimeError(err *error) {
+	if rErr, ok := (*err).(*RuntimeError); ok && rErr.stack == nil {
+		rErr.stack = i.calls.trace(rErr.Line())
+	}
+	i.calls.reset()
+}
@@ -0,0 +1,133 @@
+package interpreter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoxGo_RuntimeErrorStack(t *testing.T) {
+	tests := []struct {
+		name       string
+		sources    string
+		want       []StackFrame
+		wantOutput string
+	}{
+		{
+			name:    "Top level",
+			sources: "print 1 / nil;",
+			want:    []StackFrame{{Function: "script", Line: 1}},
+		},
+		{
+			name:    "Nested calls",
+			sources: "fun inner() {\n  return 1 / nil;\n}\nfun outer() {\n  inner();\n}\nouter();",
+			want: []StackFrame{
+				{Function: "inner", Line: 2},
+				{Function: "outer", Line: 5},
+				{Function: "script", Line: 7},
+			},
+			wantOutput: "[line 2] in inner()\n[line 5] in outer()\n[line 7] in script\n",
+		},
+		{
+			name:    "Callback of native",
+			sources: "fun replace(match) {\n  return match / 2;\n}\nregexReplace(regexCompile(\"a\"), \"abc\", replace);",
+			want: []StackFrame{
+				{Function: "replace", Line: 2},
+				{Function: "script", Line: 4},
+			},
+			wantOutput: "[line 2] in replace()\n[line 4] in script\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errorOutput := bytes.Buffer{}
+			lox := New()
+			lox.SetErrorOutput(&errorOutput)
+
+			err := lox.RunContext(context.Background(), tt.sources)
+
+			var rErr *RuntimeError
+			if !errors.As(err, &rErr) {
+				t.Fatalf("RunContext() error = %v, want a runtime error", err)
+			}
+			if !reflect.DeepEqual(rErr.Stack(), tt.want) {
+				t.Errorf("RuntimeError.Stack() = %v, want %v", rErr.Stack(), tt.want)
+			}
+			if !strings.HasSuffix(errorOutput.String(), tt.wantOutput) {
+				t.Errorf("error output = %q, want suffix %q", errorOutput.String(), tt.wantOutput)
+			}
+			if tt.wantOutput == "" && strings.Contains(errorOutput.String(), "] in ") {
+				t.Errorf("error output = %q, want no stack", errorOutput.String())
+			}
+		})
+	}
+}
+
+func TestLoxGo_SetMaxCallDepth(t *testing.T) {
+	errorOutput := bytes.Buffer{}
+	output := bytes.Buffer{}
+	lox := New()
+	lox.SetOutput(&output)
+	lox.SetErrorOutput(&errorOutput)
+	lox.SetMaxCallDepth(20)
+
+	err := lox.RunContext(context.Background(), "fun f(n) {\n  return f(n + 1);\n}\nf(0);")
+
+	var rErr *RuntimeError
+	if !errors.As(err, &rErr) || rErr.Message() != "Stack overflow." {
+		t.Fatalf("RunContext() error = %v, want stack overflow", err)
+	}
+	if len(rErr.Stack()) != 21 {
+		t.Errorf("len(RuntimeError.Stack()) = %d, want 21", len(rErr.Stack()))
+	}
+	want := "[line 2] in f() (20 times)\n[line 4] in script\n"
+	if !strings.HasSuffix(errorOutput.String(), want) {
+		t.Errorf("error output = %q, want suffix %q", errorOutput.String(), want)
+	}
+
+	// The stack is reset by the error, the next run may go as deep.
+	if err := lox.RunContext(context.Background(), "fun g(n) { if (n > 0) g(n - 1); } g(19); print \"done\";"); err != nil {
+		t.Errorf("RunContext() error = %v", err)
+	}
+	if output.String() != "done\n" {
+		t.Errorf("output = %q, want %q", output.String(), "done\n")
+	}
+}
+
+func TestLoxGo_MaxCallDepthLimit(t *testing.T) {
+	tests := []struct {
+		name      string
+		maxDepth  int
+		limit     int
+		wantLimit bool
+	}{
+		{name: "Limit lowers the stack overflow depth", maxDepth: 20, limit: 10, wantLimit: true},
+		{name: "Stack overflows below the limit", maxDepth: 20, limit: 30, wantLimit: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lox := New()
+			lox.SetErrorOutput(&bytes.Buffer{})
+			lox.SetMaxCallDepth(tt.maxDepth)
+			lox.SetLimits(Limits{MaxCallDepth: tt.limit})
+
+			err := lox.RunContext(context.Background(), "fun f(n) { return f(n + 1); } f(0);")
+
+			var limitErr *LimitError
+			if got := errors.As(err, &limitErr); got != tt.wantLimit {
+				t.Fatalf("RunContext() error = %v, want limit error %v", err, tt.wantLimit)
+			}
+			var rErr *RuntimeError
+			if !tt.wantLimit && (!errors.As(err, &rErr) || rErr.Message() != "Stack overflow.") {
+				t.Errorf("RunContext() error = %v, want stack overflow", err)
+			}
+			// The limit error leaves the stack empty for the next run.
+			if err := lox.RunContext(context.Background(), "fun g(n) { if (n > 1) g(n - 1); } g(5);"); err != nil {
+				t.Errorf("RunContext() error = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package interpreter
+
+import (
+	"sort"
+
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/parser"
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/parser/ast"
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/scanner"
+)
+
+// Coverage is ParseHooks recording which lines of a file ran and which way its
+// if and while conditions went. Runs of the same sources in any interpreters add up,
+// e.g. of every test function of a test file.
+type Coverage struct {
+	FileName string
+	Sources  string
+
+	lines    map[int]int
+	branches map[int]*BranchPoint
+	// Nodes of the last parsed sources.
+	statementLines map[ast.Stmt]int
+	conditions     map[ast.Expr]*BranchPoint
+}
+
+// BranchPoint is an if statement taking Taken[0] times its then branch and Taken[1] times
+// the else one, present or not, or a while loop running its body Taken[0] times and
+// exiting Taken[1] times.
+type BranchPoint struct {
+	Line  int
+	While bool
+	Taken [2]int
+	// position is the token index of the statement, the same in every parse of the sources.
+	position int
+}
+
+func NewCoverage(fileName string, sources string) *Coverage {
+	return &Coverage{
+		FileName:       fileName,
+		Sources:        sources,
+		lines:          map[int]int{},
+		branches:       map[int]*BranchPoint{},
+		statementLines: map[ast.Stmt]int{},
+		conditions:     map[ast.Expr]*BranchPoint{},
+	}
+}
+
+// Parsed registers statement lines as executable and if and while statements as branch points.
+// Loops of for statements are made up by the parser and have no spans, they are found
+// in the blocks holding them.
+func (c *Coverage) Parsed(tokens []scanner.Token, spans map[any]parser.Span) {
+	c.statementLines = map[ast.Stmt]int{}
+	c.conditions = map[ast.Expr]*BranchPoint{}
+	for node, span := range spans {
+		stmt, ok := node.(ast.Stmt)
+		if !ok || span.First >= len(tokens) {
+			continue
+		}
+		line := tokens[span.First].Line()
+		switch stmt := stmt.(type) {
+		case *ast.Block:
+			for _, inner := range stmt.Statements {
+				loop, ok := inner.(*ast.While)
+				if _, spanned := spans[inner]; ok && !spanned {
+					if conditionSpan, ok := spans[loop.Condition]; ok {
+						c.addBranchPoint(loop.Condition, tokens[conditionSpan.First].Line(), true, conditionSpan.First)
+					}
+				}
+			}
+			continue
+		case *ast.If:
+			c.addBranchPoint(stmt.Condition, line, false, span.First)
+		case *ast.While:
+			c.addBranchPoint(stmt.Condition, line, true, span.First)
+		}
+		c.statementLines[stmt] = line
+		if _, ok := c.lines[line]; !ok {
+			c.lines[line] = 0
+		}
+	}
+}
+
+func (c *Coverage) addBranchPoint(condition ast.Expr, line int, while bool, position int) {
+	point, ok := c.branches[position]
+	if !ok {
+		point = &BranchPoint{Line: line, While: while, position: position}
+		c.branches[position] = point
+	}
+	c.conditions[condition] = point
+}
+
+func (c *Coverage) BeforeExecute(stmt ast.Stmt) {
+	if line, ok := c.statementLines[stmt]; ok {
+		c.lines[line]++
+	}
+}
+
+func (c *Coverage) AfterExecute(ast.Stmt) {}
+
+func (c *Coverage) BeforeEvaluate(ast.Expr) {}
+
+func (c *Coverage) AfterEvaluate(expr ast.Expr, value Value) {
+	point, ok := c.conditions[expr]
+	if !ok {
+		return
+	}
+	if (Interpreter{}).isTruthy(value) {
+		point.Taken[0]++
+	} else {
+		point.Taken[1]++
+	}
+}
+
+func (c *Coverage) Define(string, Value) {}
+
+func (c *Coverage) Assign(string, Value) {}
+
+func (c *Coverage) RuntimeError(*RuntimeError) {}
+
+// Lines maps executable lines to how many times statements on them ran.
+func (c *Coverage) Lines() map[int]int {
+	return c.lines
+}
+
+// Branches are branch points in source order.
+func (c *Coverage) Branches() []*BranchPoint {
+	points := make([]*BranchPoint, 0, len(c.branches))
+	for _, point := range c.branches {
+		points = append(points, point)
+	}
+	sort.Slice(points, func(a, b int) bool {
+		return points[a].position < points[b].position
+	})
+	return points
+}
+
+// CoverageSummary counts covered lines and taken branches of files.
+type CoverageSummary struct {
+	Lines, LinesHit       int
+	Branches, BranchesHit int
+}
+
+func SummarizeCoverage(files ...*Coverage) CoverageSummary {
+	summary := CoverageSummary{}
+	for _, file := range files {
+		for _, hits := range file.lines {
+			summary.Lines++
+			if hits > 0 {
+				summary.LinesHit++
+			}
+		}
+		for _, point := range file.branches {
+			for _, taken := range point.Taken {
+				summary.Branches++
+				if taken > 0 {
+					summary.BranchesHit++
+				}
+			}
+		}
+	}
+	return summary
+}
+
+// LinePercent is the percentage of executable lines that ran, 100 without such lines.
+func (s CoverageSummary) LinePercent() float64 {
+	return percentOf(s.LinesHit, s.Lines)
+}
+
+// BranchPercent is the percentage of branches taken, 100 without branches.
+func (s CoverageSummary) BranchPercent() float64 {
+	return percentOf(s.BranchesHit, s.Branches)
+}
+
+func percentOf(part int, total int) float64 {
+	if total == 0 {
+		return 100
+	}
+	return 100 * float64(part) / float64(total)
+}
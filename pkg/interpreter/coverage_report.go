@@ -0,0 +1,131 @@
+package interpreter
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+)
+
+// WriteLCOV writes coverage of files in the LCOV tracefile format read by genhtml and editors.
+// Branches of a branch point are 0 for then or loop body and 1 for else or loop exit.
+func WriteLCOV(out io.Writer, files ...*Coverage) error {
+	report := strings.Builder{}
+	for _, file := range files {
+		fmt.Fprintf(&report, "TN:\nSF:%s\n", file.FileName)
+		summary := SummarizeCoverage(file)
+		for block, point := range file.Branches() {
+			for branch, taken := range point.Taken {
+				count := fmt.Sprint(taken)
+				if point.Taken == [2]int{} {
+					// The condition was never evaluated.
+					count = "-"
+				}
+				fmt.Fprintf(&report, "BRDA:%d,%d,%d,%s\n", point.Line, block, branch, count)
+			}
+		}
+		fmt.Fprintf(&report, "BRF:%d\nBRH:%d\n", summary.Branches, summary.BranchesHit)
+		for _, line := range sortedLines(file) {
+			fmt.Fprintf(&report, "DA:%d,%d\n", line, file.lines[line])
+		}
+		fmt.Fprintf(&report, "LF:%d\nLH:%d\nend_of_record\n", summary.Lines, summary.LinesHit)
+	}
+	_, err := io.WriteString(out, report.String())
+	return err
+}
+
+func sortedLines(file *Coverage) []int {
+	lines := make([]int, 0, len(file.lines))
+	for line := range file.lines {
+		lines = append(lines, line)
+	}
+	sort.Ints(lines)
+	return lines
+}
+
+var coverageTemplate = template.Must(template.New("coverage").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Lox coverage</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; font-family: monospace; }
+td { padding: 0 8px; white-space: pre; }
+td.number { text-align: right; color: #888; }
+tr.covered td.source { background: #dfd; }
+tr.missed td.source { background: #fdd; }
+tr.partial td.source { background: #ffd; }
+</style>
+</head>
+<body>
+<h1>Coverage: {{printf "%.1f" .Summary.LinePercent}}% of lines, {{printf "%.1f" .Summary.BranchPercent}}% of branches</h1>
+{{range .Files}}
+<h2 id="{{.Name}}">{{.Name}}: {{printf "%.1f" .Summary.LinePercent}}% of lines, {{printf "%.1f" .Summary.BranchPercent}}% of branches</h2>
+<table>
+{{range .Lines}}<tr class="{{.Class}}" title="{{.Title}}"><td class="number">{{.Number}}</td><td class="number">{{.Hits}}</td><td class="source">{{.Source}}</td></tr>
+{{end}}</table>
+{{end}}
+</body>
+</html>
+`))
+
+type coveragePage struct {
+	Summary CoverageSummary
+	Files   []coverageFile
+}
+
+type coverageFile struct {
+	Name    string
+	Summary CoverageSummary
+	Lines   []coverageLine
+}
+
+// coverageLine is a source line, Hits is empty for lines without statements.
+type coverageLine struct {
+	Number int
+	Hits   string
+	Class  string
+	Title  string
+	Source string
+}
+
+// WriteCoverageHTML writes sources of files annotated with how many times lines ran.
+// Lines that never ran are red, lines with branches never taken are yellow
+// and the branches are listed in line titles.
+func WriteCoverageHTML(out io.Writer, files ...*Coverage) error {
+	page := coveragePage{Summary: SummarizeCoverage(files...)}
+	for _, file := range files {
+		branches := map[int][]*BranchPoint{}
+		for _, point := range file.Branches() {
+			branches[point.Line] = append(branches[point.Line], point)
+		}
+		annotated := coverageFile{Name: file.FileName, Summary: SummarizeCoverage(file)}
+		for index, source := range strings.Split(strings.TrimSuffix(file.Sources, "\n"), "\n") {
+			line := coverageLine{Number: index + 1, Source: source}
+			if hits, ok := file.lines[line.Number]; ok {
+				line.Hits = fmt.Sprint(hits)
+				line.Class = "covered"
+				if hits == 0 {
+					line.Class = "missed"
+				}
+			}
+			titles := make([]string, 0, len(branches[line.Number]))
+			for _, point := range branches[line.Number] {
+				names := [2]string{"then", "else"}
+				if point.While {
+					names = [2]string{"body", "exit"}
+				}
+				titles = append(titles, fmt.Sprintf("%s %d, %s %d", names[0], point.Taken[0], names[1], point.Taken[1]))
+				if line.Class == "covered" && (point.Taken[0] == 0 || point.Taken[1] == 0) {
+					line.Class = "partial"
+				}
+			}
+			line.Title = strings.Join(titles, "; ")
+			annotated.Lines = append(annotated.Lines, line)
+		}
+		page.Files = append(page.Files, annotated)
+	}
+	return coverageTemplate.Execute(out, page)
+}
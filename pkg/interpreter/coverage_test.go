@@ -0,0 +1,114 @@
+package interpreter
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const coveredSources = `fun abs(n) {
+  if (n < 0) return -n;
+  return n;
+}
+for (var i = 0; i < 2; i = i + 1) abs(i);
+while (false) {
+  print "never";
+}
+`
+
+// cover runs sources once per call of the test in fresh interpreters.
+func cover(t *testing.T, runs int) *Coverage {
+	t.Helper()
+	coverage := NewCoverage("abs.lox", coveredSources)
+	for run := 0; run < runs; run++ {
+		lox := New()
+		lox.SetOutput(io.Discard)
+		lox.SetHooks(coverage)
+		lox.Run(coveredSources)
+		if lox.Failed() {
+			t.Fatalf("Run() failed")
+		}
+	}
+	return coverage
+}
+
+func TestCoverage(t *testing.T) {
+	coverage := cover(t, 2)
+
+	if want := map[int]int{1: 2, 2: 4, 3: 4, 5: 6, 6: 2, 7: 0}; !reflect.DeepEqual(coverage.Lines(), want) {
+		t.Errorf("Lines() = %v, want %v", coverage.Lines(), want)
+	}
+	var got []BranchPoint
+	for _, point := range coverage.Branches() {
+		got = append(got, BranchPoint{Line: point.Line, While: point.While, Taken: point.Taken})
+	}
+	want := []BranchPoint{
+		{Line: 2, Taken: [2]int{0, 4}},
+		{Line: 5, While: true, Taken: [2]int{4, 2}},
+		{Line: 6, While: true, Taken: [2]int{0, 2}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Branches() = %+v, want %+v", got, want)
+	}
+	summary := SummarizeCoverage(coverage)
+	if want := (CoverageSummary{Lines: 6, LinesHit: 5, Branches: 6, BranchesHit: 4}); summary != want {
+		t.Errorf("SummarizeCoverage() = %+v, want %+v", summary, want)
+	}
+}
+
+func TestWriteLCOV(t *testing.T) {
+	out := bytes.Buffer{}
+	if err := WriteLCOV(&out, cover(t, 1), NewCoverage("empty.lox", "")); err != nil {
+		t.Fatal(err)
+	}
+	want := `TN:
+SF:abs.lox
+BRDA:2,0,0,0
+BRDA:2,0,1,2
+BRDA:5,1,0,2
+BRDA:5,1,1,1
+BRDA:6,2,0,0
+BRDA:6,2,1,1
+BRF:6
+BRH:4
+DA:1,1
+DA:2,2
+DA:3,2
+DA:5,3
+DA:6,1
+DA:7,0
+LF:6
+LH:5
+end_of_record
+TN:
+SF:empty.lox
+BRF:0
+BRH:0
+LF:0
+LH:0
+end_of_record
+`
+	if got := out.String(); got != want {
+		t.Errorf("WriteLCOV() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWriteCoverageHTML(t *testing.T) {
+	out := bytes.Buffer{}
+	if err := WriteCoverageHTML(&out, cover(t, 1)); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"<h1>Coverage: 83.3% of lines, 66.7% of branches</h1>",
+		`<tr class="partial" title="then 0, else 2"><td class="number">2</td><td class="number">2</td><td class="source">  if (n &lt; 0) return -n;</td></tr>`,
+		`<tr class="covered" title="body 2, exit 1"><td class="number">5</td>`,
+		`<tr class="missed" title=""><td class="number">7</td><td class="number">0</td>`,
+		`<tr class="" title=""><td class="number">4</td><td class="number"></td><td class="source">}</td></tr>`,
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("WriteCoverageHTML() output doesn't contain %q:\n%s", want, out.String())
+		}
+	}
+}
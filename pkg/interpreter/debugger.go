@@ -0,0 +1,474 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/parser"
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/parser/ast"
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/scanner"
+)
+
+// ErrNotStopped is returned by Debugger methods which need the program to be stopped.
+var ErrNotStopped = errors.New("program is not stopped")
+
+// StopReason tells why a debugged program stopped.
+type StopReason string
+
+const (
+	StopEntry      = StopReason("entry")
+	StopBreakpoint = StopReason("breakpoint")
+	StopStep       = StopReason("step")
+	StopPause      = StopReason("pause")
+	StopWatch      = StopReason("watch")
+)
+
+type Stop struct {
+	Reason StopReason
+	Line   int
+	// Description tells details, e.g. old and new values of a watched variable.
+	Description string
+}
+
+// Frame is a Lox call frame, the script itself is the outermost one.
+type Frame struct {
+	Name string
+	Line int
+}
+
+// Scope is an environment of the chain from a frame to globals.
+type Scope struct {
+	Name      string
+	Variables []Variable
+}
+
+type Variable struct {
+	Name  string
+	Value string
+}
+
+type stepMode int
+
+const (
+	runMode stepMode = iota
+	stepInMode
+	stepOverMode
+	stepOutMode
+)
+
+type debugFrame struct {
+	name string
+	// line of the last executed statement of the frame.
+	line        int
+	environment *Environment
+}
+
+// Debugger stops a program run by LoxGo on breakpoints and steps, see LoxGo.SetDebugger.
+// The program runs in its own goroutine and blocks while stopped, other goroutines
+// control it with Debugger methods, inspection is done on the program goroutine.
+//
+// Stops happen when execution enters a new line of a frame or starts a loop iteration,
+// so a line with several statements is stepped over at once.
+type Debugger struct {
+	onStop func(Stop)
+
+	mu          sync.Mutex
+	breakpoints map[int]ast.Expr
+	watches     map[string]bool
+	stopOnEntry bool
+	paused      bool
+	stopped     bool
+	terminated  bool
+	// resumed is closed when the program resumes from the current stop.
+	resumed chan struct{}
+
+	// Fields below are used on the program goroutine only.
+	commands    chan func() (resume bool)
+	lines       map[ast.Stmt]int
+	frames      []*debugFrame
+	interpreter Interpreter
+	mode        stepMode
+	stepDepth   int
+	evaluating  bool
+}
+
+// NewDebugger creates a debugger calling onStop on the program goroutine every time it stops.
+func NewDebugger(onStop func(Stop)) *Debugger {
+	return &Debugger{
+		onStop:      onStop,
+		breakpoints: make(map[int]ast.Expr),
+		watches:     make(map[string]bool),
+		commands:    make(chan func() bool),
+		lines:       make(map[ast.Stmt]int),
+	}
+}
+
+// StopOnEntry makes the program stop before its first statement.
+func (d *Debugger) StopOnEntry() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.stopOnEntry = true
+}
+
+// SetBreakpoint stops the program at the line, but only when the condition is truthy
+// if it is not empty. Conditions failing with runtime errors stop the program too.
+func (d *Debugger) SetBreakpoint(line int, condition string) error {
+	var expr ast.Expr
+	if strings.TrimSpace(condition) != "" {
+		var err error
+		if expr, err = parseExpression(condition); err != nil {
+			return err
+		}
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.breakpoints[line] = expr
+	return nil
+}
+
+func (d *Debugger) ClearBreakpoints() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.breakpoints = make(map[int]ast.Expr)
+}
+
+// Watch stops the program after an assignment changes a variable with the name in any scope.
+func (d *Debugger) Watch(name string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.watches[name] = true
+}
+
+// Pause stops the running program before its next statement.
+func (d *Debugger) Pause() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.paused = true
+}
+
+func (d *Debugger) Continue() error {
+	return d.resume(runMode)
+}
+
+// StepIn stops on the next line, inside called functions too.
+func (d *Debugger) StepIn() error {
+	return d.resume(stepInMode)
+}
+
+// StepOver stops on the next line of the current function or its callers.
+func (d *Debugger) StepOver() error {
+	return d.resume(stepOverMode)
+}
+
+// StepOut stops on the next line after the current function returns.
+func (d *Debugger) StepOut() error {
+	return d.resume(stepOutMode)
+}
+
+// Terminate aborts the program with a runtime error before its next statement.
+func (d *Debugger) Terminate() {
+	d.mu.Lock()
+	d.terminated = true
+	stopped := d.stopped
+	d.mu.Unlock()
+	if stopped {
+		_ = d.command(func() bool { return true })
+	}
+}
+
+// StackTrace returns frames of the stopped program, the innermost first.
+func (d *Debugger) StackTrace() ([]Frame, error) {
+	var frames []Frame
+	err := d.query(func() {
+		for j := len(d.frames) - 1; j >= 0; j-- {
+			frames = append(frames, Frame{Name: d.frames[j].name, Line: d.frames[j].line})
+		}
+	})
+	return frames, err
+}
+
+// Scopes returns the environment chain of a frame by its StackTrace index,
+// from locals to globals. Native functions are not listed.
+func (d *Debugger) Scopes(frame int) ([]Scope, error) {
+	var scopes []Scope
+	var frameErr error
+	err := d.query(func() {
+		environment, err := d.frameEnvironment(frame)
+		if err != nil {
+			frameErr = err
+			return
+		}
+		for env, depth := environment, 0; env != nil; env, depth = env.enclosing, depth+1 {
+			scope := Scope{Name: "Locals"}
+			switch {
+			case env.enclosing == nil:
+				scope.Name = "Globals"
+			case depth > 0:
+				scope.Name = fmt.Sprintf("Enclosing #%d", depth)
+			}
+			for name, value := range env.values {
+				if _, ok := value.AsObject().(*nativeFunction); ok {
+					continue
+				}
+				scope.Variables = append(scope.Variables, Variable{Name: name, Value: d.interpreter.stringify(value)})
+			}
+			for index, name := range env.names[:env.defined] {
+				scope.Variables = append(scope.Variables, Variable{Name: name, Value: d.interpreter.stringify(env.slots[index])})
+			}
+			sort.Slice(scope.Variables, func(i, j int) bool {
+				return scope.Variables[i].Name < scope.Variables[j].Name
+			})
+			scopes = append(scopes, scope)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return scopes, frameErr
+}
+
+// Evaluate evaluates a Lox expression in the environment of a frame by its StackTrace index.
+// Breakpoints are ignored while evaluating.
+func (d *Debugger) Evaluate(frame int, expression string) (string, error) {
+	expr, err := parseExpression(expression)
+	if err != nil {
+		return "", err
+	}
+	var result string
+	var evalErr error
+	err = d.query(func() {
+		environment, err := d.frameEnvironment(frame)
+		if err != nil {
+			evalErr = err
+			return
+		}
+		var value Value
+		value, evalErr = d.evaluateIn(environment, expr)
+		result = d.interpreter.stringify(value)
+	})
+	if err != nil {
+		return "", err
+	}
+	return result, evalErr
+}
+
+func (d *Debugger) frameEnvironment(frame int) (*Environment, error) {
+	if frame < 0 || frame >= len(d.frames) {
+		return nil, fmt.Errorf("no frame %d", frame)
+	}
+	return d.frames[len(d.frames)-1-frame].environment, nil
+}
+
+func (d *Debugger) evaluateIn(environment *Environment, expr ast.Expr) (value Value, err error) {
+	d.evaluating = true
+	defer func() {
+		d.evaluating = false
+	}()
+	interpreter := d.interpreter
+	// Frames of calls failed in the expression are not the program's.
+	depth := len(interpreter.calls.frames)
+	defer func() {
+		interpreter.calls.frames = interpreter.calls.frames[:depth]
+	}()
+	defer catchRuntimeError(&err)
+	interpreter.environment = environment
+	resolveIn(environment, expr)
+	return interpreter.evaluate(expr), nil
+}
+
+func (d *Debugger) resume(mode stepMode) error {
+	return d.command(func() bool {
+		d.mode = mode
+		d.stepDepth = len(d.frames)
+		return true
+	})
+}
+
+func (d *Debugger) query(fn func()) error {
+	done := make(chan struct{})
+	err := d.command(func() bool {
+		fn()
+		close(done)
+		return false
+	})
+	if err != nil {
+		return err
+	}
+	<-done
+	return nil
+}
+
+// command sends fn to the stopped program goroutine, the program resumes when fn returns true.
+// Commands racing with a resuming one fail with ErrNotStopped instead of waiting for the next stop.
+func (d *Debugger) command(fn func() bool) error {
+	d.mu.Lock()
+	stopped, resumed := d.stopped, d.resumed
+	d.mu.Unlock()
+	if !stopped {
+		return ErrNotStopped
+	}
+	select {
+	case d.commands <- fn:
+		return nil
+	case <-resumed:
+		return ErrNotStopped
+	}
+}
+
+// addLines registers lines of parsed statements, statements without lines are never stopped at.
+func (d *Debugger) addLines(tokens []scanner.Token, spans map[any]parser.Span) {
+	for node, span := range spans {
+		if stmt, ok := node.(ast.Stmt); ok && span.First < len(tokens) {
+			d.lines[stmt] = tokens[span.First].Line()
+		}
+	}
+}
+
+func (d *Debugger) enterFunction(function *loxFunction) {
+	if d.evaluating {
+		return
+	}
+	d.frames = append(d.frames, &debugFrame{name: function.declaration.Name.Lexeme()})
+}
+
+func (d *Debugger) exitFunction() {
+	if d.evaluating {
+		return
+	}
+	d.frames = d.frames[:len(d.frames)-1]
+}
+
+// nextIteration makes the loop body lines new again, so they stop on every iteration.
+func (d *Debugger) nextIteration() {
+	if d.evaluating || len(d.frames) == 0 {
+		return
+	}
+	d.frames[len(d.frames)-1].line = 0
+}
+
+// beforeStatement is called by the interpreter before executing every statement.
+func (d *Debugger) beforeStatement(i Interpreter, stmt ast.Stmt) {
+	if d.evaluating {
+		return
+	}
+	d.checkTerminated(d.lines[stmt])
+	line, ok := d.lines[stmt]
+	if _, isBlock := stmt.(*ast.Block); !ok || isBlock {
+		return
+	}
+	if len(d.frames) == 0 {
+		d.frames = append(d.frames, &debugFrame{name: "<script>"})
+	}
+	frame := d.frames[len(d.frames)-1]
+	frame.environment = i.environment
+	d.interpreter = i
+	if frame.line == line {
+		return
+	}
+	frame.line = line
+	if reason, stop := d.shouldStop(line); stop {
+		d.stop(Stop{Reason: reason, Line: line})
+		d.checkTerminated(line)
+	}
+}
+
+// afterAssign is called by the interpreter when an assignment changed a variable.
+func (d *Debugger) afterAssign(i Interpreter, name scanner.Token, previous, value Value) {
+	if d.evaluating {
+		return
+	}
+	d.mu.Lock()
+	watched := d.watches[name.Lexeme()]
+	d.mu.Unlock()
+	if !watched || i.isEqual(previous, value) {
+		return
+	}
+	d.interpreter = i
+	if len(d.frames) > 0 {
+		d.frames[len(d.frames)-1].environment = i.environment
+	}
+	d.stop(Stop{
+		Reason:      StopWatch,
+		Line:        name.Line(),
+		Description: fmt.Sprintf("%s: %s -> %s", name.Lexeme(), i.stringify(previous), i.stringify(value)),
+	})
+	d.checkTerminated(name.Line())
+}
+
+func (d *Debugger) checkTerminated(line int) {
+	d.mu.Lock()
+	terminated := d.terminated
+	d.mu.Unlock()
+	if terminated {
+		panic(NewRuntimeError(scanner.NewToken(scanner.EOF, "", nil, line), "Terminated by debugger."))
+	}
+}
+
+func (d *Debugger) shouldStop(line int) (StopReason, bool) {
+	d.mu.Lock()
+	entry, paused := d.stopOnEntry, d.paused
+	d.stopOnEntry, d.paused = false, false
+	condition, isBreakpoint := d.breakpoints[line]
+	d.mu.Unlock()
+
+	depth := len(d.frames)
+	switch {
+	case entry:
+		return StopEntry, true
+	case paused:
+		return StopPause, true
+	case d.mode == stepInMode,
+		d.mode == stepOverMode && depth <= d.stepDepth,
+		d.mode == stepOutMode && depth < d.stepDepth:
+		return StopStep, true
+	case isBreakpoint && condition == nil:
+		return StopBreakpoint, true
+	case isBreakpoint:
+		value, err := d.evaluateIn(d.interpreter.environment, condition)
+		return StopBreakpoint, err != nil || d.interpreter.isTruthy(value)
+	}
+	return "", false
+}
+
+// stop blocks the program goroutine running commands until one of them resumes it.
+func (d *Debugger) stop(stop Stop) {
+	d.mode = runMode
+	d.mu.Lock()
+	d.stopped = true
+	d.resumed = make(chan struct{})
+	d.mu.Unlock()
+	d.onStop(stop)
+	for command := range d.commands {
+		if command() {
+			break
+		}
+	}
+	d.mu.Lock()
+	d.stopped = false
+	close(d.resumed)
+	d.mu.Unlock()
+}
+
+// parseExpression parses a standalone expression, e.g. a breakpoint condition.
+func parseExpression(sources string) (ast.Expr, error) {
+	var messages []string
+	reporter := func(line int, message string) {
+		messages = append(messages, strings.TrimSpace(message))
+	}
+	tokens := scanner.NewScanner(strings.TrimSuffix(strings.TrimSpace(sources), ";")+";", reporter).ScanTokens()
+	statements := parser.NewParser(tokens, reporter).Parse()
+	if len(messages) > 0 {
+		return nil, fmt.Errorf("invalid expression %q: %s", sources, strings.Join(messages, "; "))
+	}
+	if len(statements) != 1 {
+		return nil, fmt.Errorf("invalid expression %q", sources)
+	}
+	expression, ok := statements[0].(*ast.Expression)
+	if !ok {
+		return nil, fmt.Errorf("invalid expression %q", sources)
+	}
+	return expression.Expression, nil
+}
@@ -0,0 +1,211 @@
+package interpreter
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const debuggedSources = `var a = 1;
+fun f(x) {
+  var y = x * 2;
+  return y;
+}
+var b = f(a);
+print b;
+`
+
+type debugSession struct {
+	debugger *Debugger
+	stops    chan Stop
+	done     chan struct{}
+	output   *bytes.Buffer
+	errors   *bytes.Buffer
+}
+
+// debug runs sources in a goroutine, setup configures the debugger before the run.
+func debug(sources string, setup func(debugger *Debugger)) *debugSession {
+	session := &debugSession{
+		stops:  make(chan Stop),
+		done:   make(chan struct{}),
+		output: &bytes.Buffer{},
+		errors: &bytes.Buffer{},
+	}
+	session.debugger = NewDebugger(func(stop Stop) {
+		session.stops <- stop
+	})
+	setup(session.debugger)
+	lox := New()
+	lox.SetOutput(session.output)
+	lox.SetErrorOutput(session.errors)
+	lox.SetDebugger(session.debugger)
+	go func() {
+		defer close(session.done)
+		lox.Run(sources)
+	}()
+	return session
+}
+
+func (s *debugSession) expectStop(t *testing.T, want Stop) {
+	t.Helper()
+	select {
+	case stop := <-s.stops:
+		if stop != want {
+			t.Fatalf("stopped at %v, want %v", stop, want)
+		}
+	case <-s.done:
+		t.Fatalf("program finished, want stop %v", want)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout waiting for stop %v", want)
+	}
+}
+
+func (s *debugSession) expectDone(t *testing.T) {
+	t.Helper()
+	select {
+	case stop := <-s.stops:
+		t.Fatalf("stopped at %v, want program to finish", stop)
+	case <-s.done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout waiting for program to finish")
+	}
+}
+
+func TestDebugger_BreakpointsAndInspection(t *testing.T) {
+	session := debug(debuggedSources, func(debugger *Debugger) {
+		_ = debugger.SetBreakpoint(3, "")
+	})
+	session.expectStop(t, Stop{Reason: StopBreakpoint, Line: 3})
+
+	frames, err := session.debugger.StackTrace()
+	wantFrames := []Frame{{Name: "f", Line: 3}, {Name: "<script>", Line: 6}}
+	if err != nil || !reflect.DeepEqual(frames, wantFrames) {
+		t.Errorf("StackTrace() = %v, %v, want %v", frames, err, wantFrames)
+	}
+	scopes, err := session.debugger.Scopes(0)
+	wantScopes := []Scope{
+		{Name: "Locals", Variables: []Variable{{Name: "x", Value: "1"}}},
+		{Name: "Globals", Variables: []Variable{{Name: "a", Value: "1"}, {Name: "args", Value: "[]"}, {Name: "f", Value: "<fn f>"}}},
+	}
+	if err != nil || !reflect.DeepEqual(scopes, wantScopes) {
+		t.Errorf("Scopes() = %v, %v, want %v", scopes, err, wantScopes)
+	}
+	if value, err := session.debugger.Evaluate(0, "x + a * 10"); err != nil || value != "11" {
+		t.Errorf("Evaluate() = %v, %v, want 11", value, err)
+	}
+	if _, err := session.debugger.Evaluate(0, "b"); err == nil || err.(*RuntimeError).Message() != "Undefined variable 'b'." {
+		t.Errorf("Evaluate() error = %v, want undefined variable", err)
+	}
+	if _, err := session.debugger.Evaluate(0, "var c = 1;"); err == nil {
+		t.Errorf("Evaluate() expected error for a statement")
+	}
+
+	_ = session.debugger.StepOver()
+	session.expectStop(t, Stop{Reason: StopStep, Line: 4})
+	_ = session.debugger.StepOut()
+	session.expectStop(t, Stop{Reason: StopStep, Line: 7})
+	_ = session.debugger.Continue()
+	session.expectDone(t)
+
+	if session.output.String() != "2\n" {
+		t.Errorf("output = %q, want 2", session.output.String())
+	}
+	if err := session.debugger.Continue(); err != ErrNotStopped {
+		t.Errorf("Continue() after the end error = %v, want ErrNotStopped", err)
+	}
+}
+
+func TestDebugger_Stepping(t *testing.T) {
+	session := debug(debuggedSources, func(debugger *Debugger) {
+		debugger.StopOnEntry()
+	})
+	session.expectStop(t, Stop{Reason: StopEntry, Line: 1})
+	for _, want := range []Stop{
+		{Reason: StopStep, Line: 2},
+		{Reason: StopStep, Line: 6},
+		{Reason: StopStep, Line: 3},
+		{Reason: StopStep, Line: 4},
+		{Reason: StopStep, Line: 7},
+	} {
+		_ = session.debugger.StepIn()
+		session.expectStop(t, want)
+	}
+	_ = session.debugger.StepOver()
+	session.expectDone(t)
+}
+
+func TestDebugger_ConditionalBreakpoint(t *testing.T) {
+	sources := "for (var i = 0; i < 5; i = i + 1) {\n  print i;\n}\n"
+	session := debug(sources, func(debugger *Debugger) {
+		if err := debugger.SetBreakpoint(2, "i == 3"); err != nil {
+			t.Fatalf("SetBreakpoint() error = %v", err)
+		}
+		if err := debugger.SetBreakpoint(1, "i =="); err == nil {
+			t.Errorf("SetBreakpoint() expected error for invalid condition")
+		}
+	})
+	session.expectStop(t, Stop{Reason: StopBreakpoint, Line: 2})
+	if value, err := session.debugger.Evaluate(0, "i"); err != nil || value != "3" {
+		t.Errorf("Evaluate() = %v, %v, want 3", value, err)
+	}
+	_ = session.debugger.Continue()
+	session.expectDone(t)
+}
+
+func TestDebugger_PauseAndTerminate(t *testing.T) {
+	sources := "var x = 0;\nwhile (true) {\n  x = x + 1;\n}\n"
+	session := debug(sources, func(*Debugger) {})
+	session.debugger.Pause()
+	session.expectStop(t, Stop{Reason: StopPause, Line: 1})
+	_ = session.debugger.Continue()
+
+	time.Sleep(10 * time.Millisecond)
+	session.debugger.Pause()
+	session.expectStop(t, Stop{Reason: StopPause, Line: 3})
+	session.debugger.Terminate()
+	session.expectDone(t)
+	if got := session.errors.String(); got != "[line  3 ] Runtime error: Terminated by debugger.\n" {
+		t.Errorf("errors = %q", got)
+	}
+}
+
+func TestDebugger_QueriesAfterResume(t *testing.T) {
+	for run := 0; run < 20; run++ {
+		session := debug(debuggedSources, func(debugger *Debugger) {
+			_ = debugger.SetBreakpoint(3, "")
+		})
+		session.expectStop(t, Stop{Reason: StopBreakpoint, Line: 3})
+		_ = session.debugger.Continue()
+
+		queried := make(chan error)
+		go func() {
+			_, err := session.debugger.StackTrace()
+			queried <- err
+		}()
+		select {
+		case err := <-queried:
+			if err != ErrNotStopped {
+				t.Fatalf("StackTrace() error = %v, want %v", err, ErrNotStopped)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("StackTrace() blocked after Continue()")
+		}
+		session.expectDone(t)
+	}
+}
+
+func TestDebugger_Watch(t *testing.T) {
+	sources := "var x = 1;\nx = 1;\nx = 2;\n{\n  var x = 3;\n  x = 4;\n}\n"
+	session := debug(sources, func(debugger *Debugger) {
+		debugger.Watch("x")
+	})
+	session.expectStop(t, Stop{Reason: StopWatch, Line: 3, Description: "x: 1 -> 2"})
+	_ = session.debugger.Continue()
+	session.expectStop(t, Stop{Reason: StopWatch, Line: 6, Description: "x: 3 -> 4"})
+	if value, err := session.debugger.Evaluate(0, "x"); err != nil || value != "4" {
+		t.Errorf("Evaluate() = %v, %v, want 4", value, err)
+	}
+	_ = session.debugger.Continue()
+	session.expectDone(t)
+}
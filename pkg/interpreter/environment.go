@@ -1,42 +1,115 @@
 package interpreter
 
 import (
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/parser/ast"
 	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/scanner"
 )
 
-type valuesStorage = map[string]any
+type valuesStorage = map[string]Value
 
+// Environment holds variables of a scope. Globals are looked up by name,
+// locals are kept in slots numbered by the resolver.
 type Environment struct {
-	values    valuesStorage
+	// values are variables by name, nil in environments of local scopes.
+	values valuesStorage
+	// slots are local variables, names of slots are shared by environments of the scope.
+	slots []Value
+	names []string
+	// defined counts slots defined so far, they are defined in order.
+	defined   int
 	enclosing *Environment
+	hooks     *hooksRef
 }
 
-func NewEnvironment(environment *Environment) Environment {
-	return Environment{
+// NewEnvironment makes an environment of variables by name, e.g. globals.
+// It shares hooks with the enclosing environment, environments without one get their own.
+func NewEnvironment(environment *Environment) *Environment {
+	hooks := &hooksRef{}
+	if environment != nil {
+		hooks = environment.hooks
+	}
+	return &Environment{
 		values:    make(valuesStorage),
 		enclosing: environment,
+		hooks:     hooks,
+	}
+}
+
+// newLocalEnvironment makes an environment of a block or a function call with slots of the scope.
+func newLocalEnvironment(environment *Environment, layout *ast.Scope) *Environment {
+	return &Environment{
+		slots:     make([]Value, len(layout.Names)),
+		names:     layout.Names,
+		enclosing: environment,
+		hooks:     environment.hooks,
 	}
 }
 
-func (e Environment) get(name scanner.Token) (any, error) {
-	v, ok := e.values[name.Lexeme()]
-	if ok {
-		return v, nil
+func (e *Environment) get(name scanner.Token) (Value, error) {
+	if e.values != nil {
+		if v, ok := e.values[name.Lexeme()]; ok {
+			return v, nil
+		}
+	} else if index := e.slotOf(name.Lexeme()); index >= 0 {
+		return e.slots[index], nil
 	}
 	if e.enclosing != nil {
 		return e.enclosing.get(name)
 	}
-	return nil, NewRuntimeError(name, "Undefined variable '"+name.Lexeme()+"'.")
+	return Nil, NewRuntimeError(name, "Undefined variable '"+name.Lexeme()+"'.")
+}
+
+// slotOf finds a defined local by name, for lookups not resolved beforehand.
+func (e *Environment) slotOf(name string) int {
+	for index := e.defined - 1; index >= 0; index-- {
+		if e.names[index] == name {
+			return index
+		}
+	}
+	return -1
+}
+
+// ancestor is the environment depth scopes out of this one.
+func (e *Environment) ancestor(depth int) *Environment {
+	env := e
+	for ; depth > 0; depth-- {
+		env = env.enclosing
+	}
+	return env
 }
 
-func (e Environment) define(name string, value any) {
+func (e *Environment) getAt(at *ast.Slot) Value {
+	return e.ancestor(at.Depth).slots[at.Index]
+}
+
+func (e *Environment) define(name string, value Value) {
 	e.values[name] = value
+	if e.hooks.hooks != nil {
+		e.hooks.hooks.Define(name, value)
+	}
+}
+
+func (e *Environment) defineAt(index int, value Value) {
+	e.slots[index] = value
+	if index >= e.defined {
+		e.defined = index + 1
+	}
+	if e.hooks.hooks != nil {
+		e.hooks.hooks.Define(e.names[index], value)
+	}
 }
 
-func (e Environment) assign(name scanner.Token, value any) error {
-	_, ok := e.values[name.Lexeme()]
-	if ok {
-		e.values[name.Lexeme()] = value
+func (e *Environment) assign(name scanner.Token, value Value) error {
+	if e.values != nil {
+		if _, ok := e.values[name.Lexeme()]; ok {
+			e.values[name.Lexeme()] = value
+			if e.hooks.hooks != nil {
+				e.hooks.hooks.Assign(name.Lexeme(), value)
+			}
+			return nil
+		}
+	} else if index := e.slotOf(name.Lexeme()); index >= 0 {
+		e.assignSlot(index, value)
 		return nil
 	}
 	if e.enclosing != nil {
@@ -47,3 +120,14 @@ func (e Environment) assign(name scanner.Token, value any) error {
 		"Undefined variable '"+name.Lexeme()+"'.",
 	)
 }
+
+func (e *Environment) assignAt(at *ast.Slot, value Value) {
+	e.ancestor(at.Depth).assignSlot(at.Index, value)
+}
+
+func (e *Environment) assignSlot(index int, value Value) {
+	e.slots[index] = value
+	if e.hooks.hooks != nil {
+		e.hooks.hooks.Assign(e.names[index], value)
+	}
+}
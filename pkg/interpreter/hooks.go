@@ -0,0 +1,45 @@
+package interpreter
+
+import (
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/parser"
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/parser/ast"
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/scanner"
+)
+
+// Hooks observe a running program without changing it, e.g. Tracer.
+// They are called on the goroutine running the program.
+type Hooks interface {
+	// BeforeExecute and AfterExecute surround every statement, AfterExecute is skipped
+	// when the statement is left by a return or a runtime error.
+	BeforeExecute(stmt ast.Stmt)
+	AfterExecute(stmt ast.Stmt)
+	// BeforeEvaluate and AfterEvaluate surround every expression the same way.
+	BeforeEvaluate(expr ast.Expr)
+	AfterEvaluate(expr ast.Expr, value Value)
+	// Define and Assign are called by environments after a variable is set.
+	Define(name string, value Value)
+	Assign(name string, value Value)
+	// RuntimeError is called once with the error ending the run.
+	RuntimeError(err *RuntimeError)
+}
+
+// ParseHooks are Hooks also told about parsed sources before they run,
+// spans give tokens, and so lines, of statements and expressions.
+type ParseHooks interface {
+	Hooks
+	Parsed(tokens []scanner.Token, spans map[any]parser.Span)
+}
+
+// hooksRef is shared by an interpreter and all its environments,
+// so hooks installed after they are created still observe them.
+type hooksRef struct {
+	hooks Hooks
+}
+
+// notifyRuntimeError passes the error ending the run to hooks.
+// It must be deferred before catchRuntimeError, so it runs after it.
+func (i Interpreter) notifyRuntimeError(err *error) {
+	if rErr, ok := (*err).(*RuntimeError); ok && i.hooks.hooks != nil {
+		i.hooks.hooks.RuntimeError(rErr)
+	}
+}
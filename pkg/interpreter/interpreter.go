@@ -1,7 +1,13 @@
 package interpreter
 
 import (
+	"bufio"
+	"context"
 	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"time"
 
 	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/parser/ast"
 	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/scanner"
@@ -10,12 +16,29 @@ import (
 type RuntimeError struct {
 	token   scanner.Token
 	message string
+	stack   []StackFrame
 }
 
 func (re *RuntimeError) Error() string {
 	return fmt.Sprintf(`Runtime error: "%s" at token: %v`, re.message, re.token)
 }
 
+// Line is the source line the error happened at.
+func (re *RuntimeError) Line() int {
+	return re.token.Line()
+}
+
+// Message is the error message without location.
+func (re *RuntimeError) Message() string {
+	return re.message
+}
+
+// Stack is the Lox call stack of the error, the innermost frame first and top level code last.
+// Errors are given stacks when they end a run.
+func (re *RuntimeError) Stack() []StackFrame {
+	return re.stack
+}
+
 func NewRuntimeError(token scanner.Token, message string) *RuntimeError {
 	return &RuntimeError{
 		token:   token,
@@ -25,133 +48,231 @@ func NewRuntimeError(token scanner.Token, message string) *RuntimeError {
 
 type Interpreter struct {
 	lastPrintedValue *string
-	environment      Environment
+	globals          *Environment
+	environment      *Environment
+
+	stdin  *bufio.Reader
+	stdout io.Writer
+	random *rand.Rand
+
+	// debugger is nil unless the program is debugged.
+	debugger *Debugger
+	// hooks are shared with environments, see hooksRef.
+	hooks  *hooksRef
+	budget *budget
+	calls  *callStack
 }
 
 func NewInterpreter() Interpreter {
-	return Interpreter{
+	globals := NewEnvironment(nil)
+	defineNatives(globals, coreNatives()...)
+	defineNatives(globals, regexNatives()...)
+	globals.define(argsName, ObjectValue(newLoxList()))
+	interpreter := Interpreter{
 		lastPrintedValue: new(string),
-		environment:      NewEnvironment(nil),
+		globals:          globals,
+		environment:      globals,
+
+		stdin:  bufio.NewReader(os.Stdin),
+		stdout: os.Stdout,
+		random: rand.New(rand.NewSource(time.Now().UnixNano())),
+		hooks:  globals.hooks,
+		budget: &budget{ctx: context.Background()},
+		calls:  &callStack{maxDepth: DefaultMaxCallDepth},
+	}
+	interpreter.setCapabilities(SandboxFull.Capabilities())
+	return interpreter
+}
+
+// argsName is the global holding script command-line arguments.
+const argsName = "args"
+
+func (i Interpreter) setArgs(args []string) {
+	elements := make([]Value, 0, len(args))
+	for _, arg := range args {
+		elements = append(elements, StringValue(arg))
 	}
+	i.globals.define(argsName, ObjectValue(newLoxList(elements...)))
 }
 
-func (i Interpreter) Interpret(statements []ast.Stmt) (err error) {
-	defer func() {
-		if recovered := recover(); recovered != nil {
-			rErr, ok := recovered.(*RuntimeError)
-			if !ok {
-				err = recovered.(error)
-			} else {
-				err = rErr
-			}
+// catchRuntimeError converts a panic raised while interpreting into an error.
+// Panics with values other than errors are wrapped in one. It must be deferred directly to recover.
+func catchRuntimeError(err *error) {
+	if recovered := recover(); recovered != nil {
+		switch recovered := recovered.(type) {
+		case *RuntimeError:
+			*err = recovered
+		case error:
+			*err = recovered
+		default:
+			*err = fmt.Errorf("%v", recovered)
 		}
-	}()
+	}
+}
+
+// Interpret runs statements until they end, a runtime error or a *LimitError,
+// the context being done stops the run with a LimitError wrapping its error.
+func (i Interpreter) Interpret(ctx context.Context, statements []ast.Stmt) (err error) {
+	defer i.notifyRuntimeError(&err)
+	defer i.traceRuntimeError(&err)
+	defer catchRuntimeError(&err)
+	i.budget.start(ctx)
 	if len(statements) == 0 {
 		return NewRuntimeError(
 			scanner.NewToken(scanner.EOF, "", nil, 0),
 			"no statements given",
 		)
 	}
+	resolve(statements)
 	for _, statement := range statements {
 		i.execute(statement)
 	}
 	return nil
 }
 
-func (i Interpreter) VisitUnary(unary *ast.Unary) any {
+func (i Interpreter) callGlobal(name string) (err error) {
+	defer i.notifyRuntimeError(&err)
+	defer i.traceRuntimeError(&err)
+	defer catchRuntimeError(&err)
+	i.budget.start(context.Background())
+	token := scanner.NewToken(scanner.IDENTIFIER, name, nil, 0)
+	callee, err := i.globals.get(token)
+	if err != nil {
+		return err
+	}
+	function, ok := callee.AsObject().(loxCallable)
+	if !ok || function.arity() != 0 {
+		return NewRuntimeError(token, "'"+name+"' is not a function without parameters.")
+	}
+	_, err = function.call(i, nil)
+	if cErr, ok := err.(*callError); ok {
+		err = NewRuntimeError(token, cErr.message)
+	}
+	return err
+}
+
+func (i Interpreter) evaluateUnary(unary *ast.Unary) Value {
 	right := i.evaluate(unary.Right)
 	switch unary.Operator.Kind() {
 	case scanner.BANG:
-		return !i.isTruthy(right)
+		return BoolValue(!i.isTruthy(right))
 	case scanner.MINUS:
-		i.checkNumberOperands(unary.Operator, right)
-		return -right.(float64)
+		return i.negate(unary.Operator, right)
+	case scanner.TILDE:
+		return i.bitwiseNot(unary.Operator, right)
 	}
 	// Unreachable.
-	return nil
+	return Nil
 }
 
-func (i Interpreter) VisitVariable(variable *ast.Variable) any {
-	value, err := i.environment.get(variable.Name)
+func (i Interpreter) evaluateVariable(variable *ast.Variable) Value {
+	value, err := i.lookUp(variable.Local, variable.Name)
 	if err != nil {
 		panic(err)
 	}
 	return value
 }
 
+// lookUp reads a local from its slot or a global by name.
+func (i Interpreter) lookUp(local *ast.Slot, name scanner.Token) (Value, error) {
+	if local != nil {
+		return i.environment.getAt(local), nil
+	}
+	return i.globals.get(name)
+}
+
 // TODO: gocyclo considers this function too difficult,
 // refactor to several private operator methods after book will be completed.
 //
 //gocyclo:ignore
-func (i Interpreter) VisitBinary(binary *ast.Binary) any {
+func (i Interpreter) evaluateBinary(binary *ast.Binary) Value {
 	left := i.evaluate(binary.Left)
 	right := i.evaluate(binary.Right)
 	switch binary.Operator.Kind() {
 	case scanner.BANGEQUAL:
-		return !i.isEqual(left, right)
+		return BoolValue(!i.isEqual(left, right))
 	case scanner.EQUALEQUAL:
-		return i.isEqual(left, right)
-
-	case scanner.GREATER:
-		i.checkNumberOperands(binary.Operator, left, right)
-		return left.(float64) > right.(float64)
-	case scanner.GREATEREQUAL:
-		i.checkNumberOperands(binary.Operator, left, right)
-		return left.(float64) >= right.(float64)
-	case scanner.LESS:
-		i.checkNumberOperands(binary.Operator, left, right)
-		return left.(float64) < right.(float64)
-	case scanner.LESSEQUAL:
-		i.checkNumberOperands(binary.Operator, left, right)
-		return left.(float64) <= right.(float64)
+		return BoolValue(i.isEqual(left, right))
+
+	case scanner.GREATER, scanner.GREATEREQUAL, scanner.LESS, scanner.LESSEQUAL:
+		return BoolValue(i.comparison(binary.Operator, left, right))
 
-	case scanner.MINUS:
-		i.checkNumberOperands(binary.Operator, left, right)
-		return left.(float64) - right.(float64)
 	case scanner.PLUS:
-		const floatType = "float"
-		const stringType = "string"
-		var leftType string
-		var rightType string
-		switch left.(type) {
-		case float64:
-			leftType = floatType
-		case string:
-			leftType = stringType
+		if left.kind == StringKind && right.kind == StringKind {
+			i.budget.checkSize(binary.Operator, len(left.ref.(string))+len(right.ref.(string)))
+			return StringValue(left.ref.(string) + right.ref.(string))
 		}
-		switch right.(type) {
-		case float64:
-			rightType = floatType
-		case string:
-			rightType = stringType
+		if !left.isNumber() || !right.isNumber() {
+			err := NewRuntimeError(
+				binary.Operator,
+				"invalid type for operator '+' given, must be numbers or strings.",
+			)
+			panic(err)
 		}
-		if leftType == floatType && rightType == floatType {
-			return left.(float64) + right.(float64)
-		}
-		if leftType == stringType && rightType == stringType {
-			return left.(string) + right.(string)
+		return i.arithmetic(binary.Operator, left, right)
+	case scanner.MINUS, scanner.SLASH, scanner.STAR, scanner.PERCENT, scanner.TILDESLASH:
+		return i.arithmetic(binary.Operator, left, right)
+	case scanner.STARSTAR:
+		return i.power(binary.Operator, left, right)
+	case scanner.AMPERSAND, scanner.PIPE, scanner.CARET, scanner.LESSLESS, scanner.GREATERGREATER:
+		return i.bitwise(binary.Operator, left, right)
+	}
+	// Unreachable.
+	return Nil
+}
+
+func (i Interpreter) evaluateCall(call *ast.Call) Value {
+	callee := i.evaluate(call.Callee)
+
+	arguments := make([]Value, 0, len(call.Arguments))
+	for _, argument := range call.Arguments {
+		arguments = append(arguments, i.evaluate(argument))
+	}
+
+	function, ok := callee.AsObject().(loxCallable)
+	if !ok {
+		panic(NewRuntimeError(call.Paren, "Can only call functions and classes."))
+	}
+	if function.arity() != variadicArity && len(arguments) != function.arity() {
+		panic(NewRuntimeError(
+			call.Paren,
+			fmt.Sprintf("Expected %d arguments but got %d.", function.arity(), len(arguments)),
+		))
+	}
+	previousParen := i.calls.paren
+	i.calls.paren = call.Paren
+	result, err := function.call(i, arguments)
+	i.calls.paren = previousParen
+	if err != nil {
+		if cErr, ok := err.(*callError); ok {
+			err = NewRuntimeError(call.Paren, cErr.message)
 		}
-		err := NewRuntimeError(
-			binary.Operator,
-			"invalid type for operator '+' given, must be numbers or strings.",
-		)
 		panic(err)
-	case scanner.SLASH:
-		i.checkNumberOperands(binary.Operator, left, right)
-		return left.(float64) / right.(float64)
-	case scanner.STAR:
-		i.checkNumberOperands(binary.Operator, left, right)
-		return left.(float64) * right.(float64)
 	}
-	// Unreachable.
-	return nil
+	i.budget.checkCollection(call.Paren, result)
+	return result
 }
 
-func (i Interpreter) VisitLiteral(literal *ast.Literal) any {
-	return literal.Value
+// evaluateCoalesce evaluates the right operand only when the left one is nil.
+func (i Interpreter) evaluateCoalesce(coalesce *ast.Coalesce) Value {
+	if left := i.evaluate(coalesce.Left); !left.IsNil() {
+		return left
+	}
+	return i.evaluate(coalesce.Right)
 }
 
-func (i Interpreter) VisitLogical(logical *ast.Logical) any {
+func (i Interpreter) evaluateConditional(conditional *ast.Conditional) Value {
+	if i.isTruthy(i.evaluate(conditional.Condition)) {
+		return i.evaluate(conditional.ThenBranch)
+	}
+	return i.evaluate(conditional.ElseBranch)
+}
+
+func (i Interpreter) evaluateLiteral(literal *ast.Literal) Value {
+	return literalValue(literal.Value)
+}
+
+func (i Interpreter) evaluateLogical(logical *ast.Logical) Value {
 	left := i.evaluate(logical.Left)
 	switch logical.Operator.Kind() {
 	case scanner.OR:
@@ -166,38 +287,82 @@ func (i Interpreter) VisitLogical(logical *ast.Logical) any {
 	return i.evaluate(logical.Right)
 }
 
-func (i Interpreter) VisitGrouping(grouping *ast.Grouping) any {
+func (i Interpreter) evaluateGrouping(grouping *ast.Grouping) Value {
 	return i.evaluate(grouping.Expression)
 }
 
-func (i Interpreter) evaluate(expr ast.Expr) any {
-	return expr.Accept(i)
+func (i Interpreter) evaluate(expr ast.Expr) Value {
+	if hooks := i.hooks.hooks; hooks != nil {
+		hooks.BeforeEvaluate(expr)
+		value := i.evaluateExpr(expr)
+		hooks.AfterEvaluate(expr, value)
+		return value
+	}
+	return i.evaluateExpr(expr)
+}
+
+// evaluateExpr switches on expression types instead of calling Accept,
+// ast.VisitorExpr returns any and would box every Value.
+func (i Interpreter) evaluateExpr(expr ast.Expr) Value {
+	switch expr := expr.(type) {
+	case *ast.Assign:
+		return i.evaluateAssign(expr)
+	case *ast.Binary:
+		return i.evaluateBinary(expr)
+	case *ast.Call:
+		return i.evaluateCall(expr)
+	case *ast.Coalesce:
+		return i.evaluateCoalesce(expr)
+	case *ast.Conditional:
+		return i.evaluateConditional(expr)
+	case *ast.Grouping:
+		return i.evaluateGrouping(expr)
+	case *ast.Literal:
+		return i.evaluateLiteral(expr)
+	case *ast.Logical:
+		return i.evaluateLogical(expr)
+	case *ast.Unary:
+		return i.evaluateUnary(expr)
+	case *ast.Variable:
+		return i.evaluateVariable(expr)
+	}
+	panic(fmt.Sprintf("unknown expression %T", expr))
 }
 
 func (i Interpreter) execute(stmt ast.Stmt) {
+	i.budget.step()
+	if i.debugger != nil {
+		i.debugger.beforeStatement(i, stmt)
+	}
+	if hooks := i.hooks.hooks; hooks != nil {
+		hooks.BeforeExecute(stmt)
+		stmt.Accept(i)
+		hooks.AfterExecute(stmt)
+		return
+	}
 	stmt.Accept(i)
 }
 
 func (i Interpreter) executeBlock(statements []ast.Stmt, env *Environment) {
-	previous := i.environment
-	defer func() {
-		i.environment = previous
-	}()
-	i.environment = *env
+	i.environment = env
 	for j := range statements {
 		i.execute(statements[j])
 	}
 }
 
 func (i Interpreter) VisitBlock(stmt *ast.Block) {
-	newEnv := NewEnvironment(&i.environment)
-	i.executeBlock(stmt.Statements, &newEnv)
+	i.executeBlock(stmt.Statements, newLocalEnvironment(i.environment, stmt.Scope))
 }
 
 func (i Interpreter) VisitExpression(stmt *ast.Expression) {
 	i.evaluate(stmt.Expression)
 }
 
+func (i Interpreter) VisitFunction(stmt *ast.Function) {
+	function := newLoxFunction(stmt, i.environment)
+	i.declare(stmt.Local, stmt.Name, ObjectValue(function))
+}
+
 func (i Interpreter) VisitIf(stmt *ast.If) {
 	if i.isTruthy(i.evaluate(stmt.Condition)) {
 		i.execute(stmt.ThenBranch)
@@ -208,53 +373,91 @@ func (i Interpreter) VisitIf(stmt *ast.If) {
 
 func (i Interpreter) VisitPrint(stmt *ast.Print) {
 	value := i.evaluate(stmt.Expression)
-	strValue := i.stringify(value)
-	fmt.Println(strValue)
+	strValue := value.String()
+	_, err := fmt.Fprintln(i.stdout, strValue)
+	if err != nil {
+		panic(err)
+	}
 	*i.lastPrintedValue = strValue
 }
 
+func (i Interpreter) VisitReturn(stmt *ast.Return) {
+	value := Nil
+	if stmt.Value != nil {
+		value = i.evaluate(stmt.Value)
+	}
+	panic(returnValue{value: value})
+}
+
 func (i Interpreter) VisitVar(stmt *ast.Var) {
-	var value any
+	value := Nil
 	if stmt.Initializer != nil {
 		value = i.evaluate(stmt.Initializer)
 	}
-	i.environment.define(stmt.Name.Lexeme(), value)
+	i.declare(stmt.Local, stmt.Name, value)
 }
 
-func (i Interpreter) VisitAssign(expr *ast.Assign) any {
+// declare defines a local in its slot or a global by name.
+func (i Interpreter) declare(local *ast.Slot, name scanner.Token, value Value) {
+	if local != nil {
+		i.environment.defineAt(local.Index, value)
+		return
+	}
+	i.globals.define(name.Lexeme(), value)
+}
+
+func (i Interpreter) evaluateAssign(expr *ast.Assign) Value {
 	value := i.evaluate(expr.Value)
-	err := i.environment.assign(expr.Name, value)
-	if err != nil {
+	var previous Value
+	if i.debugger != nil {
+		previous, _ = i.lookUp(expr.Local, expr.Name)
+	}
+	if expr.Local != nil {
+		i.environment.assignAt(expr.Local, value)
+	} else if err := i.globals.assign(expr.Name, value); err != nil {
 		panic(err)
 	}
+	if i.debugger != nil {
+		i.debugger.afterAssign(i, expr.Name, previous, value)
+	}
 
 	return value
 }
 
 func (i Interpreter) VisitWhile(stmt *ast.While) {
 	for i.isTruthy(i.evaluate(stmt.Condition)) {
+		if i.debugger != nil {
+			i.debugger.nextIteration()
+		}
 		i.execute(stmt.Body)
 	}
 }
 
-func (i Interpreter) isTruthy(value any) bool {
-	if value == nil {
+func (i Interpreter) isTruthy(value Value) bool {
+	switch value.kind {
+	case NilKind:
 		return false
-	}
-	if value, ok := value.(bool); ok {
-		return value
+	case BoolKind:
+		return value.bits != 0
 	}
 	return true
 }
 
-func (i Interpreter) isEqual(left any, right any) bool {
+// isEqual compares values, numbers of different kinds are equal when their values are.
+func (i Interpreter) isEqual(left Value, right Value) bool {
+	if (left.kind != right.kind || left.isExact()) && left.isNumber() && right.isNumber() {
+		order, ok := compareExact(left, right)
+		return ok && order == 0
+	}
+	if left.kind == FloatKind && right.kind == FloatKind {
+		return left.float() == right.float()
+	}
 	return left == right
 }
 
-func (i Interpreter) checkNumberOperands(operator scanner.Token, operands ...any) {
+func (i Interpreter) checkNumberOperands(operator scanner.Token, operands ...Value) {
 	for _, operand := range operands {
-		switch operand.(type) {
-		case float64:
+		if operand.isNumber() {
 			continue
 		}
 		err := NewRuntimeError(
@@ -268,9 +471,23 @@ func (i Interpreter) checkNumberOperands(operator scanner.Token, operands ...any
 	}
 }
 
-func (i Interpreter) stringify(value any) string {
-	if value == nil {
-		return "nil"
+// checkIntegerOperands panics unless operands are integers or big integers, e.g. of bitwise operators.
+func (i Interpreter) checkIntegerOperands(operator scanner.Token, operands ...Value) {
+	for _, operand := range operands {
+		if operand.kind == IntegerKind || operand.kind == BigIntegerKind {
+			continue
+		}
+		err := NewRuntimeError(
+			operator,
+			fmt.Sprintf(
+				"invalid type for operator %s given, must be integer.",
+				operator.Kind(),
+			),
+		)
+		panic(err)
 	}
-	return fmt.Sprint(value)
+}
+
+func (i Interpreter) stringify(value Value) string {
+	return value.String()
 }
@@ -1,6 +1,9 @@
 package interpreter
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"reflect"
 	"testing"
 
@@ -19,7 +22,7 @@ func TestInterpreter_Interpret(t *testing.T) {
 		interp := NewInterpreter()
 
 		// act
-		err := interp.Interpret(parsed)
+		err := interp.Interpret(context.Background(), parsed)
 
 		// assert
 		if err != nil {
@@ -41,7 +44,7 @@ func TestInterpreter_Interpret(t *testing.T) {
 		interp := NewInterpreter()
 
 		// act
-		err := interp.Interpret(parsed)
+		err := interp.Interpret(context.Background(), parsed)
 
 		// assert
 		if err != nil {
@@ -111,7 +114,7 @@ func TestInterpreter_Interpret(t *testing.T) {
 				interp := NewInterpreter()
 
 				// act
-				err := interp.Interpret(parsed)
+				err := interp.Interpret(context.Background(), parsed)
 
 				// assert
 				if err != nil {
@@ -183,7 +186,7 @@ func TestInterpreter_Interpret(t *testing.T) {
 				interp := NewInterpreter()
 
 				// act
-				err := interp.Interpret(parsed)
+				err := interp.Interpret(context.Background(), parsed)
 
 				// assert
 				if err != nil {
@@ -207,7 +210,7 @@ func TestInterpreter_Interpret(t *testing.T) {
 		interp := NewInterpreter()
 
 		// act
-		err := interp.Interpret(parsed)
+		err := interp.Interpret(context.Background(), parsed)
 
 		// assert
 		if err != nil {
@@ -229,7 +232,7 @@ func TestInterpreter_Interpret(t *testing.T) {
 		interp := NewInterpreter()
 
 		// act
-		err := interp.Interpret(parsed)
+		err := interp.Interpret(context.Background(), parsed)
 
 		// assert
 		if err != nil {
@@ -251,7 +254,7 @@ func TestInterpreter_Interpret(t *testing.T) {
 		interp := NewInterpreter()
 
 		// act
-		err := interp.Interpret(parsed)
+		err := interp.Interpret(context.Background(), parsed)
 
 		// assert
 		if err != nil {
@@ -273,7 +276,7 @@ func TestInterpreter_Interpret(t *testing.T) {
 		interp := NewInterpreter()
 
 		// act
-		err := interp.Interpret(parsed)
+		err := interp.Interpret(context.Background(), parsed)
 
 		// assert
 		if err != nil {
@@ -295,7 +298,7 @@ func TestInterpreter_Interpret(t *testing.T) {
 		interp := NewInterpreter()
 
 		// act
-		err := interp.Interpret(parsed)
+		err := interp.Interpret(context.Background(), parsed)
 
 		// assert
 		if err != nil {
@@ -367,7 +370,7 @@ func TestInterpreter_Interpret(t *testing.T) {
 				interp := NewInterpreter()
 
 				// act
-				err := interp.Interpret(parsed)
+				err := interp.Interpret(context.Background(), parsed)
 
 				// assert
 				if err != nil {
@@ -446,7 +449,7 @@ func TestInterpreter_Interpret(t *testing.T) {
 				interp := NewInterpreter()
 
 				// act
-				err := interp.Interpret(parsed)
+				err := interp.Interpret(context.Background(), parsed)
 
 				// assert
 				if err != nil {
@@ -503,7 +506,7 @@ func TestInterpreter_Interpret(t *testing.T) {
 				interp := NewInterpreter()
 
 				// act
-				err := interp.Interpret(parsed)
+				err := interp.Interpret(context.Background(), parsed)
 
 				// assert
 				if err != nil {
@@ -606,7 +609,7 @@ func TestInterpreter_Interpret(t *testing.T) {
 				interp := NewInterpreter()
 
 				// act
-				err := interp.Interpret(parsed)
+				err := interp.Interpret(context.Background(), parsed)
 
 				// assert
 				if err != nil {
@@ -621,6 +624,178 @@ func TestInterpreter_Interpret(t *testing.T) {
 		}
 	})
 
+	t.Run("Functions works fine", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			sources string
+			want    string
+		}{
+			{
+				name: "Function without return gives nil",
+				sources: `
+					fun hello(name) { print "hello " + name; }
+					print hello("world");
+				`,
+				want: "nil",
+			},
+			{
+				name: "Recursive function",
+				sources: `
+					fun fib(n) {
+						if (n <= 1) return n;
+						return fib(n - 2) + fib(n - 1);
+					}
+					print fib(10);
+				`,
+				want: "55",
+			},
+			{
+				name: "Closures capture environment",
+				sources: `
+					fun makeCounter() {
+						var i = 0;
+						fun count() {
+							i = i + 1;
+							return i;
+						}
+						return count;
+					}
+					var counter = makeCounter();
+					counter();
+					print counter();
+				`,
+				want: "2",
+			},
+			{
+				name: "Return from loop",
+				sources: `
+					fun first() {
+						while (true) { return "first"; }
+					}
+					print first();
+				`,
+				want: "first",
+			},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				// arrange
+				pprinter := plugins.NewAstPrinter()
+				scnr := scanner.NewScanner(tt.sources, nil)
+				prsr := parser.NewParser(scnr.ScanTokens(), nil)
+				parsed := prsr.Parse()
+				interp := NewInterpreter()
+
+				// act
+				err := interp.Interpret(context.Background(), parsed)
+
+				// assert
+				if err != nil {
+					t.Errorf("Interpret() return error: %s, but shouldn't", err)
+				}
+
+				got := *interp.lastPrintedValue
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("Interpret() = %v, want %v, ast %s", got, tt.want, pprinter.Sprint(parsed))
+				}
+			})
+		}
+	})
+
+	t.Run("Native functions calls works fine", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			sources string
+			want    string
+		}{
+			{
+				name:    "Length of a string",
+				sources: `print len("hello");`,
+				want:    "5",
+			},
+			{
+				name:    "Args are empty by default",
+				sources: `print len(args);`,
+				want:    "0",
+			},
+			{
+				name:    "Native functions are values",
+				sources: `var l = len; print l;`,
+				want:    "<native fn len>",
+			},
+			{
+				name:    "Unset environment variable is nil",
+				sources: `print getenv("LOXGO_SURELY_UNSET_VARIABLE");`,
+				want:    "nil",
+			},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				// arrange
+				pprinter := plugins.NewAstPrinter()
+				scnr := scanner.NewScanner(tt.sources, nil)
+				prsr := parser.NewParser(scnr.ScanTokens(), nil)
+				parsed := prsr.Parse()
+				interp := NewInterpreter()
+
+				// act
+				err := interp.Interpret(context.Background(), parsed)
+
+				// assert
+				if err != nil {
+					t.Errorf("Interpret() return error: %s, but shouldn't", err)
+				}
+
+				got := *interp.lastPrintedValue
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("Interpret() = %v, want %v, ast %s", got, tt.want, pprinter.Sprint(parsed))
+				}
+			})
+		}
+	})
+
+	t.Run("Cannot call non-callable values", func(t *testing.T) {
+		// arrange
+		pprinter := plugins.NewAstPrinter()
+		scnr := scanner.NewScanner(`"foo"();`, nil)
+		prsr := parser.NewParser(scnr.ScanTokens(), nil)
+		parsed := prsr.Parse()
+		interp := NewInterpreter()
+
+		// act
+		err := interp.Interpret(context.Background(), parsed)
+
+		// assert
+		wantErr := `Runtime error: "Can only call functions and classes." at token: {RIGHTPAREN ) <nil> 1}`
+		if err == nil {
+			t.Errorf("Interpret() did not return error: %s, but should", wantErr)
+		}
+		if !reflect.DeepEqual(err.Error(), wantErr) {
+			t.Errorf("Interpret() error = %s, want error %s, ast %s", err.Error(), wantErr, pprinter.Sprint(parsed))
+		}
+	})
+
+	t.Run("Cannot call native with wrong arguments", func(t *testing.T) {
+		// arrange
+		pprinter := plugins.NewAstPrinter()
+		scnr := scanner.NewScanner(`get(args, 0);`, nil)
+		prsr := parser.NewParser(scnr.ScanTokens(), nil)
+		parsed := prsr.Parse()
+		interp := NewInterpreter()
+
+		// act
+		err := interp.Interpret(context.Background(), parsed)
+
+		// assert
+		wantErr := `Runtime error: "List index 0 out of range." at token: {RIGHTPAREN ) <nil> 1}`
+		if err == nil {
+			t.Errorf("Interpret() did not return error: %s, but should", wantErr)
+		}
+		if !reflect.DeepEqual(err.Error(), wantErr) {
+			t.Errorf("Interpret() error = %s, want error %s, ast %s", err.Error(), wantErr, pprinter.Sprint(parsed))
+		}
+	})
+
 	t.Run("Cannot interpret plus operator between string and number", func(t *testing.T) {
 		// arrange
 		pprinter := plugins.NewAstPrinter()
@@ -630,7 +805,7 @@ func TestInterpreter_Interpret(t *testing.T) {
 		interp := NewInterpreter()
 
 		// act
-		err := interp.Interpret(parsed)
+		err := interp.Interpret(context.Background(), parsed)
 
 		// assert
 		wantErr := `Runtime error: "invalid type for operator '+' given, must be numbers or strings." at token: {PLUS + <nil> 1}`
@@ -651,7 +826,7 @@ func TestInterpreter_Interpret(t *testing.T) {
 		interp := NewInterpreter()
 
 		// act
-		err := interp.Interpret(parsed)
+		err := interp.Interpret(context.Background(), parsed)
 
 		// assert
 		wantErr := `Runtime error: "invalid type for operator STAR given, must be number." at token: {STAR * <nil> 1}`
@@ -672,7 +847,7 @@ func TestInterpreter_Interpret(t *testing.T) {
 		interp := NewInterpreter()
 
 		// act
-		err := interp.Interpret(parsed)
+		err := interp.Interpret(context.Background(), parsed)
 
 		// assert
 		wantErr := `Runtime error: "no statements given" at token: {EOF  <nil> 0}`
@@ -685,3 +860,80 @@ func TestInterpreter_Interpret(t *testing.T) {
 	})
 
 }
+
+func TestLoxGo_RunConditionalExpressions(t *testing.T) {
+	tests := []struct {
+		name    string
+		sources string
+		want    string
+	}{
+		{
+			name:    "Conditional",
+			sources: `print 1 < 2 ? "yes" : "no"; print nil ? "yes" : "no"; print 0 ? "zero is truthy" : "no";`,
+			want:    "yes\nno\nzero is truthy\n",
+		},
+		{
+			name:    "Nested conditionals",
+			sources: `fun sign(n) { return n < 0 ? -1 : n == 0 ? 0 : 1; } print sign(-5); print sign(0); print sign(7);`,
+			want:    "-1\n0\n1\n",
+		},
+		{
+			name:    "Only the taken branch is evaluated",
+			sources: `var calls = 0; fun count() { calls += 1; return calls; } var a = true ? 1 : count(); var b = false ? count() : 2; print calls;`,
+			want:    "0\n",
+		},
+		{
+			name:    "Conditional assigns in its branches",
+			sources: `var a; var b; true ? a = 1 : (b = 2); print a; print b;`,
+			want:    "1\nnil\n",
+		},
+		{
+			name:    "Nil coalescing",
+			sources: `var missing; print missing ?? "default"; print false ?? "default"; print 0 ?? 1; print missing ?? nil ?? "last";`,
+			want:    "default\nfalse\n0\nlast\n",
+		},
+		{
+			name:    "Nil coalescing short-circuits",
+			sources: `fun fail() { return 1 / nil; } print "set" ?? fail();`,
+			want:    "set\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := bytes.Buffer{}
+			lox := New()
+			lox.SetOutput(&output)
+
+			if err := lox.RunContext(context.Background(), tt.sources); err != nil {
+				t.Fatalf("RunContext() error = %v", err)
+			}
+
+			if output.String() != tt.want {
+				t.Errorf("output = %q, want %q", output.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestCatchRuntimeError(t *testing.T) {
+	tests := []struct {
+		name    string
+		panic   any
+		wantErr string
+	}{
+		{name: "Runtime error", panic: NewRuntimeError(scanner.NewToken(scanner.NIL, "nil", nil, 1), "failed"), wantErr: `Runtime error: "failed" at token: {NIL nil <nil> 1}`},
+		{name: "Error", panic: errors.New("failed"), wantErr: "failed"},
+		{name: "Not an error", panic: "failed", wantErr: "failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run := func() (err error) {
+				defer catchRuntimeError(&err)
+				panic(tt.panic)
+			}
+			if err := run(); err == nil || err.Error() != tt.wantErr {
+				t.Errorf("catchRuntimeError() error = %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
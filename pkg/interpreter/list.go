@@ -0,0 +1,20 @@
+package interpreter
+
+// loxList is a runtime list value, it is shared by reference like in Lox objects.
+type loxList struct {
+	elements []Value
+}
+
+func newLoxList(elements ...Value) *loxList {
+	return &loxList{
+		elements: elements,
+	}
+}
+
+func (l *loxList) get(index float64) (Value, error) {
+	position := int(index)
+	if float64(position) != index || position < 0 || position >= len(l.elements) {
+		return Nil, newCallError("List index %v out of range.", index)
+	}
+	return l.elements[position], nil
+}
@@ -0,0 +1,46 @@
+package interpreter
+
+// loxMap is a runtime map value keeping keys in insertion order.
+type loxMap struct {
+	keys []Value
+	// values are keyed by mapKey of keys.
+	values map[Value]Value
+}
+
+func newLoxMap() *loxMap {
+	return &loxMap{
+		values: make(map[Value]Value),
+	}
+}
+
+func (m *loxMap) set(key Value, value Value) {
+	if _, ok := m.values[mapKey(key)]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[mapKey(key)] = value
+}
+
+func (m *loxMap) get(key Value) (Value, error) {
+	value, ok := m.values[mapKey(key)]
+	if !ok {
+		return Nil, newCallError("Undefined map key '%v'.", key)
+	}
+	return value, nil
+}
+
+// mapKey makes keys equal by Lox equality equal by ==. Numbers having an integer value
+// are integers, other finite numbers are kept as exact fractions, e.g. 0.5 and 0.50d are "1/2".
+// Big integers and decimals are shared by reference, so they are never keys themselves.
+func mapKey(key Value) Value {
+	if !key.isNumber() || key.kind == IntegerKind {
+		return key
+	}
+	rat, finite := exactRat(key)
+	if !finite {
+		return key
+	}
+	if rat.IsInt() && rat.Num().IsInt64() {
+		return IntegerValue(rat.Num().Int64())
+	}
+	return Value{kind: DecimalKind, ref: rat.RatString()}
+}
@@ -0,0 +1,51 @@
+package interpreter
+
+import (
+	"math"
+	"math/big"
+	"testing"
+)
+
+func TestLoxMap_NumberKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		set  Value
+		get  Value
+	}{
+		{name: "Equal big integers", set: BigIntegerValue(big.NewInt(123)), get: BigIntegerValue(big.NewInt(123))},
+		{name: "Big integer and integer", set: BigIntegerValue(big.NewInt(123)), get: IntegerValue(123)},
+		{name: "Huge big integers", set: BigIntegerValue(new(big.Int).Lsh(big.NewInt(1), 100)), get: BigIntegerValue(new(big.Int).Lsh(big.NewInt(1), 100))},
+		{name: "Decimals of different scales", set: DecimalValue(mustDecimal(t, "1.10")), get: DecimalValue(mustDecimal(t, "1.1"))},
+		{name: "Decimal and float", set: DecimalValue(mustDecimal(t, "0.50")), get: FloatValue(0.5)},
+		{name: "Integer and float", set: IntegerValue(1), get: FloatValue(1)},
+		{name: "Zero and negative zero", set: FloatValue(0), get: FloatValue(math.Copysign(0, -1))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dict := newLoxMap()
+			dict.set(tt.set, StringValue("first"))
+			dict.set(tt.get, StringValue("second"))
+
+			if len(dict.keys) != 1 {
+				t.Errorf("keys = %v, want one key", dict.keys)
+			}
+			if got, err := dict.get(tt.set); err != nil || got != StringValue("second") {
+				t.Errorf("get() = %v, %v, want second", got, err)
+			}
+			if got := ObjectValue(dict).String(); got != "{"+tt.set.String()+": second}" {
+				t.Errorf("String() = %s", got)
+			}
+		})
+	}
+
+	t.Run("Different numbers", func(t *testing.T) {
+		dict := newLoxMap()
+		dict.set(DecimalValue(mustDecimal(t, "0.1")), StringValue("decimal"))
+		if _, err := dict.get(FloatValue(0.1)); err == nil {
+			t.Errorf("get() of a float not equal to the decimal key succeeded")
+		}
+		if _, err := dict.get(StringValue("1/10")); err == nil {
+			t.Errorf("get() of a string succeeded")
+		}
+	})
+}
@@ -0,0 +1,390 @@
+package interpreter
+
+import (
+	"errors"
+	"math"
+	"math/big"
+
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/decimal"
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/scanner"
+)
+
+// arithmetic applies an arithmetic operator to numbers. Operations on integers give integers,
+// failing on overflow, an integer operand of a float is promoted to a float.
+// True division / always gives a float and integer division ~/ always gives an integer,
+// both give exact numbers for exact operands, see exactArithmetic.
+func (i Interpreter) arithmetic(operator scanner.Token, left, right Value) Value {
+	i.checkNumberOperands(operator, left, right)
+	if left.kind == IntegerKind && right.kind == IntegerKind && operator.Kind() != scanner.SLASH {
+		return IntegerValue(integerArithmetic(operator, left.integer(), right.integer()))
+	}
+	if left.isExact() || right.isExact() {
+		return exactArithmetic(operator, left, right)
+	}
+	a, _ := left.AsNumber()
+	b, _ := right.AsNumber()
+	switch operator.Kind() {
+	case scanner.MINUS:
+		return FloatValue(a - b)
+	case scanner.PLUS:
+		return FloatValue(a + b)
+	case scanner.SLASH:
+		return FloatValue(a / b)
+	case scanner.STAR:
+		return FloatValue(a * b)
+	case scanner.PERCENT:
+		return FloatValue(math.Mod(a, b))
+	case scanner.TILDESLASH:
+		if b == 0 {
+			panic(NewRuntimeError(operator, "Division by zero."))
+		}
+		quotient, ok := floatToInteger(math.Trunc(a / b))
+		if !ok {
+			panic(NewRuntimeError(operator, "Integer overflow."))
+		}
+		return IntegerValue(quotient)
+	}
+	// Unreachable.
+	return Nil
+}
+
+// integerArithmetic applies an operator to integers, ~/ and % truncate like Go does.
+func integerArithmetic(operator scanner.Token, a, b int64) int64 {
+	overflow := false
+	var result int64
+	switch operator.Kind() {
+	case scanner.MINUS:
+		result = a - b
+		overflow = (b < 0 && result < a) || (b > 0 && result > a)
+	case scanner.PLUS:
+		result = a + b
+		overflow = (b > 0 && result < a) || (b < 0 && result > a)
+	case scanner.STAR:
+		result, overflow = multiply(a, b)
+	case scanner.TILDESLASH, scanner.PERCENT:
+		if b == 0 {
+			panic(NewRuntimeError(operator, "Division by zero."))
+		}
+		if operator.Kind() == scanner.PERCENT {
+			return a % b
+		}
+		result = a / b
+		overflow = a == math.MinInt64 && b == -1
+	}
+	if overflow {
+		panic(NewRuntimeError(operator, "Integer overflow."))
+	}
+	return result
+}
+
+// exactArithmetic applies an operator to big integers and decimals. Integers are promoted
+// to big integers and big integers to decimals, true division gives decimals.
+// Floats can't be mixed with them, results would be inexact.
+func exactArithmetic(operator scanner.Token, left, right Value) Value {
+	if left.kind == FloatKind || right.kind == FloatKind {
+		panic(NewRuntimeError(operator, "Can't mix floats with big integers or decimals, convert them with float()."))
+	}
+	if left.kind == DecimalKind || right.kind == DecimalKind || operator.Kind() == scanner.SLASH {
+		return decimalArithmetic(operator, toDecimal(left), toDecimal(right))
+	}
+	return BigIntegerValue(bigIntegerArithmetic(operator, toBigInteger(left), toBigInteger(right)))
+}
+
+func bigIntegerArithmetic(operator scanner.Token, a, b *big.Int) *big.Int {
+	switch operator.Kind() {
+	case scanner.MINUS:
+		return new(big.Int).Sub(a, b)
+	case scanner.PLUS:
+		return new(big.Int).Add(a, b)
+	case scanner.STAR:
+		return new(big.Int).Mul(a, b)
+	}
+	if b.Sign() == 0 {
+		panic(NewRuntimeError(operator, "Division by zero."))
+	}
+	if operator.Kind() == scanner.PERCENT {
+		return new(big.Int).Rem(a, b)
+	}
+	return new(big.Int).Quo(a, b)
+}
+
+func decimalArithmetic(operator scanner.Token, a, b decimal.Decimal) Value {
+	var result decimal.Decimal
+	var err error
+	switch operator.Kind() {
+	case scanner.MINUS:
+		result = a.Sub(b)
+	case scanner.PLUS:
+		result = a.Add(b)
+	case scanner.STAR:
+		result, err = a.Mul(b)
+	case scanner.SLASH:
+		result, err = a.Quo(b)
+	case scanner.PERCENT:
+		result, err = a.Rem(b)
+	case scanner.TILDESLASH:
+		var quotient *big.Int
+		if quotient, err = a.QuoInt(b); err == nil {
+			return BigIntegerValue(quotient)
+		}
+	}
+	if errors.Is(err, decimal.ErrDivisionByZero) {
+		panic(NewRuntimeError(operator, "Division by zero."))
+	}
+	if errors.Is(err, decimal.ErrTooLarge) {
+		panic(NewRuntimeError(operator, "Number is too large."))
+	}
+	return DecimalValue(result)
+}
+
+// toBigInteger promotes an integer, big integers are returned as they are.
+func toBigInteger(v Value) *big.Int {
+	if v.kind == IntegerKind {
+		return big.NewInt(v.integer())
+	}
+	return v.ref.(*big.Int)
+}
+
+// toDecimal promotes an integer or a big integer, decimals are returned as they are.
+func toDecimal(v Value) decimal.Decimal {
+	if d, ok := v.AsDecimal(); ok {
+		return d
+	}
+	return decimal.FromInt(toBigInteger(v))
+}
+
+// multiply multiplies integers, it tells whether the product overflows.
+func multiply(a, b int64) (int64, bool) {
+	result := a * b
+	return result, a != 0 && (result/a != b || (a == -1 && b == math.MinInt64))
+}
+
+// power raises a number to a power. Integers raised to non-negative integer powers stay integers,
+// failing on overflow, other numbers give floats. Exact bases need integer exponents, see exactPower.
+func (i Interpreter) power(operator scanner.Token, base, exponent Value) Value {
+	i.checkNumberOperands(operator, base, exponent)
+	if base.isExact() || exponent.isExact() {
+		return exactPower(operator, base, exponent)
+	}
+	if base.kind == IntegerKind && exponent.kind == IntegerKind && exponent.integer() >= 0 {
+		return IntegerValue(integerPower(operator, base.integer(), exponent.integer()))
+	}
+	a, _ := base.AsNumber()
+	b, _ := exponent.AsNumber()
+	return FloatValue(math.Pow(a, b))
+}
+
+// integerPower raises the integer by squaring.
+func integerPower(operator scanner.Token, base, exponent int64) int64 {
+	result := int64(1)
+	for overflow := false; exponent > 0; exponent >>= 1 {
+		if exponent&1 == 1 {
+			if result, overflow = multiply(result, base); overflow {
+				panic(NewRuntimeError(operator, "Integer overflow."))
+			}
+		}
+		if exponent > 1 {
+			if base, overflow = multiply(base, base); overflow {
+				panic(NewRuntimeError(operator, "Integer overflow."))
+			}
+		}
+	}
+	return result
+}
+
+// exactPower raises big integers and decimals, an integer base of a big integer exponent is promoted.
+// Negative exponents give decimals, they are rounded like quotients. Results are limited
+// to decimal.MaxBits bits, so huge powers fail before being computed.
+func exactPower(operator scanner.Token, base, exponent Value) Value {
+	if base.kind == FloatKind || exponent.kind == FloatKind {
+		panic(NewRuntimeError(operator, "Can't mix floats with big integers or decimals, convert them with float()."))
+	}
+	if exponent.kind == DecimalKind || (exponent.kind == BigIntegerKind && !exponent.ref.(*big.Int).IsInt64()) {
+		panic(NewRuntimeError(operator, "Exponent of a big integer or a decimal must be an integer."))
+	}
+	n := toBigInteger(exponent).Int64()
+	if base.kind != DecimalKind && n >= 0 {
+		if decimal.ExceedsBits(toBigInteger(base), n) {
+			panic(NewRuntimeError(operator, "Number is too large."))
+		}
+		return BigIntegerValue(new(big.Int).Exp(toBigInteger(base), big.NewInt(n), nil))
+	}
+	if n == math.MinInt64 {
+		panic(NewRuntimeError(operator, "Number is too large."))
+	}
+	power, err := toDecimal(base).Pow(max(n, -n))
+	if err != nil {
+		panic(NewRuntimeError(operator, "Number is too large."))
+	}
+	if n >= 0 {
+		return DecimalValue(power)
+	}
+	reciprocal, err := decimal.FromInt(big.NewInt(1)).Quo(power)
+	if errors.Is(err, decimal.ErrDivisionByZero) {
+		panic(NewRuntimeError(operator, "Division by zero."))
+	}
+	if err != nil {
+		panic(NewRuntimeError(operator, "Number is too large."))
+	}
+	return DecimalValue(reciprocal)
+}
+
+// bitwise applies a bitwise or a shift operator to integers, an integer operand of a big integer is promoted.
+func (i Interpreter) bitwise(operator scanner.Token, left, right Value) Value {
+	i.checkIntegerOperands(operator, left, right)
+	if operator.Kind() == scanner.LESSLESS || operator.Kind() == scanner.GREATERGREATER {
+		return shift(operator, left, right)
+	}
+	if left.kind == IntegerKind && right.kind == IntegerKind {
+		switch operator.Kind() {
+		case scanner.AMPERSAND:
+			return IntegerValue(left.integer() & right.integer())
+		case scanner.PIPE:
+			return IntegerValue(left.integer() | right.integer())
+		}
+		return IntegerValue(left.integer() ^ right.integer())
+	}
+	a, b := toBigInteger(left), toBigInteger(right)
+	switch operator.Kind() {
+	case scanner.AMPERSAND:
+		return BigIntegerValue(new(big.Int).And(a, b))
+	case scanner.PIPE:
+		return BigIntegerValue(new(big.Int).Or(a, b))
+	}
+	return BigIntegerValue(new(big.Int).Xor(a, b))
+}
+
+// shift shifts the integer by a non-negative count, integers shifted left fail on overflow
+// and big integers when they would have more than decimal.MaxBits bits.
+func shift(operator scanner.Token, value, count Value) Value {
+	n, ok := int64(0), true
+	if count.kind == IntegerKind {
+		n = count.integer()
+	} else if n, ok = count.ref.(*big.Int).Int64(), count.ref.(*big.Int).IsInt64(); !ok {
+		panic(NewRuntimeError(operator, "Shift count is too large."))
+	}
+	if n < 0 {
+		panic(NewRuntimeError(operator, "Negative shift count."))
+	}
+	left := operator.Kind() == scanner.LESSLESS
+	if value.kind == BigIntegerKind {
+		if left {
+			if bits := value.ref.(*big.Int).BitLen(); bits > 0 && n > int64(decimal.MaxBits-bits) {
+				panic(NewRuntimeError(operator, "Number is too large."))
+			}
+			return BigIntegerValue(new(big.Int).Lsh(value.ref.(*big.Int), uint(n)))
+		}
+		return BigIntegerValue(new(big.Int).Rsh(value.ref.(*big.Int), uint(n)))
+	}
+	if !left {
+		return IntegerValue(value.integer() >> n)
+	}
+	shifted := value.integer() << n
+	if shifted>>n != value.integer() {
+		panic(NewRuntimeError(operator, "Integer overflow."))
+	}
+	return IntegerValue(shifted)
+}
+
+// bitwiseNot flips bits of an integer.
+func (i Interpreter) bitwiseNot(operator scanner.Token, operand Value) Value {
+	i.checkIntegerOperands(operator, operand)
+	if operand.kind == BigIntegerKind {
+		return BigIntegerValue(new(big.Int).Not(operand.ref.(*big.Int)))
+	}
+	return IntegerValue(^operand.integer())
+}
+
+// comparison applies a comparison operator to numbers, integers are compared exactly,
+// mixed operands as floats.
+func (i Interpreter) comparison(operator scanner.Token, left, right Value) bool {
+	i.checkNumberOperands(operator, left, right)
+	if left.kind == IntegerKind && right.kind == IntegerKind {
+		return compareOrdered(operator, left.integer(), right.integer())
+	}
+	if left.kind == FloatKind && right.kind == FloatKind {
+		return compareOrdered(operator, left.float(), right.float())
+	}
+	order, ok := compareExact(left, right)
+	return ok && compareOrdered(operator, order, 0)
+}
+
+func compareOrdered[T int | int64 | float64](operator scanner.Token, a, b T) bool {
+	switch operator.Kind() {
+	case scanner.GREATER:
+		return a > b
+	case scanner.GREATEREQUAL:
+		return a >= b
+	case scanner.LESS:
+		return a < b
+	case scanner.LESSEQUAL:
+		return a <= b
+	}
+	// Unreachable.
+	return false
+}
+
+// compareExact orders numbers of different kinds by their exact values, not by the values
+// rounded to floats. Numbers are unordered when either one is NaN.
+func compareExact(left, right Value) (int, bool) {
+	a, leftFinite := exactRat(left)
+	b, rightFinite := exactRat(right)
+	if leftFinite && rightFinite {
+		return a.Cmp(b), true
+	}
+	// Infinities are beyond any finite number, big ones are rounded to infinities as well.
+	x, _ := left.AsNumber()
+	y, _ := right.AsNumber()
+	switch {
+	case math.IsNaN(x) || math.IsNaN(y):
+		return 0, false
+	case x < y:
+		return -1, true
+	case x > y:
+		return 1, true
+	}
+	return 0, true
+}
+
+// exactRat is the exact value of the number, floats are exact too unless they are NaN or infinite.
+func exactRat(v Value) (*big.Rat, bool) {
+	switch v.kind {
+	case IntegerKind:
+		return new(big.Rat).SetInt64(v.integer()), true
+	case FloatKind:
+		if math.IsNaN(v.float()) || math.IsInf(v.float(), 0) {
+			return nil, false
+		}
+		return new(big.Rat).SetFloat64(v.float()), true
+	case BigIntegerKind:
+		return new(big.Rat).SetInt(v.ref.(*big.Int)), true
+	}
+	return v.ref.(decimal.Decimal).Rat(), true
+}
+
+// negate negates a number, the smallest integer has no negation.
+func (i Interpreter) negate(operator scanner.Token, operand Value) Value {
+	i.checkNumberOperands(operator, operand)
+	switch operand.kind {
+	case FloatKind:
+		return FloatValue(-operand.float())
+	case BigIntegerKind:
+		return BigIntegerValue(new(big.Int).Neg(operand.ref.(*big.Int)))
+	case DecimalKind:
+		return DecimalValue(operand.ref.(decimal.Decimal).Neg())
+	}
+	if operand.integer() == math.MinInt64 {
+		panic(NewRuntimeError(operator, "Integer overflow."))
+	}
+	return IntegerValue(-operand.integer())
+}
+
+// floatToInteger truncates the float, it fails for NaN, infinities and floats out of the int64 range.
+func floatToInteger(f float64) (int64, bool) {
+	f = math.Trunc(f)
+	// 2^63 is the first float above the range, -2^63 is in it.
+	if math.IsNaN(f) || f >= math.MaxInt64 || f < math.MinInt64 {
+		return 0, false
+	}
+	return int64(f), true
+}
@@ -0,0 +1,163 @@
+package interpreter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestLoxGo_RunNumbers(t *testing.T) {
+	tests := []struct {
+		name    string
+		sources string
+		want    string
+	}{
+		{
+			name:    "Integers stay exact",
+			sources: `print 9007199254740993 + 0; print 10 / 3 * 3; print 10 ~/ 3 * 3;`,
+			want:    "9007199254740993\n10\n9\n",
+		},
+		{
+			name:    "Mixed operands are promoted",
+			sources: `print 1 + 0.5; print 3 * 1.5; print 7 - 0.5; print 2 ~/ 0.5;`,
+			want:    "1.5\n4.5\n6.5\n4\n",
+		},
+		{
+			name:    "Modulo and integer division truncate",
+			sources: `print 7 % 3; print -7 % 3; print -7 ~/ 2; print 7.5 % 2;`,
+			want:    "1\n-1\n-3\n1.5\n",
+		},
+		{
+			name:    "Comparisons and equality",
+			sources: `print 1 == 1.0; print 2 > 1.5; print 9007199254740993 > 9007199254740992; print 1 == "1";`,
+			want:    "true\ntrue\ntrue\nfalse\n",
+		},
+		{
+			name:    "Conversions",
+			sources: `print int(3.9); print int(-3.9); print int("42"); print int("2.5"); print float(2) / 4; print float("0.25");`,
+			want:    "3\n-3\n42\n2\n0.5\n0.25\n",
+		},
+		{
+			name:    "Big integers",
+			sources: `print 9223372036854775807n + 1; print 2n * 9223372036854775807 * 9223372036854775807; print -7n % 3; print -7n ~/ 2;`,
+			want:    "9223372036854775808\n170141183460469231694793815568465002498\n-1\n-3\n",
+		},
+		{
+			name:    "Decimals keep their scale",
+			sources: `print 0.10d + 0.20d; print 1.10d * 3; print 19.99d * 3n; print 10.00d / 4; print 1d / 3; print -7.5d % 2; print 7.5d ~/ 2;`,
+			want:    "0.30\n3.30\n59.97\n2.50\n0.3333333333333333\n-1.5\n3\n",
+		},
+		{
+			name:    "Decimal quotients keep their scale",
+			sources: `print 1d / 3d / 3d / 3d; var x = 1d; for (var i = 0; i < 20; i = i + 1) x = x / 3d; print x;`,
+			want:    "0.0370370370370370\n0.0000000002867972\n",
+		},
+		{
+			name:    "Exact comparisons",
+			sources: `print 0.1d + 0.2d == 0.3d; print 0.1 + 0.2 == 0.3; print 1.10d == 1.1d; print 3n == 3; print 0.1d == 0.1; print 10n > 9.5; print 2.50d < 3n;`,
+			want:    "true\nfalse\ntrue\ntrue\nfalse\ntrue\ntrue\n",
+		},
+		{
+			name:    "Exact conversions",
+			sources: `print int(12345n); print int(-2.75d); print float(2.5d); print float(3n) / 2;`,
+			want:    "12345\n-2\n2.5\n1.5\n",
+		},
+		{
+			name:    "Powers",
+			sources: `print 2 ** 10; print 2 ** 3 ** 2; print -2 ** 2; print 2 ** -1; print 4 ** 0.5; print 2n ** 100; print 1.5d ** 2; print 2d ** -2; print 10 ** -2n; print 1n ** 100000000000; print 0n << 100000000000;`,
+			want:    "1024\n512\n-4\n0.5\n2\n1267650600228229401496703205376\n2.25\n0.25\n0.01\n1\n0\n",
+		},
+		{
+			name:    "Bitwise operators",
+			sources: `print 12 & 10; print 12 | 10; print 12 ^ 10; print ~5; print 1 << 10; print -16 >> 2; print 0xF0n | 0x0F; print 1n << 100 >> 99;`,
+			want:    "8\n14\n6\n-6\n1024\n-4\n255\n2\n",
+		},
+		{
+			name:    "Bitwise operators bind tighter than comparisons",
+			sources: `print 6 & 1 == 0; print 1 | 2 ^ 3 & 4;`,
+			want:    "true\n3\n",
+		},
+		{
+			name: "Compound assignments",
+			sources: `var a = 10; a += 5; print a; a -= 3; print a; a *= 2; print a; a /= 8; print a;
+var s = "a"; s += "b"; print s;
+{ var local = 1; local += local *= 3; print local; }
+var total = 0; for (var i = 1; i <= 4; i += 1) total += i; print total;`,
+			want: "15\n12\n24\n3\nab\n4\n10\n",
+		},
+		{
+			name:    "Counts are integers",
+			sources: `print len("abc") ~/ 2; print len(list(1, 2)) * 10;`,
+			want:    "1\n20\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := bytes.Buffer{}
+			lox := New()
+			lox.SetOutput(&output)
+
+			if err := lox.RunContext(context.Background(), tt.sources); err != nil {
+				t.Fatalf("RunContext() error = %v", err)
+			}
+
+			if output.String() != tt.want {
+				t.Errorf("output = %q, want %q", output.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestLoxGo_RunNumbersErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		sources     string
+		wantMessage string
+	}{
+		{name: "Addition overflow", sources: `print 9223372036854775807 + 1;`, wantMessage: "Integer overflow."},
+		{name: "Subtraction overflow", sources: `print -9223372036854775807 - 2;`, wantMessage: "Integer overflow."},
+		{name: "Multiplication overflow", sources: `print 4294967296 * 4294967296;`, wantMessage: "Integer overflow."},
+		{name: "Negation overflow", sources: `print -(-9223372036854775807 - 1);`, wantMessage: "Integer overflow."},
+		{name: "Integer division by zero", sources: `print 1 ~/ 0;`, wantMessage: "Division by zero."},
+		{name: "Modulo by zero", sources: `print 1 % 0;`, wantMessage: "Division by zero."},
+		{name: "Float integer division by zero", sources: `print 1.5 ~/ 0;`, wantMessage: "Division by zero."},
+		{name: "Integer division out of range", sources: `print float("1e300") ~/ 1;`, wantMessage: "Integer overflow."},
+		{name: "Big integer division by zero", sources: `print 1n ~/ 0;`, wantMessage: "Division by zero."},
+		{name: "Decimal division by zero", sources: `print 1.5d / 0.0d;`, wantMessage: "Division by zero."},
+		{name: "Decimal and float", sources: `print 1.5d + 0.5;`, wantMessage: "Can't mix floats with big integers or decimals, convert them with float()."},
+		{name: "Big integer and float", sources: `print 1n * 0.5;`, wantMessage: "Can't mix floats with big integers or decimals, convert them with float()."},
+		{name: "Conversion of big integer", sources: `print int(9223372036854775808n);`, wantMessage: "int() can't convert 9223372036854775808 to an integer."},
+		{name: "Power overflow", sources: `print 3 ** 40;`, wantMessage: "Integer overflow."},
+		{name: "Decimal exponent", sources: `print 2n ** 0.5d;`, wantMessage: "Exponent of a big integer or a decimal must be an integer."},
+		{name: "Shift overflow", sources: `print 1 << 63;`, wantMessage: "Integer overflow."},
+		{name: "Big integer shift too large", sources: `print 1n << 100000000000;`, wantMessage: "Number is too large."},
+		{name: "Big integer power too large", sources: `print 2n ** 100000000000;`, wantMessage: "Number is too large."},
+		{name: "Decimal power too large", sources: `print 1.5d ** 100000000000;`, wantMessage: "Number is too large."},
+		{name: "Decimal product too large", sources: `var x = 2n ** 10000000 * 1d; print x * x;`, wantMessage: "Number is too large."},
+		{name: "Decimal negative power too large", sources: `print 0.5d ** -9223372036854775807;`, wantMessage: "Number is too large."},
+		{name: "Negative shift", sources: `print 1 >> -1;`, wantMessage: "Negative shift count."},
+		{name: "Bitwise float", sources: `print 1.5 & 1;`, wantMessage: "invalid type for operator AMPERSAND given, must be integer."},
+		{name: "Bitwise not of decimal", sources: `print ~1d;`, wantMessage: "invalid type for operator TILDE given, must be integer."},
+		{name: "Compound assignment of undefined variable", sources: `missing += 1;`, wantMessage: "Undefined variable 'missing'."},
+		{name: "Conversion of infinity", sources: `print int(1 / 0);`, wantMessage: "int() can't convert +Inf to an integer."},
+		{name: "Conversion of text", sources: `print float("abc");`, wantMessage: `float() can't convert "abc" to a number.`},
+		{name: "Conversion of nil", sources: `print int(nil);`, wantMessage: "int() argument 1 must be a number or a string."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lox := New()
+			lox.SetErrorOutput(&bytes.Buffer{})
+
+			err := lox.RunContext(context.Background(), tt.sources)
+
+			var rErr *RuntimeError
+			if !errors.As(err, &rErr) {
+				t.Fatalf("RunContext() error = %v, want a runtime error", err)
+			}
+			if rErr.message != tt.wantMessage {
+				t.Errorf("RuntimeError message = %q, want %q", rErr.message, tt.wantMessage)
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package interpreter
+
+import (
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/parser"
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/parser/ast"
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/scanner"
+)
+
+// Optimize folds constant expressions of statements and removes dead if branches.
+// Operators are folded by the interpreter itself, so folded values are exactly what
+// runs would compute, and operations failing at runtime, e.g. -"x", are left to fail there.
+// Statements are changed in place, folded literals get spans of expressions they replace.
+func Optimize(statements []ast.Stmt, spans map[any]parser.Span) []ast.Stmt {
+	return optimizer{spans: spans}.statements(statements)
+}
+
+type optimizer struct {
+	spans map[any]parser.Span
+}
+
+func (o optimizer) statements(statements []ast.Stmt) []ast.Stmt {
+	optimized := statements[:0]
+	for _, statement := range statements {
+		if statement = o.stmt(statement); statement != nil {
+			optimized = append(optimized, statement)
+		}
+	}
+	return optimized
+}
+
+// stmt optimizes the statement, an if statement with a constant condition is replaced
+// by the branch it takes, nil when there is none.
+func (o optimizer) stmt(stmt ast.Stmt) ast.Stmt {
+	if stmt == nil {
+		return nil
+	}
+	stmt.Accept(o)
+	ifStmt, ok := stmt.(*ast.If)
+	if !ok {
+		return stmt
+	}
+	condition, ok := ifStmt.Condition.(*ast.Literal)
+	if !ok {
+		return stmt
+	}
+	if (Interpreter{}).isTruthy(literalValue(condition.Value)) {
+		return ifStmt.ThenBranch
+	}
+	return ifStmt.ElseBranch
+}
+
+// body optimizes a statement that can't be removed, e.g. a loop body.
+func (o optimizer) body(stmt ast.Stmt) ast.Stmt {
+	if stmt = o.stmt(stmt); stmt == nil {
+		return ast.NewBlock(nil)
+	}
+	return stmt
+}
+
+func (o optimizer) expr(expr ast.Expr) ast.Expr {
+	if expr == nil {
+		return nil
+	}
+	return expr.Accept(o).(ast.Expr)
+}
+
+func (o optimizer) VisitBlock(stmt *ast.Block) {
+	stmt.Statements = o.statements(stmt.Statements)
+}
+
+func (o optimizer) VisitExpression(stmt *ast.Expression) {
+	stmt.Expression = o.expr(stmt.Expression)
+}
+
+func (o optimizer) VisitFunction(stmt *ast.Function) {
+	stmt.Body = o.statements(stmt.Body)
+}
+
+func (o optimizer) VisitIf(stmt *ast.If) {
+	stmt.Condition = o.expr(stmt.Condition)
+	stmt.ThenBranch = o.body(stmt.ThenBranch)
+	stmt.ElseBranch = o.stmt(stmt.ElseBranch)
+}
+
+func (o optimizer) VisitPrint(stmt *ast.Print) {
+	stmt.Expression = o.expr(stmt.Expression)
+}
+
+func (o optimizer) VisitReturn(stmt *ast.Return) {
+	stmt.Value = o.expr(stmt.Value)
+}
+
+func (o optimizer) VisitVar(stmt *ast.Var) {
+	stmt.Initializer = o.expr(stmt.Initializer)
+}
+
+func (o optimizer) VisitWhile(stmt *ast.While) {
+	stmt.Condition = o.expr(stmt.Condition)
+	stmt.Body = o.body(stmt.Body)
+}
+
+func (o optimizer) VisitAssign(expr *ast.Assign) any {
+	expr.Value = o.expr(expr.Value)
+	return expr
+}
+
+func (o optimizer) VisitBinary(expr *ast.Binary) any {
+	expr.Left = o.expr(expr.Left)
+	expr.Right = o.expr(expr.Right)
+	if !isLiteral(expr.Left) || !isLiteral(expr.Right) {
+		return expr
+	}
+	return o.fold(expr)
+}
+
+func (o optimizer) VisitCall(expr *ast.Call) any {
+	expr.Callee = o.expr(expr.Callee)
+	for index, argument := range expr.Arguments {
+		expr.Arguments[index] = o.expr(argument)
+	}
+	return expr
+}
+
+// VisitCoalesce short-circuits literal left operands like VisitLogical does.
+func (o optimizer) VisitCoalesce(expr *ast.Coalesce) any {
+	expr.Left = o.expr(expr.Left)
+	expr.Right = o.expr(expr.Right)
+	left, ok := expr.Left.(*ast.Literal)
+	if !ok {
+		return expr
+	}
+	if left.Value != nil {
+		return o.replace(expr, left)
+	}
+	return o.replace(expr, expr.Right)
+}
+
+// VisitConditional replaces the expression with the branch a literal condition takes.
+func (o optimizer) VisitConditional(expr *ast.Conditional) any {
+	expr.Condition = o.expr(expr.Condition)
+	expr.ThenBranch = o.expr(expr.ThenBranch)
+	expr.ElseBranch = o.expr(expr.ElseBranch)
+	condition, ok := expr.Condition.(*ast.Literal)
+	if !ok {
+		return expr
+	}
+	if (Interpreter{}).isTruthy(literalValue(condition.Value)) {
+		return o.replace(expr, expr.ThenBranch)
+	}
+	return o.replace(expr, expr.ElseBranch)
+}
+
+func (o optimizer) VisitGrouping(expr *ast.Grouping) any {
+	expr.Expression = o.expr(expr.Expression)
+	if !isLiteral(expr.Expression) {
+		return expr
+	}
+	return o.fold(expr)
+}
+
+func (o optimizer) VisitLiteral(expr *ast.Literal) any {
+	return expr
+}
+
+// VisitLogical short-circuits literal left operands, the result of and and or
+// is then either the left operand or the right one.
+func (o optimizer) VisitLogical(expr *ast.Logical) any {
+	expr.Left = o.expr(expr.Left)
+	expr.Right = o.expr(expr.Right)
+	left, ok := expr.Left.(*ast.Literal)
+	if !ok {
+		return expr
+	}
+	if (Interpreter{}).isTruthy(literalValue(left.Value)) == (expr.Operator.Kind() == scanner.OR) {
+		return o.replace(expr, left)
+	}
+	return o.replace(expr, expr.Right)
+}
+
+func (o optimizer) VisitUnary(expr *ast.Unary) any {
+	expr.Right = o.expr(expr.Right)
+	if !isLiteral(expr.Right) {
+		return expr
+	}
+	return o.fold(expr)
+}
+
+func (o optimizer) VisitVariable(expr *ast.Variable) any {
+	return expr
+}
+
+// fold evaluates the expression of literals, expressions failing with runtime errors are kept.
+func (o optimizer) fold(expr ast.Expr) (folded ast.Expr) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			if _, ok := recovered.(*RuntimeError); !ok {
+				panic(recovered)
+			}
+			folded = expr
+		}
+	}()
+	value, ok := Interpreter{hooks: &hooksRef{}, budget: &budget{}}.evaluate(expr).literal()
+	if !ok {
+		return expr
+	}
+	return o.replace(expr, ast.NewLiteral(value))
+}
+
+// replace gives the replacement the span of the replaced expression unless it has its own.
+func (o optimizer) replace(expr ast.Expr, replacement ast.Expr) ast.Expr {
+	if _, ok := o.spans[replacement]; ok {
+		return replacement
+	}
+	if span, ok := o.spans[expr]; ok {
+		o.spans[replacement] = span
+	}
+	return replacement
+}
+
+func isLiteral(expr ast.Expr) bool {
+	_, ok := expr.(*ast.Literal)
+	return ok
+}
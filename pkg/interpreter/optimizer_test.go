@@ -0,0 +1,116 @@
+package interpreter
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/parser"
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/plugins"
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/scanner"
+)
+
+func TestOptimize(t *testing.T) {
+	tests := []struct {
+		name    string
+		sources string
+		want    string
+	}{
+		{
+			name:    "Arithmetic",
+			sources: "print 60 * 60 * 24;",
+			want:    "print 86400;",
+		},
+		{
+			name:    "Grouping",
+			sources: "print (1 + 2) * 3 > 8;",
+			want:    "print true;",
+		},
+		{
+			name:    "String concatenation",
+			sources: `print "a" + "b";`,
+			want:    "print ab;",
+		},
+		{
+			name:    "Operands of variables",
+			sources: "print x * (2 + 3);",
+			want:    "print (* x 5);",
+		},
+		{
+			name:    "Runtime errors kept",
+			sources: `print -"x"; print 1 + "a";`,
+			want:    "print (- x);\nprint (+ 1 a);",
+		},
+		{
+			name:    "Short-circuit",
+			sources: "print nil or x; print 1 and x; print false and x; print x or 1 + 2;",
+			want:    "print x;\nprint x;\nprint false;\nprint (or x 3);",
+		},
+		{
+			name:    "Conditional and nil coalescing",
+			sources: "print true ? x : y; print 0 ? 1 + 1 : y; print nil ?? x; print 2 ?? x; print x ? 1 : 2;",
+			want:    "print x;\nprint 2;\nprint x;\nprint 2;\nprint (?: x 1 2);",
+		},
+		{
+			name:    "Dead then branch",
+			sources: "if (false) print 1; else { print 2; }",
+			want:    "{\n\tprint 2;\n}",
+		},
+		{
+			name:    "Dead else branch",
+			sources: "if (!nil) print 1; else print 2;",
+			want:    "print 1;",
+		},
+		{
+			name:    "Dead if removed",
+			sources: "print 1; if (1 > 2) print 2; print 3;",
+			want:    "print 1;\nprint 3;",
+		},
+		{
+			name:    "Dead loop body",
+			sources: "while (x) if (false) print 1;",
+			want:    "while (x) \n{\n}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prsr := parser.NewParser(scanner.NewScanner(tt.sources, nil).ScanTokens(), nil)
+
+			got := plugins.NewAstPrinter().Sprint(Optimize(prsr.Parse(), prsr.Spans()))
+
+			if got != tt.want {
+				t.Errorf("Optimize() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoxGo_EnableOptimizations(t *testing.T) {
+	sources := `var total = 0;
+for (var i = 0; i < 3; i = i + 1) {
+  total = total + 60 * 60 * 24;
+  if (false) total = 0;
+}
+print total;
+print "a" + "b";
+print -"x";
+`
+	output := bytes.Buffer{}
+	errorOutput := bytes.Buffer{}
+	lox := New()
+	lox.SetOutput(&output)
+	lox.SetErrorOutput(&errorOutput)
+	lox.EnableOptimizations()
+
+	err := lox.RunContext(context.Background(), sources)
+
+	if want := "259200\nab\n"; output.String() != want {
+		t.Errorf("output = %q, want %q", output.String(), want)
+	}
+	if rErr, ok := err.(*RuntimeError); !ok || rErr.Line() != 8 {
+		t.Errorf("RunContext() error = %v, want runtime error on line 8", err)
+	}
+	if errorOutput.Len() == 0 {
+		t.Errorf("RunContext() didn't report the error")
+	}
+}
@@ -0,0 +1,196 @@
+package interpreter
+
+import (
+	"compress/gzip"
+	"io"
+	"sort"
+)
+
+// Field numbers of the pprof profile.proto messages written by WriteProfile,
+// see https://github.com/google/pprof/blob/main/proto/profile.proto.
+const (
+	fieldProfileSampleType  = 1
+	fieldProfileSample      = 2
+	fieldProfileLocation    = 4
+	fieldProfileFunction    = 5
+	fieldProfileStringTable = 6
+	fieldProfileDuration    = 10
+	fieldProfilePeriodType  = 11
+	fieldProfilePeriod      = 12
+	fieldValueTypeType      = 1
+	fieldValueTypeUnit      = 2
+	fieldSampleLocationID   = 1
+	fieldSampleValue        = 2
+	fieldLocationID         = 1
+	fieldLocationLine       = 4
+	fieldLineFunctionID     = 1
+	fieldLineLine           = 2
+	fieldFunctionID         = 1
+	fieldFunctionName       = 2
+	fieldFunctionSystemName = 3
+	fieldFunctionFilename   = 4
+)
+
+// WriteProfile writes a gzipped pprof profile for "go tool pprof": every sample is a Lox call stack
+// with lines and values of executed statements count and time in nanoseconds.
+func (p *Profiler) WriteProfile(out io.Writer) error {
+	zipped := gzip.NewWriter(out)
+	if _, err := zipped.Write(p.encodeProfile()); err != nil {
+		return err
+	}
+	return zipped.Close()
+}
+
+func (p *Profiler) encodeProfile() []byte {
+	table := newStringTable()
+	profile := protoBuffer{}
+	for _, valueType := range [][2]string{{"statements", "count"}, {"time", "nanoseconds"}} {
+		profile.message(fieldProfileSampleType, valueTypeMessage(table, valueType[0], valueType[1]))
+	}
+
+	samples := make([]*profileSample, 0, len(p.samples))
+	for _, sample := range p.samples {
+		samples = append(samples, sample)
+	}
+	// Stable output for the same runs.
+	sort.Slice(samples, func(a, b int) bool {
+		return lessStack(samples[a].stack, samples[b].stack)
+	})
+	locations := map[profileLocation]uint64{}
+	functions := map[string]uint64{}
+	locationMessages := []protoBuffer{}
+	functionMessages := []protoBuffer{}
+	for _, sample := range samples {
+		ids := make([]uint64, 0, len(sample.stack))
+		for _, location := range sample.stack {
+			id, ok := locations[location]
+			if !ok {
+				function, ok := functions[location.function]
+				if !ok {
+					function = uint64(len(functions) + 1)
+					functions[location.function] = function
+					message := protoBuffer{}
+					message.uint(fieldFunctionID, function)
+					message.uint(fieldFunctionName, table.index(location.function))
+					message.uint(fieldFunctionSystemName, table.index(location.function))
+					message.uint(fieldFunctionFilename, table.index(p.fileName))
+					functionMessages = append(functionMessages, message)
+				}
+				id = uint64(len(locations) + 1)
+				locations[location] = id
+				line := protoBuffer{}
+				line.uint(fieldLineFunctionID, function)
+				line.uint(fieldLineLine, uint64(location.line))
+				message := protoBuffer{}
+				message.uint(fieldLocationID, id)
+				message.message(fieldLocationLine, line)
+				locationMessages = append(locationMessages, message)
+			}
+			ids = append(ids, id)
+		}
+		message := protoBuffer{}
+		message.packed(fieldSampleLocationID, ids)
+		message.packed(fieldSampleValue, []uint64{uint64(sample.count), uint64(sample.time.Nanoseconds())})
+		profile.message(fieldProfileSample, message)
+	}
+	for _, message := range locationMessages {
+		profile.message(fieldProfileLocation, message)
+	}
+	for _, message := range functionMessages {
+		profile.message(fieldProfileFunction, message)
+	}
+	profile.uint(fieldProfileDuration, uint64(p.duration.Nanoseconds()))
+	profile.message(fieldProfilePeriodType, valueTypeMessage(table, "statements", "count"))
+	profile.uint(fieldProfilePeriod, 1)
+	// The table is complete after all messages referring to it.
+	for _, s := range table.strings {
+		profile.bytes(fieldProfileStringTable, []byte(s))
+	}
+	return profile
+}
+
+func lessStack(a, b []profileLocation) bool {
+	for index := 0; index < len(a) && index < len(b); index++ {
+		if a[index] != b[index] {
+			if a[index].function != b[index].function {
+				return a[index].function < b[index].function
+			}
+			return a[index].line < b[index].line
+		}
+	}
+	return len(a) < len(b)
+}
+
+func valueTypeMessage(table *stringTable, kind string, unit string) protoBuffer {
+	message := protoBuffer{}
+	message.uint(fieldValueTypeType, table.index(kind))
+	message.uint(fieldValueTypeUnit, table.index(unit))
+	return message
+}
+
+// stringTable indexes profile strings, the empty string is the first one as pprof requires.
+type stringTable struct {
+	strings []string
+	indexes map[string]uint64
+}
+
+func newStringTable() *stringTable {
+	return &stringTable{strings: []string{""}, indexes: map[string]uint64{"": 0}}
+}
+
+func (t *stringTable) index(s string) uint64 {
+	index, ok := t.indexes[s]
+	if !ok {
+		index = uint64(len(t.strings))
+		t.strings = append(t.strings, s)
+		t.indexes[s] = index
+	}
+	return index
+}
+
+// protoBuffer encodes protocol buffers fields, only varints and length-delimited ones are needed.
+type protoBuffer []byte
+
+const (
+	wireVarint          = 0
+	wireLengthDelimited = 2
+)
+
+func (b *protoBuffer) varint(value uint64) {
+	for value >= 0x80 {
+		*b = append(*b, byte(value)|0x80)
+		value >>= 7
+	}
+	*b = append(*b, byte(value))
+}
+
+func (b *protoBuffer) key(field int, wireType int) {
+	b.varint(uint64(field)<<3 | uint64(wireType))
+}
+
+// uint skips zero values as proto3 does.
+func (b *protoBuffer) uint(field int, value uint64) {
+	if value == 0 {
+		return
+	}
+	b.key(field, wireVarint)
+	b.varint(value)
+}
+
+func (b *protoBuffer) bytes(field int, value []byte) {
+	b.key(field, wireLengthDelimited)
+	b.varint(uint64(len(value)))
+	*b = append(*b, value...)
+}
+
+func (b *protoBuffer) message(field int, message protoBuffer) {
+	b.bytes(field, message)
+}
+
+func (b *protoBuffer) packed(field int, values []uint64) {
+	packed := protoBuffer{}
+	for _, value := range values {
+		packed.varint(value)
+	}
+	b.bytes(field, packed)
+}
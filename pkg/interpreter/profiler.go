@@ -0,0 +1,364 @@
+package interpreter
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/parser"
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/parser/ast"
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/scanner"
+)
+
+// scriptFunction names top level code in profiles and stack traces, like clox does,
+// pprof hides names in angle brackets.
+const scriptFunction = "script"
+
+// Profiler is ParseHooks measuring where a program spends time.
+// Time between hook calls is charged to the line being executed and to the Lox call stack
+// leading to it, so a line's self time excludes functions it calls.
+// Executed statements are counted per line, calls per function.
+type Profiler struct {
+	fileName string
+	nodes    sourceNodes
+	// lineTexts are sources of lines in the formatter style.
+	lineTexts map[int]string
+
+	now      func() time.Time
+	last     time.Time
+	duration time.Duration
+
+	frames  []*profileFrame
+	pending []*pendingCall
+
+	lines     map[int]*lineProfile
+	functions map[string]*functionProfile
+	samples   map[string]*profileSample
+}
+
+// profileFrame is a called function with the lines of statements being executed in it.
+type profileFrame struct {
+	function string
+	lines    []int
+	start    time.Time
+}
+
+func (f *profileFrame) line() int {
+	if len(f.lines) == 0 {
+		return 0
+	}
+	return f.lines[len(f.lines)-1]
+}
+
+// pendingCall is a call expression evaluating its callee and arguments,
+// the called function's frame starts after the last of them.
+type pendingCall struct {
+	call     *ast.Call
+	function string
+	line     int
+	frame    *profileFrame
+}
+
+type lineProfile struct {
+	count int
+	self  time.Duration
+}
+
+type functionProfile struct {
+	calls int
+	self  time.Duration
+	// total counts the outermost of recursive calls only.
+	total  time.Duration
+	active int
+}
+
+// profileSample is a call stack, innermost location first.
+type profileSample struct {
+	stack []profileLocation
+	count int
+	time  time.Duration
+}
+
+type profileLocation struct {
+	function string
+	line     int
+}
+
+// NewProfiler profiles runs of the file, its name is reported in pprof profiles.
+func NewProfiler(fileName string) *Profiler {
+	return &Profiler{
+		fileName:  fileName,
+		nodes:     sourceNodes{},
+		lineTexts: map[int]string{},
+		now:       time.Now,
+		frames:    []*profileFrame{{function: scriptFunction}},
+		lines:     map[int]*lineProfile{},
+		functions: map[string]*functionProfile{scriptFunction: {calls: 1}},
+		samples:   map[string]*profileSample{},
+	}
+}
+
+func (p *Profiler) Parsed(tokens []scanner.Token, spans map[any]parser.Span) {
+	p.nodes.add(tokens, spans)
+	lines := map[int][]scanner.Token{}
+	for _, token := range tokens {
+		if token.Kind() != scanner.EOF {
+			lines[token.Line()] = append(lines[token.Line()], token)
+		}
+	}
+	for line, tokens := range lines {
+		p.lineTexts[line] = joinTokens(tokens)
+	}
+}
+
+func (p *Profiler) BeforeExecute(stmt ast.Stmt) {
+	p.charge()
+	frame := p.frames[len(p.frames)-1]
+	line, counted := p.statementLine(stmt)
+	if line == 0 {
+		line = frame.line()
+	}
+	frame.lines = append(frame.lines, line)
+	if counted {
+		p.line(line).count++
+		p.sample().count++
+	}
+}
+
+// statementLine is the line of the statement and whether executing it counts,
+// statements the parser made up, e.g. loops of for statements, take lines of their parts.
+func (p *Profiler) statementLine(stmt ast.Stmt) (int, bool) {
+	if node, ok := p.nodes[stmt]; ok {
+		_, isBlock := stmt.(*ast.Block)
+		return node.line, !isBlock
+	}
+	switch stmt := stmt.(type) {
+	case *ast.While:
+		return p.nodes[stmt.Condition].line, false
+	case *ast.Expression:
+		return p.nodes[stmt.Expression].line, false
+	}
+	return 0, false
+}
+
+func (p *Profiler) AfterExecute(ast.Stmt) {
+	p.charge()
+	frame := p.frames[len(p.frames)-1]
+	if len(frame.lines) > 0 {
+		frame.lines = frame.lines[:len(frame.lines)-1]
+	}
+	if len(p.frames) == 1 && len(frame.lines) == 0 {
+		// Time between top level statements, e.g. in the REPL, is not the program's.
+		p.last = time.Time{}
+	}
+}
+
+func (p *Profiler) BeforeEvaluate(expr ast.Expr) {
+	if call, ok := expr.(*ast.Call); ok {
+		p.pending = append(p.pending, &pendingCall{call: call})
+	}
+}
+
+// AfterEvaluate names the called function after its callee is evaluated
+// and starts its frame after its last argument is.
+func (p *Profiler) AfterEvaluate(expr ast.Expr, value Value) {
+	if len(p.pending) == 0 {
+		return
+	}
+	pending := p.pending[len(p.pending)-1]
+	if expr == pending.call {
+		p.pending = p.pending[:len(p.pending)-1]
+		p.exitFunction(pending.frame)
+		return
+	}
+	if pending.frame != nil {
+		return
+	}
+	if expr == pending.call.Callee {
+		pending.function = functionName(value)
+		if function, ok := value.AsObject().(*loxFunction); ok {
+			pending.line = p.nodes[function.declaration].line
+		}
+	}
+	arguments := pending.call.Arguments
+	if (len(arguments) == 0 && expr == pending.call.Callee) ||
+		(len(arguments) > 0 && expr == arguments[len(arguments)-1]) {
+		pending.frame = p.enterFunction(pending.function, pending.line)
+	}
+}
+
+func functionName(callee Value) string {
+	switch callee := callee.AsObject().(type) {
+	case *loxFunction:
+		return callee.declaration.Name.Lexeme()
+	case *nativeFunction:
+		return callee.name
+	}
+	return "?"
+}
+
+// enterFunction starts a frame, Lox functions are at their declaration lines until their first statement.
+func (p *Profiler) enterFunction(name string, line int) *profileFrame {
+	p.charge()
+	frame := &profileFrame{function: name, start: p.last}
+	if line > 0 {
+		frame.lines = []int{line}
+	}
+	p.frames = append(p.frames, frame)
+	function := p.function(name)
+	function.calls++
+	function.active++
+	return frame
+}
+
+// exitFunction drops the frame with frames above it, left by statements unwound by return.
+func (p *Profiler) exitFunction(frame *profileFrame) {
+	p.charge()
+	for index := len(p.frames) - 1; index > 0; index-- {
+		if p.frames[index] != frame {
+			continue
+		}
+		function := p.function(frame.function)
+		function.active--
+		if function.active == 0 {
+			function.total += p.last.Sub(frame.start)
+		}
+		p.frames = p.frames[:index]
+		return
+	}
+}
+
+func (p *Profiler) Define(string, Value) {}
+
+func (p *Profiler) Assign(string, Value) {}
+
+// RuntimeError ends the run, frames of unfinished calls are dropped.
+func (p *Profiler) RuntimeError(*RuntimeError) {
+	p.charge()
+	for len(p.frames) > 1 {
+		p.exitFunction(p.frames[len(p.frames)-1])
+	}
+	p.frames[0].lines = nil
+	p.pending = nil
+	p.last = time.Time{}
+}
+
+// charge adds time since the previous hook call to the current function, line and stack.
+func (p *Profiler) charge() {
+	now := p.now()
+	if !p.last.IsZero() {
+		elapsed := now.Sub(p.last)
+		p.duration += elapsed
+		p.function(p.frames[len(p.frames)-1].function).self += elapsed
+		// Natives have no lines, their time is the calling line's.
+		for index := len(p.frames) - 1; index >= 0; index-- {
+			if line := p.frames[index].line(); line > 0 {
+				p.line(line).self += elapsed
+				break
+			}
+		}
+		p.sample().time += elapsed
+	}
+	p.last = now
+}
+
+func (p *Profiler) line(line int) *lineProfile {
+	profile, ok := p.lines[line]
+	if !ok {
+		profile = &lineProfile{}
+		p.lines[line] = profile
+	}
+	return profile
+}
+
+func (p *Profiler) function(name string) *functionProfile {
+	profile, ok := p.functions[name]
+	if !ok {
+		profile = &functionProfile{}
+		p.functions[name] = profile
+	}
+	return profile
+}
+
+// sample is the sample of the current call stack.
+func (p *Profiler) sample() *profileSample {
+	stack := make([]profileLocation, 0, len(p.frames))
+	key := strings.Builder{}
+	for index := len(p.frames) - 1; index >= 0; index-- {
+		location := profileLocation{function: p.frames[index].function, line: p.frames[index].line()}
+		stack = append(stack, location)
+		fmt.Fprintf(&key, "%s:%d;", location.function, location.line)
+	}
+	sample, ok := p.samples[key.String()]
+	if !ok {
+		sample = &profileSample{stack: stack}
+		p.samples[key.String()] = sample
+	}
+	return sample
+}
+
+// hotLines is how many lines the report lists.
+const hotLines = 10
+
+// WriteReport writes total time, the hottest lines by self time and all functions by total time.
+func (p *Profiler) WriteReport(out io.Writer) error {
+	report := strings.Builder{}
+	statements := 0
+	for _, line := range p.lines {
+		statements += line.count
+	}
+	fmt.Fprintf(&report, "Total time %s, %d statements executed.\n", formatDuration(p.duration), statements)
+
+	lines := make([]int, 0, len(p.lines))
+	for line := range p.lines {
+		lines = append(lines, line)
+	}
+	sort.Slice(lines, func(a, b int) bool {
+		if p.lines[lines[a]].self != p.lines[lines[b]].self {
+			return p.lines[lines[a]].self > p.lines[lines[b]].self
+		}
+		return lines[a] < lines[b]
+	})
+	if len(lines) > hotLines {
+		lines = lines[:hotLines]
+	}
+	fmt.Fprintf(&report, "\nHot lines:\n%10s %7s %10s %6s  %s\n", "self", "%", "count", "line", "source")
+	for _, line := range lines {
+		profile := p.lines[line]
+		fmt.Fprintf(&report, "%10s %6.1f%% %10d %6d  %s\n",
+			formatDuration(profile.self), p.percent(profile.self), profile.count, line, p.lineTexts[line])
+	}
+
+	functions := make([]string, 0, len(p.functions))
+	for name := range p.functions {
+		functions = append(functions, name)
+	}
+	p.functions[scriptFunction].total = p.duration
+	sort.Slice(functions, func(a, b int) bool {
+		if p.functions[functions[a]].total != p.functions[functions[b]].total {
+			return p.functions[functions[a]].total > p.functions[functions[b]].total
+		}
+		return functions[a] < functions[b]
+	})
+	fmt.Fprintf(&report, "\nFunctions:\n%10s %7s %10s %10s  %s\n", "self", "%", "total", "calls", "function")
+	for _, name := range functions {
+		profile := p.functions[name]
+		fmt.Fprintf(&report, "%10s %6.1f%% %10s %10d  %s\n",
+			formatDuration(profile.self), p.percent(profile.self), formatDuration(profile.total), profile.calls, name)
+	}
+	_, err := io.WriteString(out, report.String())
+	return err
+}
+
+func (p *Profiler) percent(d time.Duration) float64 {
+	if p.duration == 0 {
+		return 0
+	}
+	return 100 * float64(d) / float64(p.duration)
+}
+
+func formatDuration(d time.Duration) string {
+	return d.Round(time.Microsecond).String()
+}
@@ -0,0 +1,128 @@
+package interpreter
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+	"time"
+)
+
+const profiledSources = `fun fib(n) {
+  if (n < 2) return n;
+  return fib(n - 1) + fib(n - 2);
+}
+for (var i = 0; i < 3; i = i + 1) {
+  print fib(i) + len("ab");
+}
+`
+
+// profile runs sources with a clock advancing a millisecond on every reading.
+func profile(t *testing.T, sources string) *Profiler {
+	t.Helper()
+	clock := time.Unix(0, 0)
+	profiler := NewProfiler("fib.lox")
+	profiler.now = func() time.Time {
+		clock = clock.Add(time.Millisecond)
+		return clock
+	}
+	lox := New()
+	lox.SetOutput(io.Discard)
+	lox.SetHooks(profiler)
+	lox.Run(sources)
+	if lox.Failed() {
+		t.Fatalf("Run() failed")
+	}
+	return profiler
+}
+
+func TestProfiler_WriteReport(t *testing.T) {
+	profiler := profile(t, profiledSources)
+	report := bytes.Buffer{}
+	if err := profiler.WriteReport(&report); err != nil {
+		t.Fatal(err)
+	}
+	want := `Total time 57ms, 15 statements executed.
+
+Hot lines:
+      self       %      count   line  source
+      26ms   45.6%          1      5  for (var i = 0; i < 3; i = i + 1) {
+      12ms   21.1%          3      6  print fib(i) + len("ab");
+       9ms   15.8%          9      2  if (n < 2) return n;
+       7ms   12.3%          1      1  fun fib(n) {
+       3ms    5.3%          1      3  return fib(n - 1) + fib(n - 2);
+
+Functions:
+      self       %      total      calls  function
+      36ms   63.2%       57ms          1  script
+      18ms   31.6%       18ms          5  fib
+       3ms    5.3%        3ms          3  len
+`
+	if got := report.String(); got != want {
+		t.Errorf("report =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestProfiler_WriteProfile(t *testing.T) {
+	profiler := profile(t, profiledSources)
+	out := bytes.Buffer{}
+	if err := profiler.WriteProfile(&out); err != nil {
+		t.Fatal(err)
+	}
+	reader, err := gzip.NewReader(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counts := map[uint64]int{}
+	var strings []string
+	for len(data) > 0 {
+		key, n := readVarint(t, data)
+		data = data[n:]
+		field, wireType := key>>3, key&7
+		counts[field]++
+		if wireType == wireVarint {
+			_, n = readVarint(t, data)
+			data = data[n:]
+			continue
+		}
+		length, n := readVarint(t, data)
+		value := data[n : n+int(length)]
+		data = data[n+int(length):]
+		if field == fieldProfileStringTable {
+			strings = append(strings, string(value))
+		}
+	}
+
+	// Stacks: script at lines 1, 5 and 6; fib at 1, 2 and 3 from line 6 of script
+	// and at 1 and 2 from line 3 of fib; len from line 6 of script.
+	want := map[uint64]int{
+		fieldProfileSampleType: 2, fieldProfileSample: 9, fieldProfileLocation: 7, fieldProfileFunction: 3,
+		fieldProfileStringTable: 9, fieldProfileDuration: 1, fieldProfilePeriodType: 1, fieldProfilePeriod: 1,
+	}
+	for field, count := range want {
+		if counts[field] != count {
+			t.Errorf("field %d count = %d, want %d", field, counts[field], count)
+		}
+	}
+	if len(strings) == 0 || strings[0] != "" {
+		t.Errorf("string table = %q, want empty first string", strings)
+	}
+}
+
+func readVarint(t *testing.T, data []byte) (uint64, int) {
+	t.Helper()
+	value := uint64(0)
+	for index := 0; index < len(data); index++ {
+		value |= uint64(data[index]&0x7f) << (7 * index)
+		if data[index] < 0x80 {
+			return value, index + 1
+		}
+	}
+	t.Fatalf("truncated varint")
+	return 0, 0
+}
@@ -0,0 +1,207 @@
+package interpreter
+
+import (
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/parser/ast"
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/scanner"
+)
+
+// resolverScope is a scope being resolved, slots are numbered in declaration order.
+type resolverScope struct {
+	layout *ast.Scope
+	slots  map[string]int
+}
+
+// declare gives the name a slot, a name declared again in the same scope keeps its slot,
+// the same way a variable declared again replaces the previous one.
+func (s *resolverScope) declare(name string) int {
+	if index, ok := s.slots[name]; ok {
+		return index
+	}
+	index := len(s.layout.Names)
+	s.slots[name] = index
+	s.layout.Names = append(s.layout.Names, name)
+	return index
+}
+
+// resolver numbers slots of local variables, like jlox resolves their depths,
+// and stores them in the nodes, so they are not looked up while running.
+// Top level declarations are globals and stay looked up by name, so do variables
+// not declared in any enclosing scope when they are resolved.
+type resolver struct {
+	scopes []*resolverScope
+}
+
+// resolve records slots and scopes in nodes of the statements.
+func resolve(statements []ast.Stmt) {
+	r := resolver{}
+	r.statements(statements)
+}
+
+// resolveIn resolves an expression parsed while the program runs, e.g. by the debugger,
+// against variables defined so far in the environment and its enclosing ones.
+func resolveIn(environment *Environment, expr ast.Expr) {
+	r := resolver{}
+	for env := environment; env != nil && env.values == nil; env = env.enclosing {
+		scope := &resolverScope{layout: &ast.Scope{}, slots: map[string]int{}}
+		for _, name := range env.names[:env.defined] {
+			scope.declare(name)
+		}
+		r.scopes = append([]*resolverScope{scope}, r.scopes...)
+	}
+	r.expr(expr)
+}
+
+func (r *resolver) statements(statements []ast.Stmt) {
+	for _, statement := range statements {
+		r.stmt(statement)
+	}
+}
+
+func (r *resolver) stmt(stmt ast.Stmt) {
+	if stmt != nil {
+		stmt.Accept(r)
+	}
+}
+
+func (r *resolver) expr(expr ast.Expr) {
+	if expr != nil {
+		expr.Accept(r)
+	}
+}
+
+func (r *resolver) beginScope() *resolverScope {
+	scope := &resolverScope{layout: &ast.Scope{}, slots: map[string]int{}}
+	r.scopes = append(r.scopes, scope)
+	return scope
+}
+
+func (r *resolver) endScope() {
+	r.scopes = r.scopes[:len(r.scopes)-1]
+}
+
+// declare gives a local declaration its slot, top level ones are globals.
+func (r *resolver) declare(name scanner.Token) *ast.Slot {
+	if len(r.scopes) == 0 {
+		return nil
+	}
+	return &ast.Slot{Index: r.scopes[len(r.scopes)-1].declare(name.Lexeme())}
+}
+
+// local finds the slot of the innermost variable with the name, if any.
+// Expressions resolved again, e.g. breakpoint conditions, may turn out globals.
+func (r *resolver) local(name scanner.Token) *ast.Slot {
+	for depth := 0; depth < len(r.scopes); depth++ {
+		scope := r.scopes[len(r.scopes)-1-depth]
+		if index, ok := scope.slots[name.Lexeme()]; ok {
+			return &ast.Slot{Depth: depth, Index: index}
+		}
+	}
+	return nil
+}
+
+func (r *resolver) VisitBlock(stmt *ast.Block) {
+	stmt.Scope = r.beginScope().layout
+	r.statements(stmt.Statements)
+	r.endScope()
+}
+
+func (r *resolver) VisitExpression(stmt *ast.Expression) {
+	r.expr(stmt.Expression)
+}
+
+// VisitFunction declares the function before resolving its body, so it can call itself.
+// Parameters and the body share the function scope.
+func (r *resolver) VisitFunction(stmt *ast.Function) {
+	stmt.Local = r.declare(stmt.Name)
+	scope := r.beginScope()
+	stmt.Scope = scope.layout
+	for _, param := range stmt.Params {
+		scope.layout.Params = append(scope.layout.Params, scope.declare(param.Lexeme()))
+	}
+	r.statements(stmt.Body)
+	r.endScope()
+}
+
+func (r *resolver) VisitIf(stmt *ast.If) {
+	r.expr(stmt.Condition)
+	r.stmt(stmt.ThenBranch)
+	r.stmt(stmt.ElseBranch)
+}
+
+func (r *resolver) VisitPrint(stmt *ast.Print) {
+	r.expr(stmt.Expression)
+}
+
+func (r *resolver) VisitReturn(stmt *ast.Return) {
+	r.expr(stmt.Value)
+}
+
+// VisitVar resolves the initializer first, so it reads a variable of an enclosing scope
+// with the same name, as it did before the variable was declared.
+func (r *resolver) VisitVar(stmt *ast.Var) {
+	r.expr(stmt.Initializer)
+	stmt.Local = r.declare(stmt.Name)
+}
+
+func (r *resolver) VisitWhile(stmt *ast.While) {
+	r.expr(stmt.Condition)
+	r.stmt(stmt.Body)
+}
+
+func (r *resolver) VisitAssign(expr *ast.Assign) any {
+	r.expr(expr.Value)
+	expr.Local = r.local(expr.Name)
+	return nil
+}
+
+func (r *resolver) VisitBinary(expr *ast.Binary) any {
+	r.expr(expr.Left)
+	r.expr(expr.Right)
+	return nil
+}
+
+func (r *resolver) VisitCall(expr *ast.Call) any {
+	r.expr(expr.Callee)
+	for _, argument := range expr.Arguments {
+		r.expr(argument)
+	}
+	return nil
+}
+
+func (r *resolver) VisitCoalesce(expr *ast.Coalesce) any {
+	r.expr(expr.Left)
+	r.expr(expr.Right)
+	return nil
+}
+
+func (r *resolver) VisitConditional(expr *ast.Conditional) any {
+	r.expr(expr.Condition)
+	r.expr(expr.ThenBranch)
+	r.expr(expr.ElseBranch)
+	return nil
+}
+
+func (r *resolver) VisitGrouping(expr *ast.Grouping) any {
+	r.expr(expr.Expression)
+	return nil
+}
+
+func (r *resolver) VisitLiteral(*ast.Literal) any {
+	return nil
+}
+
+func (r *resolver) VisitLogical(expr *ast.Logical) any {
+	r.expr(expr.Left)
+	r.expr(expr.Right)
+	return nil
+}
+
+func (r *resolver) VisitUnary(expr *ast.Unary) any {
+	r.expr(expr.Right)
+	return nil
+}
+
+func (r *resolver) VisitVariable(expr *ast.Variable) any {
+	expr.Local = r.local(expr.Name)
+	return nil
+}
@@ -0,0 +1,108 @@
+package interpreter
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/parser"
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/parser/ast"
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/scanner"
+)
+
+func TestInterpreter_resolve(t *testing.T) {
+	sources := `var a = 1;
+{
+  var b = a;
+  {
+    print a + b;
+  }
+}`
+	statements := parser.NewParser(scanner.NewScanner(sources, nil).ScanTokens(), nil).Parse()
+
+	resolve(statements)
+
+	outer := statements[1].(*ast.Block)
+	inner := outer.Statements[1].(*ast.Block)
+	sum := inner.Statements[0].(*ast.Print).Expression.(*ast.Binary)
+	if local := sum.Left.(*ast.Variable).Local; local != nil {
+		t.Errorf("global a resolved as a local %v", *local)
+	}
+	if got, want := sum.Right.(*ast.Variable).Local, (ast.Slot{Depth: 1, Index: 0}); got == nil || *got != want {
+		t.Errorf("b slot = %v, want %v", got, want)
+	}
+	if got := outer.Scope.Names; len(got) != 1 || got[0] != "b" {
+		t.Errorf("outer block slots = %v, want [b]", got)
+	}
+	if got := inner.Scope.Names; len(got) != 0 {
+		t.Errorf("inner block slots = %v, want none", got)
+	}
+}
+
+func TestLoxGo_RunLocals(t *testing.T) {
+	tests := []struct {
+		name    string
+		sources string
+		want    string
+	}{
+		{
+			name:    "Shadowing",
+			sources: `var a = "global"; { var a = "outer"; { var a = "inner"; print a; } print a; } print a;`,
+			want:    "inner\nouter\nglobal\n",
+		},
+		{
+			name:    "Initializer reads enclosing variable",
+			sources: `var a = "outer"; { var a = a + " inner"; print a; }`,
+			want:    "outer inner\n",
+		},
+		{
+			name:    "Declared again",
+			sources: `{ var a = 1; fun f() { return a; } var a = a + 1; print f(); }`,
+			want:    "2\n",
+		},
+		{
+			name:    "Closures bind scopes they are declared in",
+			sources: `var a = "global"; { fun show() { print a; } show(); var a = "block"; show(); }`,
+			want:    "global\nglobal\n",
+		},
+		{
+			name: "Closure counter",
+			sources: `fun counter() {
+  var count = 0;
+  fun increment() { count = count + 1; return count; }
+  return increment;
+}
+var first = counter();
+var second = counter();
+first();
+print first();
+print second();`,
+			want: "2\n1\n",
+		},
+		{
+			name:    "Parameters",
+			sources: `fun f(a, b) { var c = a - b; { var a = c * 2; return a; } } print f(5, 3);`,
+			want:    "4\n",
+		},
+		{
+			name:    "Recursion in blocks",
+			sources: `{ fun fact(n) { if (n < 2) return 1; return n * fact(n - 1); } print fact(5); }`,
+			want:    "120\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := bytes.Buffer{}
+			lox := New()
+			lox.SetOutput(&output)
+
+			if err := lox.RunContext(context.Background(), tt.sources); err != nil {
+				t.Fatalf("RunContext() error = %v", err)
+			}
+
+			if output.String() != tt.want {
+				t.Errorf("output = %q, want %q", output.String(), tt.want)
+			}
+		})
+	}
+}
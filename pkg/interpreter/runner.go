@@ -2,7 +2,10 @@ package interpreter
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"strings"
@@ -14,8 +17,10 @@ import (
 type LoxGo struct {
 	hadError        bool
 	hadRuntimeError bool
+	optimize        bool
 
 	interpreter Interpreter
+	stderr      io.Writer
 }
 
 func New() *LoxGo {
@@ -23,9 +28,93 @@ func New() *LoxGo {
 		hadError:        false,
 		hadRuntimeError: false,
 		interpreter:     NewInterpreter(),
+		stderr:          os.Stderr,
 	}
 }
 
+// SetArgs exposes script command-line arguments as the global args list.
+func (lox *LoxGo) SetArgs(args []string) {
+	lox.interpreter.setArgs(args)
+}
+
+// SetInput configures the stream read by readLine() and readAll() natives.
+func (lox *LoxGo) SetInput(input io.Reader) {
+	lox.interpreter.stdin = bufio.NewReader(input)
+}
+
+// SetOutput configures the stream print statements write to.
+func (lox *LoxGo) SetOutput(output io.Writer) {
+	lox.interpreter.stdout = output
+}
+
+// SetErrorOutput configures the stream compile and runtime errors are reported to.
+func (lox *LoxGo) SetErrorOutput(output io.Writer) {
+	lox.stderr = output
+}
+
+// EnableAssertions installs assert() and assertEqual() natives used by Lox tests.
+func (lox *LoxGo) EnableAssertions() {
+	defineNatives(lox.interpreter.globals, assertionNatives()...)
+}
+
+// EnableOptimizations makes following runs fold constant expressions and drop dead branches, see Optimize.
+func (lox *LoxGo) EnableOptimizations() {
+	lox.optimize = true
+}
+
+// Failed reports whether the last run had compile or runtime errors.
+func (lox *LoxGo) Failed() bool {
+	return lox.hadError || lox.hadRuntimeError
+}
+
+// Call calls a global function without arguments, e.g. a test function.
+// Runtime errors are returned as *RuntimeError, not reported.
+func (lox *LoxGo) Call(name string) error {
+	return lox.interpreter.callGlobal(name)
+}
+
+// SetSeed makes random natives reproducible: runs with the same seed give the same numbers.
+func (lox *LoxGo) SetSeed(seed int64) {
+	lox.interpreter.random.Seed(seed)
+}
+
+// SetSandbox installs natives allowed by the profile, calls of others fail with runtime errors.
+func (lox *LoxGo) SetSandbox(profile SandboxProfile) error {
+	capabilities := profile.Capabilities()
+	if capabilities == nil {
+		return fmt.Errorf("unknown sandbox profile %q", profile)
+	}
+	lox.SetCapabilities(capabilities...)
+	return nil
+}
+
+// SetCapabilities installs natives of exactly the capabilities, like SetSandbox.
+func (lox *LoxGo) SetCapabilities(capabilities ...Capability) {
+	lox.interpreter.setCapabilities(capabilities)
+}
+
+// SetLimits bounds following runs, see Limits.
+func (lox *LoxGo) SetLimits(limits Limits) {
+	lox.interpreter.budget.limits = limits
+}
+
+// SetMaxCallDepth bounds recursion, deeper calls fail with the "Stack overflow." runtime error.
+// It is DefaultMaxCallDepth by default.
+func (lox *LoxGo) SetMaxCallDepth(depth int) {
+	lox.interpreter.calls.maxDepth = depth
+}
+
+// SetDebugger makes following runs stop as the debugger says, Run blocks while stopped.
+func (lox *LoxGo) SetDebugger(debugger *Debugger) {
+	lox.interpreter.debugger = debugger
+}
+
+// SetHooks makes following runs call hooks, nil removes them.
+// ParseHooks are also told about parsed sources.
+func (lox *LoxGo) SetHooks(hooks Hooks) {
+	lox.interpreter.hooks.hooks = hooks
+}
+
 func (lox *LoxGo) RunFile(fileName string) {
 	sources, err := os.ReadFile(fileName)
 	if err != nil {
@@ -33,24 +122,37 @@ func (lox *LoxGo) RunFile(fileName string) {
 	}
 	log.Printf("running file: %s\n", fileName)
 	lox.Run(string(sources))
+	if code := lox.ExitCode(); code != 0 {
+		os.Exit(code)
+	}
+}
+
+// ExitCode is the process exit code for the last run: 65 after compile errors,
+// 70 after runtime errors and 0 on success.
+func (lox *LoxGo) ExitCode() int {
 	if lox.hadError {
-		os.Exit(65)
+		return 65
 	}
 	if lox.hadRuntimeError {
-		os.Exit(70)
+		return 70
 	}
+	return 0
 }
 
 func (lox *LoxGo) RunPrompt() {
 	log.Println("running prompt")
-	scannr := bufio.NewScanner(os.Stdin)
+	// Reading lines from the input stream shared with natives without
+	// buffering ahead, so readLine() gets the lines following the prompt.
 	for {
 		fmt.Print("> ")
-		ok := scannr.Scan()
-		if !ok {
+		input, err := lox.interpreter.stdin.ReadString('\n')
+		if err != nil && (err != io.EOF || input == "") {
+			if err != io.EOF {
+				log.Println(err)
+			}
 			break
 		}
-		input := scannr.Text()
+		input = strings.TrimRight(input, "\r\n")
 		if input == "" {
 			continue
 		}
@@ -62,12 +164,18 @@ func (lox *LoxGo) RunPrompt() {
 		lox.hadError = false
 		lox.hadRuntimeError = false
 	}
-	if err := scannr.Err(); err != nil {
-		log.Println(err)
-	}
 }
 
 func (lox *LoxGo) Run(sources string) {
+	_ = lox.RunContext(context.Background(), sources)
+}
+
+// ErrCompile is returned by RunContext for sources with compile errors.
+var ErrCompile = errors.New("compile errors")
+
+// RunContext runs sources like Run and also returns the error ending the run after reporting it:
+// ErrCompile, *RuntimeError or *LimitError. The run stops with a *LimitError when the context is done.
+func (lox *LoxGo) RunContext(ctx context.Context, sources string) error {
 	errRepCallback := func(line int, message string) {
 		lox.erro(line, message)
 	}
@@ -81,14 +189,24 @@ func (lox *LoxGo) Run(sources string) {
 	//fmt.Println((&parser.AstPrinter{}).Sprint(astTree))
 	//fmt.Println(plugins.AstPrinter{}.Sprint(astTree))
 	if lox.hadError {
-		return
+		return ErrCompile
+	}
+	if lox.optimize {
+		statements = Optimize(statements, parsr.Spans())
+	}
+	if lox.interpreter.debugger != nil {
+		lox.interpreter.debugger.addLines(tokens, parsr.Spans())
+	}
+	if hooks, ok := lox.interpreter.hooks.hooks.(ParseHooks); ok {
+		hooks.Parsed(tokens, parsr.Spans())
 	}
 
 	// Trying to interpret.
-	err := lox.interpreter.Interpret(statements)
+	err := lox.interpreter.Interpret(ctx, statements)
 	if err != nil {
 		lox.runtimeError(err)
 	}
+	return err
 }
 
 func (lox *LoxGo) erro(line int, message string) {
@@ -96,7 +214,7 @@ func (lox *LoxGo) erro(line int, message string) {
 }
 
 func (lox *LoxGo) report(line int, where string, message string) {
-	_, err := fmt.Fprintln(os.Stderr, "[line ", line, "] Error"+where+": "+message)
+	_, err := fmt.Fprintln(lox.stderr, "[line ", line, "] Error"+where+": "+message)
 	if err != nil {
 		log.Fatalln(err)
 	}
@@ -104,11 +222,48 @@ func (lox *LoxGo) report(line int, where string, message string) {
 }
 
 func (lox *LoxGo) runtimeError(err error) {
+	if lErr, ok := err.(*LimitError); ok {
+		lox.limitError(lErr)
+		return
+	}
 	rErr, ok := err.(*RuntimeError)
 	if !ok {
 		log.Fatalln(err)
 	}
-	_, err = fmt.Fprintln(os.Stderr, "[line ", rErr.token.Line(), "] Runtime error: "+rErr.message)
+	_, err = fmt.Fprintln(lox.stderr, "[line ", rErr.token.Line(), "] Runtime error: "+rErr.message)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	// Errors in functions are followed by the stack like clox prints it,
+	// repeated frames of recursive calls are printed once.
+	stack := rErr.Stack()
+	for index := 0; len(stack) > 1 && index < len(stack); {
+		frame := stack[index]
+		repeated := 1
+		for index+repeated < len(stack) && stack[index+repeated] == frame {
+			repeated++
+		}
+		index += repeated
+		function := frame.Function
+		if function != scriptFunction {
+			function += "()"
+		}
+		if repeated > 1 {
+			function += fmt.Sprintf(" (%d times)", repeated)
+		}
+		if _, err := fmt.Fprintf(lox.stderr, "[line %d] in %s\n", frame.Line, function); err != nil {
+			log.Fatalln(err)
+		}
+	}
+	lox.hadRuntimeError = true
+}
+
+func (lox *LoxGo) limitError(lErr *LimitError) {
+	message := lErr.Error()
+	if lErr.Line() > 0 {
+		message = fmt.Sprint("[line ", lErr.Line(), "] ", message)
+	}
+	_, err := fmt.Fprintln(lox.stderr, message)
 	if err != nil {
 		log.Fatalln(err)
 	}
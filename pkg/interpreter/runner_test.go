@@ -1,6 +1,7 @@
 package interpreter
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -21,3 +22,60 @@ func TestLoxGo_Run(t *testing.T) {
 		})
 	}
 }
+
+func TestLoxGo_RunWithArgsAndInput(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		input   string
+		sources string
+		want    string
+	}{
+		{
+			name:    "script arguments",
+			args:    []string{"first", "second"},
+			sources: "print get(args, 1);",
+			want:    "second",
+		},
+		{
+			name:    "all script arguments",
+			args:    []string{"first", "second"},
+			sources: "print args;",
+			want:    "[first, second]",
+		},
+		{
+			name:    "read lines",
+			input:   "first\r\nsecond\n",
+			sources: "var first = readLine(); print first + readLine();",
+			want:    "firstsecond",
+		},
+		{
+			name:    "read line at end of input",
+			input:   "first",
+			sources: "readLine(); print readLine();",
+			want:    "nil",
+		},
+		{
+			name:    "read the rest of input",
+			input:   "first\nsecond\nthird",
+			sources: "readLine(); print readAll();",
+			want:    "second\nthird",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lox := New()
+			lox.SetArgs(tt.args)
+			lox.SetInput(strings.NewReader(tt.input))
+
+			lox.Run(tt.sources)
+
+			if lox.hadError || lox.hadRuntimeError {
+				t.Errorf("Run() failed on sources: %s", tt.sources)
+			}
+			if got := *lox.interpreter.lastPrintedValue; got != tt.want {
+				t.Errorf("Run() printed %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package interpreter
+
+import (
+	"fmt"
+)
+
+// Capability allows natives reaching outside of the interpreter, natives computing
+// on their arguments only, like len() or regexTest(), are always installed.
+type Capability string
+
+const (
+	CapabilityStdin   = Capability("stdin")
+	CapabilityEnv     = Capability("env")
+	CapabilityRandom  = Capability("random")
+	CapabilityFSRead  = Capability("fs-read")
+	CapabilityFSWrite = Capability("fs-write")
+)
+
+// capabilityModules are natives installed with every capability, in installation order.
+var capabilityModules = []struct {
+	capability Capability
+	natives    func() []*nativeFunction
+}{
+	{CapabilityStdin, ioNatives},
+	{CapabilityEnv, envNatives},
+	{CapabilityRandom, randomNatives},
+	{CapabilityFSRead, fsReadNatives},
+	{CapabilityFSWrite, fsWriteNatives},
+}
+
+// SandboxProfile is a named set of capabilities.
+type SandboxProfile string
+
+const (
+	// SandboxPure allows computing only, runs give the same results for the same sources.
+	SandboxPure = SandboxProfile("pure")
+	// SandboxReadOnlyFS also allows reading files.
+	SandboxReadOnlyFS = SandboxProfile("read-only-fs")
+	// SandboxFull allows everything, it is the default.
+	SandboxFull = SandboxProfile("full")
+)
+
+var sandboxCapabilities = map[SandboxProfile][]Capability{
+	SandboxPure:       {},
+	SandboxReadOnlyFS: {CapabilityFSRead},
+	SandboxFull:       {CapabilityStdin, CapabilityEnv, CapabilityRandom, CapabilityFSRead, CapabilityFSWrite},
+}
+
+// Capabilities of the profile, nil for unknown profiles.
+func (p SandboxProfile) Capabilities() []Capability {
+	return sandboxCapabilities[p]
+}
+
+// setCapabilities installs natives of the capabilities, natives of other ones are replaced
+// with natives failing with a runtime error naming the missing capability.
+func (i Interpreter) setCapabilities(capabilities []Capability) {
+	allowed := map[Capability]bool{}
+	for _, capability := range capabilities {
+		allowed[capability] = true
+	}
+	for _, module := range capabilityModules {
+		natives := module.natives()
+		if !allowed[module.capability] {
+			for index, native := range natives {
+				natives[index] = deniedNative(native, module.capability)
+			}
+		}
+		defineNatives(i.globals, natives...)
+	}
+}
+
+func deniedNative(native *nativeFunction, capability Capability) *nativeFunction {
+	message := fmt.Sprintf("%s() needs the %s capability, the sandbox denies it.", native.name, capability)
+	return newNativeFunction(native.name, native.argsCount, func(Interpreter, []Value) (Value, error) {
+		return Nil, newCallError("%s", message)
+	})
+}
@@ -0,0 +1,108 @@
+package interpreter
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestLoxGo_SetSandbox(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "existing.txt")
+	if err := os.WriteFile(existing, []byte("content"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	created := strconv.Quote(filepath.Join(dir, "created.txt"))
+
+	tests := []struct {
+		name    string
+		profile SandboxProfile
+		sources string
+		want    string
+		wantErr string
+	}{
+		{
+			name:    "Pure allows computing",
+			profile: SandboxPure,
+			sources: `print regexTest("a+", "caab") and len(list(1, 2)) == 2;`,
+			want:    "true",
+		},
+		{
+			name:    "Pure denies input",
+			profile: SandboxPure,
+			sources: `readLine();`,
+			wantErr: "readLine() needs the stdin capability, the sandbox denies it.",
+		},
+		{
+			name:    "Pure denies environment",
+			profile: SandboxPure,
+			sources: `getenv("HOME");`,
+			wantErr: "getenv() needs the env capability, the sandbox denies it.",
+		},
+		{
+			name:    "Pure denies random numbers",
+			profile: SandboxPure,
+			sources: `random();`,
+			wantErr: "random() needs the random capability, the sandbox denies it.",
+		},
+		{
+			name:    "Read-only FS allows reading files",
+			profile: SandboxReadOnlyFS,
+			sources: `print fileExists(` + created + `) or readFile(` + strconv.Quote(existing) + `);`,
+			want:    "content",
+		},
+		{
+			name:    "Read-only FS denies writing files",
+			profile: SandboxReadOnlyFS,
+			sources: `writeFile(` + created + `, "text");`,
+			wantErr: "writeFile() needs the fs-write capability, the sandbox denies it.",
+		},
+		{
+			name:    "Full allows writing files",
+			profile: SandboxFull,
+			sources: `writeFile(` + created + `, "text"); print readFile(` + created + `);`,
+			want:    "text",
+		},
+		{
+			name:    "Missing files are runtime errors",
+			profile: SandboxFull,
+			sources: `readFile(` + strconv.Quote(filepath.Join(dir, "missing.txt")) + `);`,
+			wantErr: "Cannot read file: open " + filepath.Join(dir, "missing.txt") + ": no such file or directory.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lox := New()
+			lox.SetOutput(&bytes.Buffer{})
+			lox.SetErrorOutput(&bytes.Buffer{})
+			if err := lox.SetSandbox(tt.profile); err != nil {
+				t.Fatal(err)
+			}
+
+			err := lox.RunContext(context.Background(), tt.sources)
+
+			if tt.wantErr != "" {
+				rErr, ok := err.(*RuntimeError)
+				if !ok || rErr.Message() != tt.wantErr {
+					t.Fatalf("RunContext() error = %v, want runtime error %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RunContext() error = %v", err)
+			}
+			if got := *lox.interpreter.lastPrintedValue; got != tt.want {
+				t.Errorf("RunContext() printed %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("Unknown profile", func(t *testing.T) {
+		if err := New().SetSandbox("everything"); err == nil {
+			t.Errorf("SetSandbox() accepted an unknown profile")
+		}
+	})
+}
@@ -0,0 +1,103 @@
+package interpreter
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// coreNatives are helpers needed to work with natives' results.
+func coreNatives() []*nativeFunction {
+	return []*nativeFunction{
+		newNativeFunction("len", 1, nativeLen),
+		newNativeFunction("get", 2, nativeGet),
+		newNativeFunction("list", variadicArity, nativeList),
+		newNativeFunction("int", 1, nativeInt),
+		newNativeFunction("float", 1, nativeFloat),
+	}
+}
+
+func nativeList(_ Interpreter, arguments []Value) (Value, error) {
+	return ObjectValue(newLoxList(arguments...)), nil
+}
+
+func nativeLen(_ Interpreter, arguments []Value) (Value, error) {
+	if value, ok := arguments[0].AsString(); ok {
+		return IntegerValue(int64(utf8.RuneCountInString(value))), nil
+	}
+	switch value := arguments[0].AsObject().(type) {
+	case *loxList:
+		return IntegerValue(int64(len(value.elements))), nil
+	case *loxMap:
+		return IntegerValue(int64(len(value.keys))), nil
+	}
+	return Nil, newCallError("len() argument must be a string, a list or a map.")
+}
+
+func nativeGet(_ Interpreter, arguments []Value) (Value, error) {
+	if dict, ok := arguments[0].AsObject().(*loxMap); ok {
+		return dict.get(arguments[1])
+	}
+	list, err := listArgument("get", arguments, 0)
+	if err != nil {
+		return Nil, newCallError("get() argument 1 must be a list or a map.")
+	}
+	index, err := numberArgument("get", arguments, 1)
+	if err != nil {
+		return Nil, err
+	}
+	return list.get(index)
+}
+
+// nativeInt converts a number or a numeric string to an integer, floats and decimals are truncated.
+func nativeInt(_ Interpreter, arguments []Value) (Value, error) {
+	argument := arguments[0]
+	if text, ok := argument.AsString(); ok {
+		text = strings.TrimSpace(text)
+		if integer, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return IntegerValue(integer), nil
+		}
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return Nil, newCallError("int() can't convert %q to a number.", text)
+		}
+		argument = FloatValue(f)
+	}
+	if integer, ok := argument.AsInteger(); ok {
+		return IntegerValue(integer), nil
+	}
+	if d, ok := argument.AsDecimal(); ok {
+		argument = BigIntegerValue(d.Int())
+	}
+	if n, ok := argument.AsBigInteger(); ok {
+		if !n.IsInt64() {
+			return Nil, newCallError("int() can't convert %v to an integer.", n)
+		}
+		return IntegerValue(n.Int64()), nil
+	}
+	f, ok := argument.AsFloat()
+	if !ok {
+		return Nil, argumentError("int", 0, "a number or a string")
+	}
+	integer, ok := floatToInteger(f)
+	if !ok {
+		return Nil, newCallError("int() can't convert %v to an integer.", f)
+	}
+	return IntegerValue(integer), nil
+}
+
+// nativeFloat converts a number or a numeric string to a float.
+func nativeFloat(_ Interpreter, arguments []Value) (Value, error) {
+	if text, ok := arguments[0].AsString(); ok {
+		f, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+		if err != nil {
+			return Nil, newCallError("float() can't convert %q to a number.", text)
+		}
+		return FloatValue(f), nil
+	}
+	f, ok := arguments[0].AsNumber()
+	if !ok {
+		return Nil, argumentError("float", 0, "a number or a string")
+	}
+	return FloatValue(f), nil
+}
@@ -0,0 +1,70 @@
+package interpreter
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+)
+
+// fsReadNatives read files, paths are relative to the working directory.
+func fsReadNatives() []*nativeFunction {
+	return []*nativeFunction{
+		newNativeFunction("readFile", 1, nativeReadFile),
+		newNativeFunction("fileExists", 1, nativeFileExists),
+	}
+}
+
+// fsWriteNatives change files.
+func fsWriteNatives() []*nativeFunction {
+	return []*nativeFunction{
+		newNativeFunction("writeFile", 2, nativeWriteFile),
+	}
+}
+
+func nativeReadFile(interpreter Interpreter, arguments []Value) (Value, error) {
+	path, err := stringArgument("readFile", arguments, 0)
+	if err != nil {
+		return Nil, err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return Nil, newCallError("Cannot read file: %s.", err)
+	}
+	defer file.Close()
+	data, err := readLimited(interpreter, file)
+	if err != nil {
+		return Nil, newCallError("Cannot read file: %s.", err)
+	}
+	return StringValue(string(data)), nil
+}
+
+func nativeFileExists(_ Interpreter, arguments []Value) (Value, error) {
+	path, err := stringArgument("fileExists", arguments, 0)
+	if err != nil {
+		return Nil, err
+	}
+	_, err = os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return BoolValue(false), nil
+	}
+	if err != nil {
+		return Nil, newCallError("Cannot check file: %s.", err)
+	}
+	return BoolValue(true), nil
+}
+
+// nativeWriteFile replaces the file content with the text, creating the file if needed.
+func nativeWriteFile(_ Interpreter, arguments []Value) (Value, error) {
+	path, err := stringArgument("writeFile", arguments, 0)
+	if err != nil {
+		return Nil, err
+	}
+	text, err := stringArgument("writeFile", arguments, 1)
+	if err != nil {
+		return Nil, err
+	}
+	if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+		return Nil, newCallError("Cannot write file: %s.", err)
+	}
+	return Nil, nil
+}
@@ -0,0 +1,81 @@
+package interpreter
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"strings"
+)
+
+// ioNatives read from the interpreter input stream.
+func ioNatives() []*nativeFunction {
+	return []*nativeFunction{
+		newNativeFunction("readLine", 0, nativeReadLine),
+		newNativeFunction("readAll", 0, nativeReadAll),
+	}
+}
+
+// envNatives read the process environment.
+func envNatives() []*nativeFunction {
+	return []*nativeFunction{
+		newNativeFunction("getenv", 1, nativeGetenv),
+	}
+}
+
+// nativeReadLine returns the next line without line ending or nil at the end of input.
+// Lines are read by buffer sizes checking the collection size limit, so a too long one is never read whole.
+func nativeReadLine(interpreter Interpreter, _ []Value) (Value, error) {
+	var line []byte
+	for {
+		chunk, err := interpreter.stdin.ReadSlice('\n')
+		line = append(line, chunk...)
+		interpreter.budget.checkSize(interpreter.calls.paren, len(bytes.TrimRight(line, "\r\n")))
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err == io.EOF {
+			if len(line) == 0 {
+				return Nil, nil
+			}
+		} else if err != nil {
+			return Nil, newCallError("Cannot read input: %s.", err)
+		}
+		break
+	}
+	text := strings.TrimSuffix(string(line), "\n")
+	return StringValue(strings.TrimSuffix(text, "\r")), nil
+}
+
+func nativeReadAll(interpreter Interpreter, _ []Value) (Value, error) {
+	data, err := readLimited(interpreter, interpreter.stdin)
+	if err != nil {
+		return Nil, newCallError("Cannot read input: %s.", err)
+	}
+	return StringValue(string(data)), nil
+}
+
+// readLimited reads until the end, but one byte more than the collection size limit at most.
+func readLimited(interpreter Interpreter, reader io.Reader) ([]byte, error) {
+	if limit := interpreter.budget.collectionLimit(); limit >= 0 {
+		reader = io.LimitReader(reader, int64(limit))
+	}
+	data, err := io.ReadAll(reader)
+	if err == nil {
+		interpreter.budget.checkSize(interpreter.calls.paren, len(data))
+	}
+	return data, err
+}
+
+// nativeGetenv returns nil for unset variables to distinguish them from empty ones.
+func nativeGetenv(_ Interpreter, arguments []Value) (Value, error) {
+	name, err := stringArgument("getenv", arguments, 0)
+	if err != nil {
+		return Nil, err
+	}
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return Nil, nil
+	}
+	return StringValue(value), nil
+}
@@ -0,0 +1,82 @@
+package interpreter
+
+import (
+	"math"
+)
+
+// randomNatives use the interpreter generator, so runs with the same seed are reproducible.
+func randomNatives() []*nativeFunction {
+	return []*nativeFunction{
+		newNativeFunction("random", 0, nativeRandom),
+		newNativeFunction("randomInt", 2, nativeRandomInt),
+		newNativeFunction("shuffle", 1, nativeShuffle),
+		newNativeFunction("choice", 1, nativeChoice),
+	}
+}
+
+// nativeRandom returns a number in [0, 1).
+func nativeRandom(interpreter Interpreter, _ []Value) (Value, error) {
+	return FloatValue(interpreter.random.Float64()), nil
+}
+
+// nativeRandomInt returns an integer number in [lo, hi], both bounds are included.
+func nativeRandomInt(interpreter Interpreter, arguments []Value) (Value, error) {
+	lo, err := randomBound(arguments, 0)
+	if err != nil {
+		return Nil, err
+	}
+	hi, err := randomBound(arguments, 1)
+	if err != nil {
+		return Nil, err
+	}
+	if lo > hi {
+		return Nil, newCallError("randomInt() lower bound %d is greater than upper bound %d.", lo, hi)
+	}
+	// Int63n takes the count of integers in the range, it must fit in an int64 too.
+	if span := hi - lo; span < 0 || span == math.MaxInt64 {
+		return Nil, newCallError("randomInt() range from %d to %d is too wide.", lo, hi)
+	}
+	return IntegerValue(lo + interpreter.random.Int63n(hi-lo+1)), nil
+}
+
+// randomBound is a bound of randomInt, floats must be whole numbers in integer range.
+func randomBound(arguments []Value, position int) (int64, error) {
+	if bound, ok := arguments[position].AsInteger(); ok {
+		return bound, nil
+	}
+	bound, err := numberArgument("randomInt", arguments, position)
+	if err != nil {
+		return 0, err
+	}
+	if bound != math.Trunc(bound) {
+		return 0, newCallError("randomInt() bounds must be integer numbers.")
+	}
+	// The float of math.MaxInt64 is 2^63, which is out of int64 range itself.
+	if bound < math.MinInt64 || bound >= math.MaxInt64 {
+		return 0, newCallError("randomInt() bound %v is out of integer range.", bound)
+	}
+	return int64(bound), nil
+}
+
+// nativeShuffle shuffles list in place and returns it.
+func nativeShuffle(interpreter Interpreter, arguments []Value) (Value, error) {
+	list, err := listArgument("shuffle", arguments, 0)
+	if err != nil {
+		return Nil, err
+	}
+	interpreter.random.Shuffle(len(list.elements), func(i, j int) {
+		list.elements[i], list.elements[j] = list.elements[j], list.elements[i]
+	})
+	return ObjectValue(list), nil
+}
+
+func nativeChoice(interpreter Interpreter, arguments []Value) (Value, error) {
+	list, err := listArgument("choice", arguments, 0)
+	if err != nil {
+		return Nil, err
+	}
+	if len(list.elements) == 0 {
+		return Nil, newCallError("choice() from an empty list.")
+	}
+	return list.elements[interpreter.random.Intn(len(list.elements))], nil
+}
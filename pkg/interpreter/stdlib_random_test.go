@@ -0,0 +1,126 @@
+package interpreter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/parser"
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/scanner"
+)
+
+func TestRandomNatives(t *testing.T) {
+	t.Run("Same seed gives same results", func(t *testing.T) {
+		sources := `
+			var values = list(random(), randomInt(-10, 10), choice(list("a", "b", "c")));
+			print list(values, shuffle(list(1, 2, 3, 4, 5, 6, 7, 8)));
+		`
+		printed := make([]string, 0, 2)
+		for run := 0; run < 2; run++ {
+			lox := New()
+			lox.SetSeed(42)
+			lox.Run(sources)
+			if lox.hadError || lox.hadRuntimeError {
+				t.Fatalf("Run() failed on sources: %s", sources)
+			}
+			printed = append(printed, *lox.interpreter.lastPrintedValue)
+		}
+		if printed[0] != printed[1] {
+			t.Errorf("Run() printed %q and %q with the same seed", printed[0], printed[1])
+		}
+	})
+
+	t.Run("Random values are in bounds", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			sources string
+		}{
+			{
+				name:    "random",
+				sources: `var x = random(); print x >= 0 and x < 1;`,
+			},
+			{
+				name:    "randomInt",
+				sources: `var x = randomInt(1, 3); print x == 1 or x == 2 or x == 3;`,
+			},
+			{
+				name:    "randomInt with the widest range",
+				sources: `var x = randomInt(-4611686018427387904, 4611686018427387902); print x >= -4611686018427387904 and x <= 4611686018427387902;`,
+			},
+			{
+				name:    "randomInt with same bounds",
+				sources: `print randomInt(7, 7) == 7;`,
+			},
+			{
+				name:    "choice",
+				sources: `var x = choice(list(1, 2)); print x == 1 or x == 2;`,
+			},
+			{
+				name:    "shuffle keeps elements",
+				sources: `var l = shuffle(list(1, 1, 1)); print len(l) == 3 and get(l, 2) == 1;`,
+			},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				for seed := int64(0); seed < 20; seed++ {
+					lox := New()
+					lox.SetSeed(seed)
+					lox.Run(tt.sources)
+					if got := *lox.interpreter.lastPrintedValue; got != "true" {
+						t.Errorf("Run() with seed %d printed %s, want true", seed, got)
+					}
+				}
+			})
+		}
+	})
+
+	t.Run("Invalid arguments are runtime errors", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			sources string
+			wantErr string
+		}{
+			{
+				name:    "randomInt with swapped bounds",
+				sources: `randomInt(3, 1);`,
+				wantErr: `Runtime error: "randomInt() lower bound 3 is greater than upper bound 1." at token: {RIGHTPAREN ) <nil> 1}`,
+			},
+			{
+				name:    "randomInt with fraction bound",
+				sources: `randomInt(0, 1.5);`,
+				wantErr: `Runtime error: "randomInt() bounds must be integer numbers." at token: {RIGHTPAREN ) <nil> 1}`,
+			},
+			{
+				name:    "randomInt with bounds out of integer range",
+				sources: `randomInt(-1e19, 1e19);`,
+				wantErr: `Runtime error: "randomInt() bound -1e+19 is out of integer range." at token: {RIGHTPAREN ) <nil> 1}`,
+			},
+			{
+				name:    "randomInt with range wider than integers",
+				sources: `randomInt(-9223372036854775807, 9223372036854775807);`,
+				wantErr: `Runtime error: "randomInt() range from -9223372036854775807 to 9223372036854775807 is too wide." at token: {RIGHTPAREN ) <nil> 1}`,
+			},
+			{
+				name:    "choice from empty list",
+				sources: `choice(list());`,
+				wantErr: `Runtime error: "choice() from an empty list." at token: {RIGHTPAREN ) <nil> 1}`,
+			},
+			{
+				name:    "shuffle not a list",
+				sources: `shuffle("abc");`,
+				wantErr: `Runtime error: "shuffle() argument 1 must be a list." at token: {RIGHTPAREN ) <nil> 1}`,
+			},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				scnr := scanner.NewScanner(tt.sources, nil)
+				prsr := parser.NewParser(scnr.ScanTokens(), nil)
+				interp := NewInterpreter()
+
+				err := interp.Interpret(context.Background(), prsr.Parse())
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("Interpret() error = %v, want error %s", err, tt.wantErr)
+				}
+			})
+		}
+	})
+}
@@ -0,0 +1,157 @@
+package interpreter
+
+import (
+	"regexp"
+)
+
+// loxRegex is a compiled RE2 regular expression value.
+type loxRegex struct {
+	re *regexp.Regexp
+}
+
+func (r *loxRegex) String() string {
+	return "<regex " + r.re.String() + ">"
+}
+
+// regexNatives work on compiled regexes or on pattern strings compiled on each call.
+// Matches are maps with "match", "index", "groups" and "named" keys,
+// unmatched groups are nil.
+func regexNatives() []*nativeFunction {
+	return []*nativeFunction{
+		newNativeFunction("regexCompile", 1, nativeRegexCompile),
+		newNativeFunction("regexTest", 2, nativeRegexTest),
+		newNativeFunction("regexFind", 2, nativeRegexFind),
+		newNativeFunction("regexFindAll", 2, nativeRegexFindAll),
+		newNativeFunction("regexReplace", 3, nativeRegexReplace),
+	}
+}
+
+func nativeRegexCompile(_ Interpreter, arguments []Value) (Value, error) {
+	regex, err := regexArgument("regexCompile", arguments, 0)
+	if err != nil {
+		return Nil, err
+	}
+	return ObjectValue(regex), nil
+}
+
+func nativeRegexTest(_ Interpreter, arguments []Value) (Value, error) {
+	regex, text, err := regexAndTextArguments("regexTest", arguments)
+	if err != nil {
+		return Nil, err
+	}
+	return BoolValue(regex.re.MatchString(text)), nil
+}
+
+func nativeRegexFind(_ Interpreter, arguments []Value) (Value, error) {
+	regex, text, err := regexAndTextArguments("regexFind", arguments)
+	if err != nil {
+		return Nil, err
+	}
+	indexes := regex.re.FindStringSubmatchIndex(text)
+	if indexes == nil {
+		return Nil, nil
+	}
+	return ObjectValue(regex.match(text, indexes)), nil
+}
+
+// nativeRegexFindAll finds one match more than the collection size limit at most.
+func nativeRegexFindAll(interpreter Interpreter, arguments []Value) (Value, error) {
+	regex, text, err := regexAndTextArguments("regexFindAll", arguments)
+	if err != nil {
+		return Nil, err
+	}
+	found := regex.re.FindAllStringSubmatchIndex(text, interpreter.budget.collectionLimit())
+	interpreter.budget.checkSize(interpreter.calls.paren, len(found))
+	matches := newLoxList()
+	for _, indexes := range found {
+		matches.elements = append(matches.elements, ObjectValue(regex.match(text, indexes)))
+	}
+	return ObjectValue(matches), nil
+}
+
+// nativeRegexReplace accepts a replacement string with $1 and ${name} expansion
+// or a callback receiving a match and returning a replacement string.
+// The result is checked against the collection size limit after every replacement.
+func nativeRegexReplace(interpreter Interpreter, arguments []Value) (Value, error) {
+	regex, text, err := regexAndTextArguments("regexReplace", arguments)
+	if err != nil {
+		return Nil, err
+	}
+	template, isTemplate := arguments[2].AsString()
+
+	result := make([]byte, 0, len(text))
+	last := 0
+	for _, indexes := range regex.re.FindAllStringSubmatchIndex(text, -1) {
+		result = append(result, text[last:indexes[0]]...)
+		if isTemplate {
+			result = regex.re.ExpandString(result, template, text, indexes)
+		} else {
+			replacement, err := callCallable(interpreter, "regexReplace", arguments[2], ObjectValue(regex.match(text, indexes)))
+			if err != nil {
+				return Nil, err
+			}
+			replacementStr, ok := replacement.AsString()
+			if !ok {
+				return Nil, newCallError("regexReplace() callback must return a string.")
+			}
+			result = append(result, replacementStr...)
+		}
+		last = indexes[1]
+		interpreter.budget.checkSize(interpreter.calls.paren, len(result))
+	}
+	result = append(result, text[last:]...)
+	interpreter.budget.checkSize(interpreter.calls.paren, len(result))
+	return StringValue(string(result)), nil
+}
+
+// match converts submatch byte indexes into a Lox match map.
+func (r *loxRegex) match(text string, indexes []int) *loxMap {
+	groups := newLoxList()
+	named := newLoxMap()
+	for group, name := range r.re.SubexpNames() {
+		value := Nil
+		if indexes[2*group] >= 0 {
+			value = StringValue(text[indexes[2*group]:indexes[2*group+1]])
+		}
+		if group == 0 {
+			continue
+		}
+		groups.elements = append(groups.elements, value)
+		if name != "" {
+			named.set(StringValue(name), value)
+		}
+	}
+
+	match := newLoxMap()
+	match.set(StringValue("match"), StringValue(text[indexes[0]:indexes[1]]))
+	match.set(StringValue("index"), IntegerValue(int64(len([]rune(text[:indexes[0]])))))
+	match.set(StringValue("groups"), ObjectValue(groups))
+	match.set(StringValue("named"), ObjectValue(named))
+	return match
+}
+
+func regexArgument(native string, arguments []Value, position int) (*loxRegex, error) {
+	if pattern, ok := arguments[position].AsString(); ok {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, newCallError("Invalid regex pattern: %s.", err)
+		}
+		return &loxRegex{re: re}, nil
+	}
+	if regex, ok := arguments[position].AsObject().(*loxRegex); ok {
+		return regex, nil
+	}
+	return nil, argumentError(native, position, "a regex or a pattern string")
+}
+
+func regexAndTextArguments(native string, arguments []Value) (*loxRegex, string, error) {
+	regex, err := regexArgument(native, arguments, 0)
+	if err != nil {
+		return nil, "", err
+	}
+	text, err := stringArgument(native, arguments, 1)
+	if err != nil {
+		return nil, "", err
+	}
+	return regex, text, nil
+}
@@ -0,0 +1,106 @@
+package interpreter
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/parser"
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/plugins"
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/scanner"
+)
+
+func TestRegexNatives(t *testing.T) {
+	tests := []struct {
+		name    string
+		sources string
+		want    string
+		wantErr string
+	}{
+		{
+			name:    "Test pattern string",
+			sources: `print regexTest("^a+b$", "aaab");`,
+			want:    "true",
+		},
+		{
+			name:    "Test compiled regex",
+			sources: `var re = regexCompile("[0-9]+"); print regexTest(re, "abc");`,
+			want:    "false",
+		},
+		{
+			name:    "Compiled regex printing",
+			sources: `print regexCompile("a|b");`,
+			want:    "<regex a|b>",
+		},
+		{
+			name:    "Find with groups",
+			sources: `print regexFind("(\w+)=(?P<value>\d+)?", "ключ key=42");`,
+			want:    "{match: key=42, index: 5, groups: [key, 42], named: {value: 42}}",
+		},
+		{
+			name:    "Find nothing",
+			sources: `print regexFind("x", "abc");`,
+			want:    "nil",
+		},
+		{
+			name:    "Find all with unmatched group",
+			sources: `var all = regexFindAll("(a)|b", "ab"); print get(get(all, 1), "groups");`,
+			want:    "[nil]",
+		},
+		{
+			name:    "Replace with template",
+			sources: `print regexReplace("(\w+)@(\w+)", "me@host", "$2 at $1");`,
+			want:    "host at me",
+		},
+		{
+			name: "Replace with callback",
+			sources: `
+				fun double(match) {
+					var number = get(get(match, "groups"), 0);
+					return number + number;
+				}
+				print regexReplace("<(\d)>", "1<2>3<4>", double);
+			`,
+			want: "122344",
+		},
+		{
+			name:    "Invalid pattern",
+			sources: `regexTest("(", "abc");`,
+			wantErr: `Runtime error: "Invalid regex pattern: error parsing regexp: missing closing ): ` +
+				"`(`" + `." at token: {RIGHTPAREN ) <nil> 1}`,
+		},
+		{
+			name:    "Callback returns not a string",
+			sources: `fun f(m) { return 1; } regexReplace("a", "a", f);`,
+			wantErr: `Runtime error: "regexReplace() callback must return a string." at token: {RIGHTPAREN ) <nil> 1}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// arrange
+			pprinter := plugins.NewAstPrinter()
+			scnr := scanner.NewScanner(tt.sources, nil)
+			prsr := parser.NewParser(scnr.ScanTokens(), nil)
+			parsed := prsr.Parse()
+			interp := NewInterpreter()
+
+			// act
+			err := interp.Interpret(context.Background(), parsed)
+
+			// assert
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("Interpret() error = %v, want error %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Interpret() return error: %s, but shouldn't", err)
+			}
+			got := *interp.lastPrintedValue
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Interpret() = %v, want %v, ast %s", got, tt.want, pprinter.Sprint(parsed))
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package interpreter
+
+// assertionFailedPrefix starts messages of runtime errors raised by failed assertions.
+const assertionFailedPrefix = "Assertion failed"
+
+// assertionNatives are installed only for Lox tests, see LoxGo.EnableAssertions.
+func assertionNatives() []*nativeFunction {
+	return []*nativeFunction{
+		newNativeFunction("assert", variadicArity, nativeAssert),
+		newNativeFunction("assertEqual", variadicArity, nativeAssertEqual),
+	}
+}
+
+// nativeAssert accepts condition and an optional message: assert(condition[, message]).
+func nativeAssert(interpreter Interpreter, arguments []Value) (Value, error) {
+	if len(arguments) < 1 || len(arguments) > 2 {
+		return Nil, newCallError("Expected 1 or 2 arguments but got %d.", len(arguments))
+	}
+	if interpreter.isTruthy(arguments[0]) {
+		return Nil, nil
+	}
+	return Nil, assertionFailed(interpreter, arguments[1:], "expected truthy value but got %s.",
+		interpreter.stringify(arguments[0]))
+}
+
+// nativeAssertEqual accepts an optional message: assertEqual(actual, expected[, message]).
+func nativeAssertEqual(interpreter Interpreter, arguments []Value) (Value, error) {
+	if len(arguments) < 2 || len(arguments) > 3 {
+		return Nil, newCallError("Expected 2 or 3 arguments but got %d.", len(arguments))
+	}
+	actual, expected := arguments[0], arguments[1]
+	if interpreter.isEqual(actual, expected) {
+		return Nil, nil
+	}
+	return Nil, assertionFailed(interpreter, arguments[2:], "expected %s but got %s.",
+		describe(interpreter, expected), describe(interpreter, actual))
+}
+
+func assertionFailed(interpreter Interpreter, message []Value, format string, args ...any) error {
+	if len(message) == 1 {
+		return newCallError(assertionFailedPrefix+": %s", interpreter.stringify(message[0]))
+	}
+	return newCallError(assertionFailedPrefix+": "+format, args...)
+}
+
+// describe quotes strings, so "1" and 1 are distinguishable in failure messages.
+func describe(interpreter Interpreter, value Value) string {
+	if str, ok := value.AsString(); ok {
+		return `"` + str + `"`
+	}
+	return interpreter.stringify(value)
+}
@@ -0,0 +1,151 @@
+package interpreter
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/formatter"
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/parser"
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/parser/ast"
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/scanner"
+)
+
+// Tracer is ParseHooks printing a readable execution trace: executed statements,
+// values of evaluated expressions, variable changes and runtime errors, each with its line.
+// Code of called functions is indented.
+type Tracer struct {
+	out   io.Writer
+	nodes sourceNodes
+	line  int
+	depth int
+}
+
+func NewTracer(out io.Writer) *Tracer {
+	return &Tracer{out: out, nodes: sourceNodes{}}
+}
+
+func (t *Tracer) Parsed(tokens []scanner.Token, spans map[any]parser.Span) {
+	t.nodes.add(tokens, spans)
+}
+
+// BeforeExecute prints statements with their sources, compound statements with their headers only.
+// Blocks and statements the parser made up, e.g. loops of for statements, are not printed.
+func (t *Tracer) BeforeExecute(stmt ast.Stmt) {
+	node, ok := t.nodes[stmt]
+	if !ok {
+		return
+	}
+	t.line = node.line
+	if _, ok := stmt.(*ast.Block); ok {
+		return
+	}
+	t.printf("%s", t.nodes.statementText(stmt))
+}
+
+func (t *Tracer) AfterExecute(ast.Stmt) {}
+
+func (t *Tracer) BeforeEvaluate(expr ast.Expr) {
+	if node, ok := t.nodes[expr]; ok {
+		t.line = node.line
+	}
+	if _, ok := expr.(*ast.Call); ok {
+		t.depth++
+	}
+}
+
+// AfterEvaluate prints values of expressions, except literals and groupings
+// repeating other values and assignments printed by Assign.
+func (t *Tracer) AfterEvaluate(expr ast.Expr, value Value) {
+	switch expr.(type) {
+	case *ast.Literal, *ast.Grouping, *ast.Assign:
+		return
+	case *ast.Call:
+		t.depth--
+	}
+	node, ok := t.nodes[expr]
+	if !ok {
+		return
+	}
+	t.line = node.line
+	t.printf("  %s => %s", joinTokens(node.tokens), traceValue(value))
+}
+
+func (t *Tracer) Define(name string, value Value) {
+	t.printf("  define %s = %s", name, traceValue(value))
+}
+
+func (t *Tracer) Assign(name string, value Value) {
+	t.printf("  assign %s = %s", name, traceValue(value))
+}
+
+func (t *Tracer) RuntimeError(err *RuntimeError) {
+	t.line = err.Line()
+	t.printf("runtime error: %s", err.Message())
+	t.depth = 0
+}
+
+func (t *Tracer) printf(format string, args ...any) {
+	prefix := fmt.Sprintf("[line %d] %s", t.line, strings.Repeat("  ", t.depth))
+	_, _ = fmt.Fprintf(t.out, prefix+format+"\n", args...)
+}
+
+// sourceNodes are sources of parsed statements and expressions.
+type sourceNodes map[any]sourceNode
+
+type sourceNode struct {
+	line   int
+	tokens []scanner.Token
+}
+
+func (n sourceNodes) add(tokens []scanner.Token, spans map[any]parser.Span) {
+	for node, span := range spans {
+		if span.First < len(tokens) && span.Last < len(tokens) {
+			n[node] = sourceNode{line: tokens[span.First].Line(), tokens: tokens[span.First : span.Last+1]}
+		}
+	}
+}
+
+// statementText is the source of the statement on one line, compound statements have headers only.
+func (n sourceNodes) statementText(stmt ast.Stmt) string {
+	switch stmt := stmt.(type) {
+	case *ast.Block:
+		return "{ ... }"
+	case *ast.If:
+		return "if (" + joinTokens(n[stmt.Condition].tokens) + ")"
+	case *ast.While:
+		return "while (" + joinTokens(n[stmt.Condition].tokens) + ")"
+	case *ast.Function:
+		params := make([]string, 0, len(stmt.Params))
+		for _, param := range stmt.Params {
+			params = append(params, param.Lexeme())
+		}
+		return "fun " + stmt.Name.Lexeme() + "(" + strings.Join(params, ", ") + ")"
+	}
+	return joinTokens(n[stmt].tokens)
+}
+
+// traceValue quotes strings to tell them from other values.
+func traceValue(value Value) string {
+	if value, ok := value.AsString(); ok {
+		return strconv.Quote(value)
+	}
+	return value.String()
+}
+
+// joinTokens prints tokens on one line, spacing them like the formatter does.
+func joinTokens(tokens []scanner.Token) string {
+	text := strings.Builder{}
+	for index, token := range tokens {
+		beforePrevious := scanner.EOF
+		if index > 1 {
+			beforePrevious = tokens[index-2].Kind()
+		}
+		if index > 0 && formatter.NeedsSpace(tokens[index-1].Kind(), beforePrevious, token.Kind()) {
+			text.WriteString(" ")
+		}
+		text.WriteString(token.Lexeme())
+	}
+	return text.String()
+}
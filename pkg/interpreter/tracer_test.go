@@ -0,0 +1,118 @@
+package interpreter
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/parser/ast"
+)
+
+func TestTracer(t *testing.T) {
+	sources := `var a = "x";
+fun twice(s) {
+  return s + s;
+}
+if (a != nil) a = twice(a);
+print -len(a) / nil;
+`
+	want := `[line 1] var a = "x";
+[line 1]   define a = "x"
+[line 2] fun twice(s)
+[line 2]   define twice = <fn twice>
+[line 5] if (a != nil)
+[line 5]   a => "x"
+[line 5]   a != nil => true
+[line 5] a = twice(a);
+[line 5]     twice => <fn twice>
+[line 5]     a => "x"
+[line 5]     define s = "x"
+[line 3]   return s + s;
+[line 3]     s => "x"
+[line 3]     s => "x"
+[line 3]     s + s => "xx"
+[line 5]   twice(a) => "xx"
+[line 5]   assign a = "xx"
+[line 6] print -len(a) / nil;
+[line 6]     len => <native fn len>
+[line 6]     a => "xx"
+[line 6]   len(a) => 2
+[line 6]   -len(a) => -2
+[line 6] runtime error: invalid type for operator SLASH given, must be number.
+`
+	trace := bytes.Buffer{}
+	lox := New()
+	lox.SetOutput(io.Discard)
+	lox.SetErrorOutput(io.Discard)
+	lox.SetHooks(NewTracer(&trace))
+
+	lox.Run(sources)
+
+	if got := trace.String(); got != want {
+		t.Errorf("trace =\n%s\nwant\n%s", got, want)
+	}
+}
+
+// recordingHooks records hook calls by node type.
+type recordingHooks struct {
+	calls []string
+}
+
+func (h *recordingHooks) BeforeExecute(stmt ast.Stmt) {
+	h.calls = append(h.calls, fmt.Sprintf("before %T", stmt))
+}
+
+func (h *recordingHooks) AfterExecute(stmt ast.Stmt) {
+	h.calls = append(h.calls, fmt.Sprintf("after %T", stmt))
+}
+
+func (h *recordingHooks) BeforeEvaluate(expr ast.Expr) {
+	h.calls = append(h.calls, fmt.Sprintf("before %T", expr))
+}
+
+func (h *recordingHooks) AfterEvaluate(expr ast.Expr, value Value) {
+	h.calls = append(h.calls, fmt.Sprintf("after %T %v", expr, value))
+}
+
+func (h *recordingHooks) Define(name string, value Value) {
+	h.calls = append(h.calls, fmt.Sprintf("define %s %v", name, value))
+}
+
+func (h *recordingHooks) Assign(name string, value Value) {
+	h.calls = append(h.calls, fmt.Sprintf("assign %s %v", name, value))
+}
+
+func (h *recordingHooks) RuntimeError(err *RuntimeError) {
+	h.calls = append(h.calls, "error "+err.Message())
+}
+
+func TestLoxGo_SetHooks(t *testing.T) {
+	hooks := &recordingHooks{}
+	lox := New()
+	lox.SetErrorOutput(io.Discard)
+	lox.SetHooks(hooks)
+
+	lox.Run("var x = 1;\n{ x = 2; }\nx();")
+
+	want := []string{
+		"before *ast.Var", "before *ast.Literal", "after *ast.Literal 1", "define x 1", "after *ast.Var",
+		"before *ast.Block",
+		"before *ast.Expression", "before *ast.Assign", "before *ast.Literal", "after *ast.Literal 2",
+		"assign x 2", "after *ast.Assign 2", "after *ast.Expression",
+		"after *ast.Block",
+		"before *ast.Expression", "before *ast.Call", "before *ast.Variable", "after *ast.Variable 2",
+		"error Can only call functions and classes.",
+	}
+	if !reflect.DeepEqual(hooks.calls, want) {
+		t.Errorf("hook calls =\n%q\nwant\n%q", hooks.calls, want)
+	}
+
+	hooks.calls = nil
+	lox.SetHooks(nil)
+	lox.Run("print x;")
+	if len(hooks.calls) != 0 {
+		t.Errorf("hook calls after removing hooks = %q", hooks.calls)
+	}
+}
@@ -0,0 +1,240 @@
+package interpreter
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/decimal"
+)
+
+// ValueKind tells what a Value holds.
+type ValueKind uint8
+
+const (
+	NilKind ValueKind = iota
+	BoolKind
+	IntegerKind
+	FloatKind
+	// BigIntegerKind and DecimalKind values are exact, they keep a *big.Int and a decimal.Decimal in ref.
+	BigIntegerKind
+	DecimalKind
+	StringKind
+	// ObjectKind values are shared by reference, e.g. functions, lists and maps.
+	ObjectKind
+)
+
+var valueKindNames = [...]string{
+	NilKind:        "nil",
+	BoolKind:       "bool",
+	IntegerKind:    "integer",
+	FloatKind:      "float",
+	BigIntegerKind: "big integer",
+	DecimalKind:    "decimal",
+	StringKind:     "string",
+	ObjectKind:     "object",
+}
+
+func (k ValueKind) String() string {
+	return valueKindNames[k]
+}
+
+// Value is a Lox value tagged with its kind. Bools and numbers are kept unboxed in one word,
+// so arithmetic doesn't allocate, strings and objects are kept in ref.
+// Values of the same kind are comparable, == is Lox equality for them but floats, big integers and decimals:
+// floats are compared by their bits, so NaN equals itself and 0 doesn't equal -0.
+type Value struct {
+	kind ValueKind
+	// bits are the integer, the float by math.Float64bits, or 1 and 0 for true and false.
+	bits uint64
+	ref  any
+}
+
+// Nil is the Lox nil, the zero Value.
+var Nil = Value{}
+
+func BoolValue(b bool) Value {
+	if b {
+		return Value{kind: BoolKind, bits: 1}
+	}
+	return Value{kind: BoolKind}
+}
+
+func IntegerValue(n int64) Value {
+	return Value{kind: IntegerKind, bits: uint64(n)}
+}
+
+func FloatValue(n float64) Value {
+	return Value{kind: FloatKind, bits: math.Float64bits(n)}
+}
+
+// BigIntegerValue wraps the integer, it must not be changed afterwards.
+func BigIntegerValue(n *big.Int) Value {
+	return Value{kind: BigIntegerKind, ref: n}
+}
+
+func DecimalValue(d decimal.Decimal) Value {
+	return Value{kind: DecimalKind, ref: d}
+}
+
+func StringValue(s string) Value {
+	return Value{kind: StringKind, ref: s}
+}
+
+// ObjectValue wraps a pointer to a runtime object, e.g. a *loxList.
+func ObjectValue(object any) Value {
+	return Value{kind: ObjectKind, ref: object}
+}
+
+// literalValue converts a literal value made by the scanner.
+func literalValue(literal any) Value {
+	switch literal := literal.(type) {
+	case bool:
+		return BoolValue(literal)
+	case int64:
+		return IntegerValue(literal)
+	case float64:
+		return FloatValue(literal)
+	case *big.Int:
+		return BigIntegerValue(literal)
+	case decimal.Decimal:
+		return DecimalValue(literal)
+	case string:
+		return Value{kind: StringKind, ref: literal}
+	case nil:
+		return Nil
+	}
+	return ObjectValue(literal)
+}
+
+// literal converts the value back for ast.Literal, objects have no literals.
+func (v Value) literal() (any, bool) {
+	switch v.kind {
+	case NilKind:
+		return nil, true
+	case BoolKind:
+		return v.bits != 0, true
+	case IntegerKind:
+		return v.integer(), true
+	case FloatKind:
+		return v.float(), true
+	case BigIntegerKind, DecimalKind, StringKind:
+		return v.ref, true
+	}
+	return nil, false
+}
+
+func (v Value) Kind() ValueKind {
+	return v.kind
+}
+
+func (v Value) IsNil() bool {
+	return v.kind == NilKind
+}
+
+// AsBool returns the bool and whether the value is one, the same way for other accessors.
+func (v Value) AsBool() (bool, bool) {
+	return v.bits != 0, v.kind == BoolKind
+}
+
+func (v Value) integer() int64 {
+	return int64(v.bits)
+}
+
+func (v Value) float() float64 {
+	return math.Float64frombits(v.bits)
+}
+
+func (v Value) AsInteger() (int64, bool) {
+	return v.integer(), v.kind == IntegerKind
+}
+
+func (v Value) AsFloat() (float64, bool) {
+	return v.float(), v.kind == FloatKind
+}
+
+func (v Value) AsBigInteger() (*big.Int, bool) {
+	n, ok := v.ref.(*big.Int)
+	return n, ok && v.kind == BigIntegerKind
+}
+
+func (v Value) AsDecimal() (decimal.Decimal, bool) {
+	d, ok := v.ref.(decimal.Decimal)
+	return d, ok && v.kind == DecimalKind
+}
+
+// AsNumber returns numbers of any kind as floats, big integers and decimals rounded to the nearest one.
+func (v Value) AsNumber() (float64, bool) {
+	switch v.kind {
+	case IntegerKind:
+		return float64(v.integer()), true
+	case FloatKind:
+		return v.float(), true
+	case BigIntegerKind:
+		f, _ := new(big.Float).SetInt(v.ref.(*big.Int)).Float64()
+		return f, true
+	case DecimalKind:
+		return v.ref.(decimal.Decimal).Float64(), true
+	}
+	return 0, false
+}
+
+// isNumber tells whether the value is a number of any kind.
+func (v Value) isNumber() bool {
+	return v.kind >= IntegerKind && v.kind <= DecimalKind
+}
+
+// isExact tells whether the value is a big integer or a decimal.
+func (v Value) isExact() bool {
+	return v.kind == BigIntegerKind || v.kind == DecimalKind
+}
+
+func (v Value) AsString() (string, bool) {
+	if v.kind != StringKind {
+		return "", false
+	}
+	return v.ref.(string), true
+}
+
+// AsObject returns the object, nil for values of other kinds.
+func (v Value) AsObject() any {
+	if v.kind != ObjectKind {
+		return nil
+	}
+	return v.ref
+}
+
+// String formats the value the way print does.
+func (v Value) String() string {
+	switch v.kind {
+	case NilKind:
+		return "nil"
+	case BoolKind:
+		return fmt.Sprint(v.bits != 0)
+	case IntegerKind:
+		return strconv.FormatInt(v.integer(), 10)
+	case FloatKind:
+		return fmt.Sprint(v.float())
+	case BigIntegerKind, DecimalKind:
+		return fmt.Sprint(v.ref)
+	case StringKind:
+		return v.ref.(string)
+	}
+	switch object := v.ref.(type) {
+	case *loxList:
+		elements := make([]string, 0, len(object.elements))
+		for _, element := range object.elements {
+			elements = append(elements, element.String())
+		}
+		return "[" + strings.Join(elements, ", ") + "]"
+	case *loxMap:
+		pairs := make([]string, 0, len(object.keys))
+		for _, key := range object.keys {
+			pairs = append(pairs, key.String()+": "+object.values[mapKey(key)].String())
+		}
+		return "{" + strings.Join(pairs, ", ") + "}"
+	}
+	return fmt.Sprint(v.ref)
+}
@@ -0,0 +1,139 @@
+package interpreter
+
+import (
+	"math"
+	"math/big"
+	"testing"
+	"unsafe"
+
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/decimal"
+)
+
+func TestValue(t *testing.T) {
+	list := newLoxList(FloatValue(1), StringValue("a"), Nil)
+	dict := newLoxMap()
+	dict.set(StringValue("key"), BoolValue(true))
+	tests := []struct {
+		name       string
+		value      Value
+		wantKind   ValueKind
+		wantString string
+	}{
+		{name: "Nil", value: Nil, wantKind: NilKind, wantString: "nil"},
+		{name: "True", value: BoolValue(true), wantKind: BoolKind, wantString: "true"},
+		{name: "False", value: BoolValue(false), wantKind: BoolKind, wantString: "false"},
+		{name: "Integer", value: IntegerValue(9007199254740993), wantKind: IntegerKind, wantString: "9007199254740993"},
+		{name: "Integral float", value: FloatValue(86400), wantKind: FloatKind, wantString: "86400"},
+		{name: "Float", value: FloatValue(0.5), wantKind: FloatKind, wantString: "0.5"},
+		{name: "Big integer", value: BigIntegerValue(new(big.Int).Lsh(big.NewInt(1), 64)), wantKind: BigIntegerKind, wantString: "18446744073709551616"},
+		{name: "Decimal", value: DecimalValue(mustDecimal(t, "1.10")), wantKind: DecimalKind, wantString: "1.10"},
+		{name: "String", value: StringValue("text"), wantKind: StringKind, wantString: "text"},
+		{name: "List", value: ObjectValue(list), wantKind: ObjectKind, wantString: "[1, a, nil]"},
+		{name: "Map", value: ObjectValue(dict), wantKind: ObjectKind, wantString: "{key: true}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.value.Kind(); got != tt.wantKind {
+				t.Errorf("Kind() = %s, want %s", got, tt.wantKind)
+			}
+			if got := tt.value.String(); got != tt.wantString {
+				t.Errorf("String() = %q, want %q", got, tt.wantString)
+			}
+		})
+	}
+}
+
+func TestValue_Accessors(t *testing.T) {
+	if b, ok := BoolValue(true).AsBool(); !ok || !b {
+		t.Errorf("AsBool() = %v, %v, want true, true", b, ok)
+	}
+	if n, ok := FloatValue(2.5).AsNumber(); !ok || n != 2.5 {
+		t.Errorf("AsNumber() = %v, %v, want 2.5, true", n, ok)
+	}
+	if n, ok := IntegerValue(3).AsNumber(); !ok || n != 3 {
+		t.Errorf("AsNumber() of an integer = %v, %v, want 3, true", n, ok)
+	}
+	if _, ok := IntegerValue(3).AsFloat(); ok {
+		t.Errorf("AsFloat() of an integer is ok")
+	}
+	if n, ok := IntegerValue(3).AsInteger(); !ok || n != 3 {
+		t.Errorf("AsInteger() = %v, %v, want 3, true", n, ok)
+	}
+	if s, ok := StringValue("a").AsString(); !ok || s != "a" {
+		t.Errorf("AsString() = %q, %v, want a, true", s, ok)
+	}
+	if _, ok := FloatValue(1).AsString(); ok {
+		t.Errorf("AsString() of a number is ok")
+	}
+	if _, ok := BoolValue(true).AsNumber(); ok {
+		t.Errorf("AsNumber() of a bool is ok")
+	}
+	list := newLoxList()
+	if got := ObjectValue(list).AsObject(); got != list {
+		t.Errorf("AsObject() = %v, want the list", got)
+	}
+	if got := StringValue("a").AsObject(); got != nil {
+		t.Errorf("AsObject() of a string = %v, want nil", got)
+	}
+	if !Nil.IsNil() || BoolValue(false).IsNil() {
+		t.Errorf("IsNil() is wrong")
+	}
+}
+
+func TestValue_Equality(t *testing.T) {
+	list := newLoxList()
+	tests := []struct {
+		name  string
+		left  Value
+		right Value
+		want  bool
+	}{
+		{name: "Numbers", left: FloatValue(1), right: FloatValue(1), want: true},
+		{name: "Zero and negative zero", left: FloatValue(0), right: FloatValue(math.Copysign(0, -1)), want: true},
+		{name: "NaN", left: FloatValue(math.NaN()), right: FloatValue(math.NaN()), want: false},
+		{name: "Integer and float", left: IntegerValue(1), right: FloatValue(1), want: true},
+		{name: "Integer and fraction", left: IntegerValue(1), right: FloatValue(1.5), want: false},
+		{name: "Big integers", left: BigIntegerValue(big.NewInt(7)), right: BigIntegerValue(big.NewInt(7)), want: true},
+		{name: "Decimals of different scales", left: DecimalValue(mustDecimal(t, "1.10")), right: DecimalValue(mustDecimal(t, "1.1")), want: true},
+		{name: "Decimal and inexact float", left: DecimalValue(mustDecimal(t, "0.1")), right: FloatValue(0.1), want: false},
+		{name: "Strings", left: StringValue("a" + "b"), right: StringValue("ab"), want: true},
+		{name: "Same object", left: ObjectValue(list), right: ObjectValue(list), want: true},
+		{name: "Other objects", left: ObjectValue(list), right: ObjectValue(newLoxList()), want: false},
+		{name: "Number and string", left: FloatValue(1), right: StringValue("1"), want: false},
+		{name: "False and nil", left: BoolValue(false), right: Nil, want: false},
+		{name: "Zero and false", left: FloatValue(0), right: BoolValue(false), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (Interpreter{}).isEqual(tt.left, tt.right); got != tt.want {
+				t.Errorf("isEqual() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func mustDecimal(t *testing.T, text string) decimal.Decimal {
+	t.Helper()
+	d, err := decimal.Parse(text)
+	if err != nil {
+		t.Fatalf("decimal.Parse(%q) error = %v", text, err)
+	}
+	return d
+}
+
+func TestValue_Layout(t *testing.T) {
+	// Numbers share one word, a Value is a kind, the word and an interface.
+	if size, want := unsafe.Sizeof(Value{}), unsafe.Alignof(uint64(0))+unsafe.Sizeof(uint64(0))+unsafe.Sizeof(any(nil)); size != want {
+		t.Errorf("Value size = %d, want %d", size, want)
+	}
+	for _, n := range []int64{0, -1, math.MinInt64, math.MaxInt64} {
+		if got, _ := IntegerValue(n).AsInteger(); got != n {
+			t.Errorf("AsInteger() = %d, want %d", got, n)
+		}
+	}
+	for _, n := range []float64{0, math.Copysign(0, -1), -2.5, math.Inf(1), math.MaxFloat64} {
+		if got, _ := FloatValue(n).AsFloat(); got != n || math.Signbit(got) != math.Signbit(n) {
+			t.Errorf("AsFloat() = %v, want %v", got, n)
+		}
+	}
+}
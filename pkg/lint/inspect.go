@@ -0,0 +1,125 @@
+package lint
+
+import (
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/parser/ast"
+)
+
+// Inspect traverses statements depth-first calling fn for every ast.Stmt and ast.Expr,
+// children of a node are skipped when fn returns false.
+func Inspect(statements []ast.Stmt, fn func(node any) bool) {
+	insp := inspector{fn: fn}
+	insp.statements(statements)
+}
+
+type inspector struct {
+	fn func(node any) bool
+}
+
+func (insp inspector) statements(statements []ast.Stmt) {
+	for _, statement := range statements {
+		insp.stmt(statement)
+	}
+}
+
+func (insp inspector) stmt(stmt ast.Stmt) {
+	if stmt != nil && insp.fn(stmt) {
+		stmt.Accept(insp)
+	}
+}
+
+func (insp inspector) expr(expr ast.Expr) {
+	if expr != nil && insp.fn(expr) {
+		expr.Accept(insp)
+	}
+}
+
+func (insp inspector) VisitBlock(stmt *ast.Block) {
+	insp.statements(stmt.Statements)
+}
+
+func (insp inspector) VisitExpression(stmt *ast.Expression) {
+	insp.expr(stmt.Expression)
+}
+
+func (insp inspector) VisitFunction(stmt *ast.Function) {
+	insp.statements(stmt.Body)
+}
+
+func (insp inspector) VisitIf(stmt *ast.If) {
+	insp.expr(stmt.Condition)
+	insp.stmt(stmt.ThenBranch)
+	insp.stmt(stmt.ElseBranch)
+}
+
+func (insp inspector) VisitPrint(stmt *ast.Print) {
+	insp.expr(stmt.Expression)
+}
+
+func (insp inspector) VisitReturn(stmt *ast.Return) {
+	insp.expr(stmt.Value)
+}
+
+func (insp inspector) VisitVar(stmt *ast.Var) {
+	insp.expr(stmt.Initializer)
+}
+
+func (insp inspector) VisitWhile(stmt *ast.While) {
+	insp.expr(stmt.Condition)
+	insp.stmt(stmt.Body)
+}
+
+func (insp inspector) VisitAssign(expr *ast.Assign) any {
+	insp.expr(expr.Value)
+	return nil
+}
+
+func (insp inspector) VisitBinary(expr *ast.Binary) any {
+	insp.expr(expr.Left)
+	insp.expr(expr.Right)
+	return nil
+}
+
+func (insp inspector) VisitCall(expr *ast.Call) any {
+	insp.expr(expr.Callee)
+	for _, argument := range expr.Arguments {
+		insp.expr(argument)
+	}
+	return nil
+}
+
+func (insp inspector) VisitCoalesce(expr *ast.Coalesce) any {
+	insp.expr(expr.Left)
+	insp.expr(expr.Right)
+	return nil
+}
+
+func (insp inspector) VisitConditional(expr *ast.Conditional) any {
+	insp.expr(expr.Condition)
+	insp.expr(expr.ThenBranch)
+	insp.expr(expr.ElseBranch)
+	return nil
+}
+
+func (insp inspector) VisitGrouping(expr *ast.Grouping) any {
+	insp.expr(expr.Expression)
+	return nil
+}
+
+func (insp inspector) VisitLiteral(*ast.Literal) any {
+	return nil
+}
+
+func (insp inspector) VisitLogical(expr *ast.Logical) any {
+	insp.expr(expr.Left)
+	insp.expr(expr.Right)
+	return nil
+}
+
+func (insp inspector) VisitUnary(expr *ast.Unary) any {
+	insp.expr(expr.Right)
+	return nil
+}
+
+func (insp inspector) VisitVariable(*ast.Variable) any {
+	return nil
+}
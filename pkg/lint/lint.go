@@ -0,0 +1,164 @@
+// Package lint statically checks Lox programs with a set of configurable rules.
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/parser"
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/parser/ast"
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/scanner"
+)
+
+// suppressionPattern matches "// lint:ignore" comments with optional comma separated rule IDs.
+// Trailing comment suppresses its line, comment on its own line suppresses the next line.
+var suppressionPattern = regexp.MustCompile(`^//\s*lint:ignore\b\s*([\w,\s-]*)`)
+
+// Diagnostic is a single rule finding.
+type Diagnostic struct {
+	Rule    string
+	Line    int
+	Message string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%d: [%s] %s", d.Line, d.Rule, d.Message)
+}
+
+// Rule checks the whole program and returns its findings.
+type Rule interface {
+	ID() string
+	Description() string
+	Check(program *Program) []Diagnostic
+}
+
+// Program is a parsed program with positions needed by rules.
+type Program struct {
+	Statements []ast.Stmt
+
+	tokens []scanner.Token
+	spans  map[any]parser.Span
+	scopes *scopes
+}
+
+// Line returns the first line of a parsed node or 0 for nodes without sources.
+func (p *Program) Line(node any) int {
+	span, ok := p.spans[node]
+	if !ok {
+		return 0
+	}
+	return p.tokens[span.First].Line()
+}
+
+// Config enables and disables rules by ID, rules are enabled by default.
+type Config struct {
+	Rules map[string]bool `json:"rules"`
+}
+
+// LoadConfig reads JSON config, e.g. {"rules": {"shadowed-name": false}}.
+func LoadConfig(path string) (Config, error) {
+	config := Config{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("invalid lint config %s: %w", path, err)
+	}
+	for id := range config.Rules {
+		if _, ok := ruleByID(id); !ok {
+			return config, fmt.Errorf("invalid lint config %s: unknown rule %q", path, id)
+		}
+	}
+	return config, nil
+}
+
+// Linter runs enabled rules.
+type Linter struct {
+	rules []Rule
+}
+
+func NewLinter(config Config) *Linter {
+	linter := &Linter{}
+	for _, rule := range Rules() {
+		if enabled, ok := config.Rules[rule.ID()]; ok && !enabled {
+			continue
+		}
+		linter.rules = append(linter.rules, rule)
+	}
+	return linter
+}
+
+// SyntaxError is returned for sources that cannot be parsed.
+type SyntaxError struct {
+	Messages []string
+}
+
+func (se *SyntaxError) Error() string {
+	return "syntax error: " + strings.Join(se.Messages, "; ")
+}
+
+// Lint parses sources and returns not suppressed diagnostics sorted by line.
+func (l *Linter) Lint(sources string) ([]Diagnostic, error) {
+	syntaxErr := &SyntaxError{}
+	reporter := func(line int, message string) {
+		syntaxErr.Messages = append(syntaxErr.Messages, fmt.Sprintf("[line %d] %s", line, message))
+	}
+	scannr := scanner.NewScanner(sources, reporter)
+	tokens := scannr.ScanTokens()
+	parsr := parser.NewParser(tokens, reporter)
+	statements := parsr.Parse()
+	if len(syntaxErr.Messages) > 0 {
+		return nil, syntaxErr
+	}
+
+	program := &Program{
+		Statements: statements,
+		tokens:     tokens,
+		spans:      parsr.Spans(),
+		scopes:     analyzeScopes(statements),
+	}
+	suppressed := suppressions(tokens, scannr.Comments())
+
+	var diagnostics []Diagnostic
+	for _, rule := range l.rules {
+		for _, diagnostic := range rule.Check(program) {
+			rules, ok := suppressed[diagnostic.Line]
+			if ok && (len(rules) == 0 || rules[diagnostic.Rule]) {
+				continue
+			}
+			diagnostics = append(diagnostics, diagnostic)
+		}
+	}
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		return diagnostics[i].Line < diagnostics[j].Line
+	})
+	return diagnostics, nil
+}
+
+// suppressions maps lines to suppressed rule IDs, an empty set suppresses all rules.
+func suppressions(tokens []scanner.Token, comments []scanner.Comment) map[int]map[string]bool {
+	suppressed := make(map[int]map[string]bool)
+	for _, comment := range comments {
+		match := suppressionPattern.FindStringSubmatch(comment.Text())
+		if match == nil {
+			continue
+		}
+		line := comment.Line() + 1
+		if comment.Next() > 0 && tokens[comment.Next()-1].Line() == comment.Line() {
+			line = comment.Line()
+		}
+		rules := make(map[string]bool)
+		for _, id := range strings.FieldsFunc(match[1], func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t'
+		}) {
+			rules[id] = true
+		}
+		suppressed[line] = rules
+	}
+	return suppressed
+}
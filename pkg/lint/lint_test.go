@@ -0,0 +1,112 @@
+package lint
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLinter_Lint(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		sources string
+		want    []Diagnostic
+	}{
+		{
+			name:    "Unused variables",
+			sources: "var a = 1;\nvar b = 2;\nprint b;\nfun f() { var c; }\n{ var d = 1; d = 2; }",
+			want: []Diagnostic{
+				{Rule: "unused-variable", Line: 1, Message: "Variable 'a' is declared but never used."},
+				{Rule: "unused-variable", Line: 4, Message: "Variable 'c' is declared but never used."},
+				{Rule: "unused-variable", Line: 5, Message: "Variable 'd' is declared but never used."},
+			},
+		},
+		{
+			name:    "Globals are read by name from functions",
+			sources: "fun f() { print g; }\nvar g = 1;\nf();",
+			want:    nil,
+		},
+		{
+			name:    "Shadowed names",
+			sources: "var a = 1;\nfun f(a) {\n  { var a = a; print a; }\n}\nf(a);",
+			want: []Diagnostic{
+				{Rule: "shadowed-name", Line: 2, Message: "The parameter 'a' shadows variable declared on line 1."},
+				{Rule: "shadowed-name", Line: 3, Message: "The variable 'a' shadows parameter declared on line 2."},
+			},
+		},
+		{
+			name:    "Unreachable code",
+			sources: "fun f() {\n  return 1;\n  print 2;\n  print 3;\n}\nf();",
+			want: []Diagnostic{
+				{Rule: "unreachable-code", Line: 3, Message: "Unreachable code after return."},
+			},
+		},
+		{
+			name:    "Assignments in conditions",
+			sources: "var a;\nif (a = 1) print a;\nwhile ((a = nil)) print a;",
+			want: []Diagnostic{
+				{Rule: "assignment-in-condition", Line: 2, Message: "Assignment to 'a' used as a condition, did you mean '=='?"},
+			},
+		},
+		{
+			name:    "Constant false loops",
+			sources: "while ((false)) print 1;\nwhile (true) print 2;\nfor (; nil;) print 3;",
+			want: []Diagnostic{
+				{Rule: "constant-false-while", Line: 1, Message: "Loop condition is always false."},
+				{Rule: "constant-false-while", Line: 3, Message: "Loop condition is always false."},
+			},
+		},
+		{
+			name: "Suppression comments",
+			sources: "var a; // lint:ignore\n// lint:ignore unused-variable, shadowed-name\nvar b;\n" +
+				"var c; // lint:ignore shadowed-name",
+			want: []Diagnostic{
+				{Rule: "unused-variable", Line: 4, Message: "Variable 'c' is declared but never used."},
+			},
+		},
+		{
+			name:    "Disabled rules",
+			config:  Config{Rules: map[string]bool{"unused-variable": false, "constant-false-while": true}},
+			sources: "var a;\nwhile (false) {}",
+			want: []Diagnostic{
+				{Rule: "constant-false-while", Line: 2, Message: "Loop condition is always false."},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewLinter(tt.config).Lint(tt.sources)
+			if err != nil {
+				t.Fatalf("Lint() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Lint() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLinter_LintSyntaxError(t *testing.T) {
+	_, err := NewLinter(Config{}).Lint("var = 1;")
+	if _, ok := err.(*SyntaxError); !ok {
+		t.Errorf("Lint() error = %v, want *SyntaxError", err)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid.json")
+	unknown := filepath.Join(dir, "unknown.json")
+	_ = os.WriteFile(valid, []byte(`{"rules": {"shadowed-name": false}}`), 0o644)
+	_ = os.WriteFile(unknown, []byte(`{"rules": {"no-such-rule": false}}`), 0o644)
+
+	config, err := LoadConfig(valid)
+	if err != nil || config.Rules["shadowed-name"] {
+		t.Errorf("LoadConfig() = %v, %v", config, err)
+	}
+	if _, err := LoadConfig(unknown); err == nil {
+		t.Errorf("LoadConfig() expected error for unknown rule")
+	}
+}
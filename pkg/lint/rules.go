@@ -0,0 +1,189 @@
+package lint
+
+import (
+	"fmt"
+
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/parser/ast"
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/scope"
+)
+
+// Rules returns all known rules in the order they are run.
+func Rules() []Rule {
+	return []Rule{
+		unusedVariable{},
+		shadowedName{},
+		unreachableCode{},
+		assignmentInCondition{},
+		constantFalseWhile{},
+	}
+}
+
+func ruleByID(id string) (Rule, bool) {
+	for _, rule := range Rules() {
+		if rule.ID() == id {
+			return rule, true
+		}
+	}
+	return nil, false
+}
+
+type unusedVariable struct{}
+
+func (unusedVariable) ID() string { return "unused-variable" }
+
+func (unusedVariable) Description() string {
+	return "variable is declared but never read"
+}
+
+func (r unusedVariable) Check(program *Program) []Diagnostic {
+	var diagnostics []Diagnostic
+	for _, decl := range program.scopes.Declarations {
+		if decl.Kind != scope.Variable || !program.scopes.unused(decl) {
+			continue
+		}
+		diagnostics = append(diagnostics, Diagnostic{
+			Rule:    r.ID(),
+			Line:    decl.Name.Line(),
+			Message: fmt.Sprintf("Variable '%s' is declared but never used.", decl.Name.Lexeme()),
+		})
+	}
+	return diagnostics
+}
+
+type shadowedName struct{}
+
+func (shadowedName) ID() string { return "shadowed-name" }
+
+func (shadowedName) Description() string {
+	return "local declaration hides a declaration of an enclosing scope"
+}
+
+func (r shadowedName) Check(program *Program) []Diagnostic {
+	var diagnostics []Diagnostic
+	for _, decl := range program.scopes.Declarations {
+		if decl.Shadowed == nil {
+			continue
+		}
+		diagnostics = append(diagnostics, Diagnostic{
+			Rule: r.ID(),
+			Line: decl.Name.Line(),
+			Message: fmt.Sprintf(
+				"The %s '%s' shadows %s declared on line %d.",
+				decl.Kind, decl.Name.Lexeme(), decl.Shadowed.Kind, decl.Shadowed.Name.Line(),
+			),
+		})
+	}
+	return diagnostics
+}
+
+type unreachableCode struct{}
+
+func (unreachableCode) ID() string { return "unreachable-code" }
+
+func (unreachableCode) Description() string {
+	return "statements after return are never executed"
+}
+
+func (r unreachableCode) Check(program *Program) []Diagnostic {
+	var diagnostics []Diagnostic
+	check := func(statements []ast.Stmt) {
+		for index, statement := range statements {
+			if _, ok := statement.(*ast.Return); ok && index+1 < len(statements) {
+				diagnostics = append(diagnostics, Diagnostic{
+					Rule:    r.ID(),
+					Line:    program.Line(statements[index+1]),
+					Message: "Unreachable code after return.",
+				})
+				return
+			}
+		}
+	}
+	Inspect(program.Statements, func(node any) bool {
+		switch stmt := node.(type) {
+		case *ast.Block:
+			check(stmt.Statements)
+		case *ast.Function:
+			check(stmt.Body)
+		}
+		return true
+	})
+	return diagnostics
+}
+
+type assignmentInCondition struct{}
+
+func (assignmentInCondition) ID() string { return "assignment-in-condition" }
+
+func (assignmentInCondition) Description() string {
+	return "condition is an assignment, probably == was meant"
+}
+
+func (r assignmentInCondition) Check(program *Program) []Diagnostic {
+	var diagnostics []Diagnostic
+	check := func(condition ast.Expr) {
+		// Parenthesized assignment is an explicit intent.
+		if assign, ok := condition.(*ast.Assign); ok {
+			diagnostics = append(diagnostics, Diagnostic{
+				Rule:    r.ID(),
+				Line:    assign.Name.Line(),
+				Message: fmt.Sprintf("Assignment to '%s' used as a condition, did you mean '=='?", assign.Name.Lexeme()),
+			})
+		}
+	}
+	Inspect(program.Statements, func(node any) bool {
+		switch stmt := node.(type) {
+		case *ast.If:
+			check(stmt.Condition)
+		case *ast.While:
+			check(stmt.Condition)
+		}
+		return true
+	})
+	return diagnostics
+}
+
+type constantFalseWhile struct{}
+
+func (constantFalseWhile) ID() string { return "constant-false-while" }
+
+func (constantFalseWhile) Description() string {
+	return "while loop condition is always false, so the body never runs"
+}
+
+func (r constantFalseWhile) Check(program *Program) []Diagnostic {
+	var diagnostics []Diagnostic
+	Inspect(program.Statements, func(node any) bool {
+		stmt, ok := node.(*ast.While)
+		if !ok || !falsey(stmt.Condition) {
+			return true
+		}
+		// Loops desugared from for have no sources, only their conditions do.
+		line := program.Line(stmt)
+		if line == 0 {
+			line = program.Line(stmt.Condition)
+		}
+		diagnostics = append(diagnostics, Diagnostic{
+			Rule:    r.ID(),
+			Line:    line,
+			Message: "Loop condition is always false.",
+		})
+		return true
+	})
+	return diagnostics
+}
+
+// falsey reports whether expr is a false or nil literal, maybe parenthesized.
+func falsey(expr ast.Expr) bool {
+	for {
+		grouping, ok := expr.(*ast.Grouping)
+		if !ok {
+			break
+		}
+		expr = grouping.Expression
+	}
+	literal, ok := expr.(*ast.Literal)
+	if !ok {
+		return false
+	}
+	return literal.Value == nil || literal.Value == false
+}
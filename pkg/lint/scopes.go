@@ -0,0 +1,40 @@
+package lint
+
+import (
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/parser/ast"
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/scope"
+)
+
+// scopes are declarations of the program with what of them is read.
+type scopes struct {
+	*scope.Scopes
+	reads map[*scope.Declaration]bool
+	// globalReads are names of globals read, globals are resolved at runtime by name.
+	globalReads map[string]bool
+}
+
+func analyzeScopes(statements []ast.Stmt) *scopes {
+	s := &scopes{
+		Scopes:      scope.Resolve(statements),
+		reads:       make(map[*scope.Declaration]bool),
+		globalReads: make(map[string]bool),
+	}
+	for _, use := range s.Uses {
+		switch {
+		case use.Assignment:
+		case use.Declaration == nil || use.Declaration.Global:
+			s.globalReads[use.Name.Lexeme()] = true
+		default:
+			s.reads[use.Declaration] = true
+		}
+	}
+	return s
+}
+
+// unused reports whether a variable is never read.
+func (s *scopes) unused(decl *scope.Declaration) bool {
+	if decl.Global {
+		return !s.globalReads[decl.Name.Lexeme()]
+	}
+	return !s.reads[decl]
+}
@@ -0,0 +1,229 @@
+package loxtest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/interpreter"
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/parser"
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/parser/ast"
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/scanner"
+)
+
+const (
+	testFileSuffix     = "_test.lox"
+	testFunctionPrefix = "test_"
+)
+
+// Summary counts test functions results over all test files.
+type Summary struct {
+	Files  int
+	Passed int
+	Failed int
+}
+
+// Runner runs test_* functions of *_test.lox files, each in a fresh interpreter.
+type Runner struct {
+	out     io.Writer
+	verbose bool
+	// coverage is nil unless coverage is collected.
+	coverage []*interpreter.Coverage
+}
+
+func NewRunner(out io.Writer, verbose bool) *Runner {
+	return &Runner{
+		out:     out,
+		verbose: verbose,
+	}
+}
+
+// EnableCoverage makes following runs collect coverage of test files, see Coverage.
+func (r *Runner) EnableCoverage() {
+	r.coverage = []*interpreter.Coverage{}
+}
+
+// Coverage is coverage of test files run so far with coverage enabled.
+func (r *Runner) Coverage() []*interpreter.Coverage {
+	return r.coverage
+}
+
+// Discover returns sorted test files, paths are files or directories walked recursively.
+func Discover(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		err = filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !entry.IsDir() && strings.HasSuffix(entry.Name(), testFileSuffix) {
+				files = append(files, file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// Run runs all test files found in paths and prints results with a final summary line.
+func (r *Runner) Run(paths []string) (Summary, error) {
+	files, err := Discover(paths)
+	if err != nil {
+		return Summary{}, err
+	}
+	summary := Summary{}
+	for _, file := range files {
+		passed, failed, err := r.runFile(file)
+		if err != nil {
+			return summary, err
+		}
+		summary.Files++
+		summary.Passed += passed
+		summary.Failed += failed
+	}
+	r.printSummary(summary)
+	return summary, nil
+}
+
+func (r *Runner) runFile(file string) (passed int, failed int, err error) {
+	sources, err := os.ReadFile(file)
+	if err != nil {
+		return 0, 0, err
+	}
+	lines := strings.Split(string(sources), "\n")
+
+	names, compileErrors := testFunctions(string(sources))
+	if len(compileErrors) > 0 {
+		r.printf("--- FAIL: %s\n", file)
+		for _, compileError := range compileErrors {
+			r.printf("    %s\n", compileError)
+		}
+		r.printf("FAIL\t%s\tcompile error\n", file)
+		return 0, 1, nil
+	}
+
+	var coverage *interpreter.Coverage
+	if r.coverage != nil {
+		coverage = interpreter.NewCoverage(file, string(sources))
+		r.coverage = append(r.coverage, coverage)
+	}
+	for _, name := range names {
+		output, testErr := runTest(string(sources), name, coverage)
+		if testErr == nil {
+			passed++
+			if r.verbose {
+				r.printf("--- PASS: %s\n", name)
+			}
+			continue
+		}
+		failed++
+		r.printFailure(file, lines, name, testErr, output)
+	}
+
+	status := "ok  "
+	if failed > 0 {
+		status = "FAIL"
+	}
+	r.printf("%s\t%s\t%d passed, %d failed\n", status, file, passed, failed)
+	return passed, failed, nil
+}
+
+// testFunctions returns names of top level test functions in declaration order.
+func testFunctions(sources string) ([]string, []string) {
+	var compileErrors []string
+	reporter := func(line int, message string) {
+		compileErrors = append(compileErrors, fmt.Sprintf("[line %d] Error: %s", line, message))
+	}
+	tokens := scanner.NewScanner(sources, reporter).ScanTokens()
+	statements := parser.NewParser(tokens, reporter).Parse()
+	if len(compileErrors) > 0 {
+		return nil, compileErrors
+	}
+
+	var names []string
+	for _, statement := range statements {
+		function, ok := statement.(*ast.Function)
+		if ok && strings.HasPrefix(function.Name.Lexeme(), testFunctionPrefix) {
+			names = append(names, function.Name.Lexeme())
+		}
+	}
+	return names, nil
+}
+
+// runTest runs the whole file top level code and then calls the test function,
+// coverage is nil unless collected.
+func runTest(sources string, name string, coverage *interpreter.Coverage) (string, error) {
+	output := &bytes.Buffer{}
+	lox := interpreter.New()
+	lox.SetOutput(output)
+	lox.SetErrorOutput(output)
+	lox.EnableAssertions()
+	if coverage != nil {
+		lox.SetHooks(coverage)
+	}
+
+	lox.Run(sources)
+	if lox.Failed() {
+		return output.String(), fmt.Errorf("file top level code failed")
+	}
+	err := lox.Call(name)
+	return output.String(), err
+}
+
+func (r *Runner) printFailure(file string, lines []string, name string, err error, output string) {
+	rErr, ok := err.(*interpreter.RuntimeError)
+	if !ok || rErr.Line() < 1 || rErr.Line() > len(lines) {
+		r.printf("--- FAIL: %s (%s)\n", name, file)
+		r.printf("    %s\n", err)
+	} else {
+		r.printf("--- FAIL: %s (%s:%d)\n", name, file, rErr.Line())
+		r.printf("    %s\n", rErr.Message())
+		r.printf("    %d | %s\n", rErr.Line(), strings.TrimSpace(lines[rErr.Line()-1]))
+	}
+	if output != "" {
+		r.printf("    output:\n")
+		for _, line := range strings.Split(strings.TrimRight(output, "\n"), "\n") {
+			r.printf("        %s\n", line)
+		}
+	}
+}
+
+func (r *Runner) printSummary(summary Summary) {
+	if summary.Files == 0 {
+		r.printf("no test files\n")
+		return
+	}
+	status := "PASS"
+	if summary.Failed > 0 {
+		status = "FAIL"
+	}
+	r.printf("%s: %d passed, %d failed, %d files\n", status, summary.Passed, summary.Failed, summary.Files)
+	if r.coverage != nil {
+		coverage := interpreter.SummarizeCoverage(r.coverage...)
+		r.printf("coverage: %.1f%% of lines, %.1f%% of branches\n", coverage.LinePercent(), coverage.BranchPercent())
+	}
+}
+
+func (r *Runner) printf(format string, args ...any) {
+	_, err := fmt.Fprintf(r.out, format, args...)
+	if err != nil {
+		panic(err)
+	}
+}
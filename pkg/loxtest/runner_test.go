@@ -0,0 +1,160 @@
+package loxtest
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestDiscover(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"b_test.lox":        "",
+		"a.lox":             "",
+		"nested/c_test.lox": "",
+	})
+
+	got, err := Discover([]string{dir})
+
+	if err != nil {
+		t.Fatalf("Discover() returned error: %s", err)
+	}
+	want := []string{
+		filepath.Join(dir, "b_test.lox"),
+		filepath.Join(dir, "nested", "c_test.lox"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Discover() = %v, want %v", got, want)
+	}
+}
+
+func TestRunner_Run(t *testing.T) {
+	t.Run("Passed and failed tests", func(t *testing.T) {
+		dir := writeFiles(t, map[string]string{
+			"math_test.lox": `var base = 10;
+
+fun test_sum() {
+	assertEqual(base + 1, 11);
+}
+
+fun test_wrong_sum() {
+	print "computing";
+	assertEqual(base + 1, 12);
+}
+
+fun helper() {
+	assert(false);
+}
+`,
+		})
+		out := &bytes.Buffer{}
+
+		summary, err := NewRunner(out, false).Run([]string{dir})
+
+		if err != nil {
+			t.Fatalf("Run() returned error: %s", err)
+		}
+		if want := (Summary{Files: 1, Passed: 1, Failed: 1}); summary != want {
+			t.Errorf("Run() = %+v, want %+v", summary, want)
+		}
+		for _, want := range []string{
+			"--- FAIL: test_wrong_sum (" + filepath.Join(dir, "math_test.lox") + ":9)",
+			"    Assertion failed: expected 12 but got 11.",
+			"    9 | assertEqual(base + 1, 12);",
+			"        computing",
+			"FAIL: 1 passed, 1 failed, 1 files",
+		} {
+			if !strings.Contains(out.String(), want) {
+				t.Errorf("Run() output %q doesn't contain %q", out.String(), want)
+			}
+		}
+	})
+
+	t.Run("Tests run in fresh interpreters", func(t *testing.T) {
+		dir := writeFiles(t, map[string]string{
+			"state_test.lox": `var counter = 0;
+fun test_first() { counter = counter + 1; assertEqual(counter, 1); }
+fun test_second() { counter = counter + 1; assertEqual(counter, 1, "state leaked"); }
+`,
+		})
+		out := &bytes.Buffer{}
+
+		summary, err := NewRunner(out, true).Run([]string{dir})
+
+		if err != nil {
+			t.Fatalf("Run() returned error: %s", err)
+		}
+		if want := (Summary{Files: 1, Passed: 2}); summary != want {
+			t.Errorf("Run() = %+v, want %+v, output %s", summary, want, out.String())
+		}
+		if !strings.Contains(out.String(), "--- PASS: test_second") {
+			t.Errorf("Run() verbose output %q doesn't contain passed test", out.String())
+		}
+	})
+
+	t.Run("Coverage of test files", func(t *testing.T) {
+		dir := writeFiles(t, map[string]string{
+			"abs_test.lox": `fun abs(n) {
+  if (n < 0) return -n;
+  return n;
+}
+fun test_positive() { assertEqual(abs(1), 1); }
+fun test_zero() { assertEqual(abs(0), 0); }
+`,
+		})
+		out := &bytes.Buffer{}
+		runner := NewRunner(out, false)
+		runner.EnableCoverage()
+
+		if _, err := runner.Run([]string{dir}); err != nil {
+			t.Fatalf("Run() returned error: %s", err)
+		}
+
+		coverage := runner.Coverage()
+		if len(coverage) != 1 || coverage[0].FileName != filepath.Join(dir, "abs_test.lox") {
+			t.Fatalf("Coverage() = %v, want coverage of abs_test.lox", coverage)
+		}
+		if want := map[int]int{1: 2, 2: 2, 3: 2, 5: 3, 6: 3}; !reflect.DeepEqual(coverage[0].Lines(), want) {
+			t.Errorf("Coverage() lines = %v, want %v", coverage[0].Lines(), want)
+		}
+		if want := "coverage: 100.0% of lines, 50.0% of branches"; !strings.Contains(out.String(), want) {
+			t.Errorf("Run() output %q doesn't contain %q", out.String(), want)
+		}
+	})
+
+	t.Run("Compile errors fail the file", func(t *testing.T) {
+		dir := writeFiles(t, map[string]string{
+			"broken_test.lox": "fun test_broken( { }",
+		})
+		out := &bytes.Buffer{}
+
+		summary, err := NewRunner(out, false).Run([]string{dir})
+
+		if err != nil {
+			t.Fatalf("Run() returned error: %s", err)
+		}
+		if want := (Summary{Files: 1, Failed: 1}); summary != want {
+			t.Errorf("Run() = %+v, want %+v", summary, want)
+		}
+		if !strings.Contains(out.String(), "Expect parameter name.") {
+			t.Errorf("Run() output %q doesn't contain compile error", out.String())
+		}
+	})
+}
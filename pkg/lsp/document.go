@@ -0,0 +1,342 @@
+package lsp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/cst"
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/lint"
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/parser/ast"
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/scanner"
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/scope"
+)
+
+// document is an open text document analyzed on every change.
+type document struct {
+	uri     string
+	version int
+	text    string
+	tree    *cst.Tree
+
+	// ranges of tokens by their indexes and ranges of comments.
+	ranges   []Range
+	comments []Range
+
+	// symbols are top-level declarations, nested ones are their children.
+	symbols []*symbol
+	// occurrences map identifier token indexes, declarations included, to resolved symbols.
+	occurrences map[int]*symbol
+
+	diagnostics []Diagnostic
+}
+
+func newDocument(uri string, version int, text string) *document {
+	doc := &document{
+		uri:         uri,
+		version:     version,
+		text:        text,
+		occurrences: make(map[int]*symbol),
+	}
+	var syntaxErrors []Diagnostic
+	doc.tree = cst.Parse(text, func(line int, message string) {
+		syntaxErrors = append(syntaxErrors, Diagnostic{
+			Range:    doc.lineRange(line),
+			Severity: SeverityError,
+			Source:   "loxgo",
+			Message:  strings.TrimSpace(message),
+		})
+	})
+	doc.locateTokens()
+	resolve(doc)
+
+	doc.diagnostics = syntaxErrors
+	if len(syntaxErrors) == 0 {
+		doc.diagnostics = doc.lintDiagnostics()
+	}
+	return doc
+}
+
+// locateTokens computes token ranges walking tokens with their trivia.
+func (d *document) locateTokens() {
+	tokens := d.tree.Tokens()
+	d.ranges = make([]Range, len(tokens))
+	position := Position{}
+	skip := func(trivia []scanner.Trivia) {
+		for _, piece := range trivia {
+			start := position
+			position = advance(position, piece.Text())
+			if piece.Kind() == scanner.COMMENT {
+				d.comments = append(d.comments, Range{Start: start, End: position})
+			}
+		}
+	}
+	for index, token := range tokens {
+		trivia := d.tree.Trivia(index)
+		skip(trivia.Leading)
+		start := position
+		position = advance(position, token.Lexeme())
+		d.ranges[index] = Range{Start: start, End: position}
+		skip(trivia.Trailing)
+	}
+}
+
+func advance(position Position, text string) Position {
+	for _, r := range text {
+		if r == '\n' {
+			position.Line++
+			position.Character = 0
+			continue
+		}
+		// Characters out of the basic plane take two UTF-16 code units.
+		position.Character++
+		if r >= 0x10000 {
+			position.Character++
+		}
+	}
+	return position
+}
+
+// lineRange covers the whole line, lines are one-based as the scanner counts them.
+func (d *document) lineRange(line int) Range {
+	lines := strings.Split(d.text, "\n")
+	if line < 1 {
+		line = 1
+	}
+	if line > len(lines) {
+		line = len(lines)
+	}
+	text := strings.TrimRight(lines[line-1], "\r")
+	return Range{
+		Start: Position{Line: line - 1},
+		End:   advance(Position{Line: line - 1}, text),
+	}
+}
+
+func (d *document) lintDiagnostics() []Diagnostic {
+	findings, err := lint.NewLinter(lint.Config{}).Lint(d.text)
+	if err != nil {
+		return nil
+	}
+	diagnostics := make([]Diagnostic, 0, len(findings))
+	for _, finding := range findings {
+		diagnostics = append(diagnostics, Diagnostic{
+			Range:    d.lineRange(finding.Line),
+			Severity: SeverityWarning,
+			Code:     finding.Rule,
+			Source:   "loxgo lint",
+			Message:  finding.Message,
+		})
+	}
+	return diagnostics
+}
+
+// identifierAt returns the index of the identifier token at position or -1.
+func (d *document) identifierAt(position Position) int {
+	for index, token := range d.tree.Tokens() {
+		if token.Kind() == scanner.IDENTIFIER && d.ranges[index].contains(position) {
+			return index
+		}
+	}
+	return -1
+}
+
+func (d *document) location(index int) Location {
+	return Location{URI: d.uri, Range: d.ranges[index]}
+}
+
+// nodeRange covers tokens of the parsed node or only its name for nodes without sources.
+func (d *document) nodeRange(node any, nameIndex int) Range {
+	cstNode := d.tree.NodeOf(node)
+	if cstNode == nil {
+		return d.ranges[nameIndex]
+	}
+	return Range{Start: d.ranges[cstNode.First].Start, End: d.ranges[cstNode.Last].End}
+}
+
+func (d *document) definition(position Position) *Location {
+	index := d.identifierAt(position)
+	sym, ok := d.occurrences[index]
+	if !ok {
+		return nil
+	}
+	location := d.location(sym.index)
+	return &location
+}
+
+func (d *document) references(position Position, includeDeclaration bool) []Location {
+	index := d.identifierAt(position)
+	sym, ok := d.occurrences[index]
+	if !ok {
+		return nil
+	}
+	var locations []Location
+	if includeDeclaration {
+		locations = append(locations, d.location(sym.index))
+	}
+	for _, reference := range sym.references {
+		locations = append(locations, d.location(reference))
+	}
+	return locations
+}
+
+func (d *document) hover(position Position) *Hover {
+	index := d.identifierAt(position)
+	sym, ok := d.occurrences[index]
+	if !ok {
+		return nil
+	}
+	var declaration string
+	switch node := sym.node.(type) {
+	case *ast.Function:
+		declaration = signature(node)
+		if sym.kind == scope.Parameter {
+			declaration = fmt.Sprintf("(parameter) %s\n// %s", sym.name, declaration)
+		}
+	case *ast.Var:
+		declaration = "var " + sym.name + ";"
+		if cstNode := d.tree.NodeOf(node); cstNode != nil {
+			declaration = cstNode.TrimmedText()
+		}
+	}
+	line := d.ranges[sym.index].Start.Line + 1
+	return &Hover{
+		Contents: MarkupContent{
+			Kind:  "markdown",
+			Value: fmt.Sprintf("```lox\n%s\n```\nDeclared on line %d.", declaration, line),
+		},
+		Range: d.ranges[index],
+	}
+}
+
+func signature(function *ast.Function) string {
+	params := make([]string, 0, len(function.Params))
+	for _, param := range function.Params {
+		params = append(params, param.Lexeme())
+	}
+	return fmt.Sprintf("fun %s(%s)", function.Name.Lexeme(), strings.Join(params, ", "))
+}
+
+func (d *document) documentSymbols() []DocumentSymbol {
+	return d.documentSymbolsOf(d.symbols)
+}
+
+func (d *document) documentSymbolsOf(symbols []*symbol) []DocumentSymbol {
+	result := make([]DocumentSymbol, 0, len(symbols))
+	for _, sym := range symbols {
+		docSymbol := DocumentSymbol{
+			Name:           sym.name,
+			Kind:           SymbolKindVariable,
+			Range:          d.nodeRange(sym.node, sym.index),
+			SelectionRange: d.ranges[sym.index],
+			Children:       d.documentSymbolsOf(sym.children),
+		}
+		if function, ok := sym.node.(*ast.Function); ok {
+			docSymbol.Kind = SymbolKindFunction
+			docSymbol.Detail = signature(function)
+		}
+		result = append(result, docSymbol)
+	}
+	return result
+}
+
+// Semantic token types and modifiers, indexes in the legend are used in encoded tokens.
+var (
+	semanticTokenTypes = []string{
+		"keyword", "variable", "parameter", "function", "string", "number", "operator", "comment",
+	}
+	semanticTokenModifiers = []string{"declaration"}
+)
+
+const declarationModifier = 1
+
+var semanticTokenKinds = map[scanner.TokenType]string{
+	scanner.AND: "keyword", scanner.CLASS: "keyword", scanner.ELSE: "keyword", scanner.FALSE: "keyword",
+	scanner.FUN: "keyword", scanner.FOR: "keyword", scanner.IF: "keyword", scanner.NIL: "keyword",
+	scanner.OR: "keyword", scanner.PRINT: "keyword", scanner.RETURN: "keyword", scanner.SUPER: "keyword",
+	scanner.THIS: "keyword", scanner.TRUE: "keyword", scanner.VAR: "keyword", scanner.WHILE: "keyword",
+
+	scanner.IDENTIFIER: "variable",
+	scanner.STRING:     "string",
+	scanner.NUMBER:     "number",
+
+	scanner.MINUS: "operator", scanner.PLUS: "operator", scanner.SLASH: "operator", scanner.STAR: "operator",
+	scanner.BANG: "operator", scanner.BANGEQUAL: "operator", scanner.EQUAL: "operator",
+	scanner.EQUALEQUAL: "operator", scanner.GREATER: "operator", scanner.GREATEREQUAL: "operator",
+	scanner.LESS: "operator", scanner.LESSEQUAL: "operator",
+	scanner.PERCENT: "operator", scanner.TILDESLASH: "operator", scanner.STARSTAR: "operator",
+	scanner.AMPERSAND: "operator", scanner.PIPE: "operator", scanner.CARET: "operator", scanner.TILDE: "operator",
+	scanner.LESSLESS: "operator", scanner.GREATERGREATER: "operator",
+	scanner.PLUSEQUAL: "operator", scanner.MINUSEQUAL: "operator", scanner.STAREQUAL: "operator",
+	scanner.SLASHEQUAL: "operator", scanner.QUESTION: "operator", scanner.COLON: "operator",
+	scanner.QUESTIONQUESTION: "operator",
+}
+
+type semanticToken struct {
+	start     Position
+	length    int
+	kind      int
+	modifiers int
+}
+
+// semanticTokens encodes tokens as the protocol wants: five integers per token,
+// line and start character relative to the previous token, length, type and modifiers.
+func (d *document) semanticTokens() SemanticTokens {
+	var tokens []semanticToken
+	add := func(r Range, text string, kind string, modifiers int) {
+		kindIndex := indexOf(semanticTokenTypes, kind)
+		// Multi-line strings are split by lines, tokens cannot span lines.
+		for offset, line := range strings.Split(text, "\n") {
+			start := r.Start
+			if offset > 0 {
+				start = Position{Line: r.Start.Line + offset}
+			}
+			length := advance(Position{}, line).Character
+			if length > 0 {
+				tokens = append(tokens, semanticToken{start: start, length: length, kind: kindIndex, modifiers: modifiers})
+			}
+		}
+	}
+	for index, token := range d.tree.Tokens() {
+		kind, ok := semanticTokenKinds[token.Kind()]
+		if !ok {
+			continue
+		}
+		modifiers := 0
+		if sym, ok := d.occurrences[index]; ok {
+			kind = string(sym.kind)
+			if sym.index == index {
+				modifiers = declarationModifier
+			}
+		}
+		add(d.ranges[index], token.Lexeme(), kind, modifiers)
+	}
+	for _, comment := range d.comments {
+		add(comment, strings.Repeat(" ", comment.End.Character-comment.Start.Character), "comment", 0)
+	}
+	sort.SliceStable(tokens, func(i, j int) bool {
+		return tokens[i].start.before(tokens[j].start)
+	})
+
+	data := make([]int, 0, len(tokens)*5)
+	previous := Position{}
+	for _, token := range tokens {
+		deltaLine := token.start.Line - previous.Line
+		deltaStart := token.start.Character
+		if deltaLine == 0 {
+			deltaStart -= previous.Character
+		}
+		data = append(data, deltaLine, deltaStart, token.length, token.kind, token.modifiers)
+		previous = token.start
+	}
+	return SemanticTokens{Data: data}
+}
+
+func indexOf(values []string, value string) int {
+	for index, v := range values {
+		if v == value {
+			return index
+		}
+	}
+	return -1
+}
@@ -0,0 +1,66 @@
+package lsp
+
+import (
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/parser/ast"
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/scope"
+)
+
+type symbol struct {
+	name string
+	// kind is named after the semantic token type the symbol is highlighted with.
+	kind scope.Kind
+	// index of the name token.
+	index int
+	// node is the *ast.Var or *ast.Function declaring the symbol,
+	// the function for parameters.
+	node       ast.Stmt
+	references []int
+	// children are declarations nested in function bodies.
+	children []*symbol
+}
+
+// resolve binds names with scope.Resolve, the way lint and the interpreter do,
+// and maps them to token indexes. Nodes without sources are skipped.
+func resolve(doc *document) {
+	scopes := scope.Resolve(doc.tree.Statements)
+	symbols := make(map[*scope.Declaration]*symbol, len(scopes.Declarations))
+	for _, decl := range scopes.Declarations {
+		first := firstToken(doc, decl.Node)
+		if first < 0 {
+			continue
+		}
+		// Tokens are "var name" and "fun name ( param , param ...".
+		index := first + 1
+		if decl.Kind == scope.Parameter {
+			index = first + 3 + 2*decl.Param
+		}
+		sym := &symbol{name: decl.Name.Lexeme(), kind: decl.Kind, index: index, node: decl.Node}
+		symbols[decl] = sym
+		doc.occurrences[index] = sym
+		switch function, ok := symbols[decl.Function]; {
+		case decl.Kind == scope.Parameter:
+		case ok:
+			function.children = append(function.children, sym)
+		default:
+			doc.symbols = append(doc.symbols, sym)
+		}
+	}
+	for _, use := range scopes.Uses {
+		sym, ok := symbols[use.Declaration]
+		index := firstToken(doc, use.Node)
+		if !ok || index < 0 {
+			continue
+		}
+		sym.references = append(sym.references, index)
+		doc.occurrences[index] = sym
+	}
+}
+
+// firstToken is the index of the first token of a parsed node or -1 for nodes without sources.
+func firstToken(doc *document, node any) int {
+	cstNode := doc.tree.NodeOf(node)
+	if cstNode == nil {
+		return -1
+	}
+	return cstNode.First
+}
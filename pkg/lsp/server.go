@@ -0,0 +1,217 @@
+// Package lsp implements a Language Server Protocol server for Lox over a byte stream,
+// usually standard input and output of "loxgo lsp".
+package lsp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/framing"
+)
+
+// ErrExitWithoutShutdown is returned by Run when the client sends exit before shutdown.
+var ErrExitWithoutShutdown = errors.New("exit notification received before shutdown request")
+
+type Server struct {
+	conn        *framing.Conn
+	documents   map[string]*document
+	initialized bool
+	shutdown    bool
+}
+
+func NewServer(in io.Reader, out io.Writer) *Server {
+	return &Server{
+		conn:      framing.NewConn(in, out),
+		documents: make(map[string]*document),
+	}
+}
+
+// Run serves messages until the exit notification or the end of input.
+func (s *Server) Run() error {
+	for {
+		body, err := s.conn.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			if err := s.replyError(nil, codeParseError, err.Error()); err != nil {
+				return err
+			}
+			continue
+		}
+		if req.Method == "exit" {
+			if !s.shutdown {
+				return ErrExitWithoutShutdown
+			}
+			return nil
+		}
+		if err := s.dispatch(req); err != nil {
+			return err
+		}
+	}
+}
+
+// dispatch answers requests and handles notifications, it fails only on write errors.
+func (s *Server) dispatch(req request) error {
+	isNotification := len(req.ID) == 0
+	if !s.initialized && req.Method != "initialize" {
+		if isNotification {
+			return nil
+		}
+		return s.replyError(req.ID, codeServerNotInitialized, "server is not initialized")
+	}
+	if s.shutdown && !isNotification {
+		return s.replyError(req.ID, codeInvalidRequest, "server is shut down")
+	}
+
+	if isNotification {
+		return s.notified(req)
+	}
+	result, err := s.requested(req)
+	if err != nil {
+		return s.replyError(req.ID, err.Code, err.Message)
+	}
+	return s.conn.Write(response{JSONRPC: "2.0", ID: req.ID, Result: result})
+}
+
+func (s *Server) notified(req request) error {
+	switch req.Method {
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if json.Unmarshal(req.Params, &params) != nil {
+			return nil
+		}
+		item := params.TextDocument
+		return s.update(newDocument(item.URI, item.Version, item.Text))
+	case "textDocument/didChange":
+		var params didChangeParams
+		if json.Unmarshal(req.Params, &params) != nil || len(params.ContentChanges) == 0 {
+			return nil
+		}
+		text := params.ContentChanges[len(params.ContentChanges)-1].Text
+		return s.update(newDocument(params.TextDocument.URI, params.TextDocument.Version, text))
+	case "textDocument/didClose":
+		var params didCloseParams
+		if json.Unmarshal(req.Params, &params) != nil {
+			return nil
+		}
+		delete(s.documents, params.TextDocument.URI)
+		return s.publishDiagnostics(params.TextDocument.URI, 0, []Diagnostic{})
+	}
+	// "initialized", "$/cancelRequest" and other notifications need no reaction.
+	return nil
+}
+
+func (s *Server) update(doc *document) error {
+	s.documents[doc.uri] = doc
+	diagnostics := doc.diagnostics
+	if diagnostics == nil {
+		diagnostics = []Diagnostic{}
+	}
+	return s.publishDiagnostics(doc.uri, doc.version, diagnostics)
+}
+
+func (s *Server) publishDiagnostics(uri string, version int, diagnostics []Diagnostic) error {
+	return s.conn.Write(notification{
+		JSONRPC: "2.0",
+		Method:  "textDocument/publishDiagnostics",
+		Params:  publishDiagnosticsParams{URI: uri, Version: version, Diagnostics: diagnostics},
+	})
+}
+
+func (s *Server) requested(req request) (any, *responseError) {
+	switch req.Method {
+	case "initialize":
+		s.initialized = true
+		return initializeResult(), nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/definition":
+		var params textDocumentPositionParams
+		doc, err := s.documentOf(req.Params, &params, &params.TextDocument)
+		if err != nil {
+			return nil, err
+		}
+		return doc.definition(params.Position), nil
+	case "textDocument/references":
+		var params referenceParams
+		doc, err := s.documentOf(req.Params, &params, &params.TextDocument)
+		if err != nil {
+			return nil, err
+		}
+		return doc.references(params.Position, params.Context.IncludeDeclaration), nil
+	case "textDocument/hover":
+		var params textDocumentPositionParams
+		doc, err := s.documentOf(req.Params, &params, &params.TextDocument)
+		if err != nil {
+			return nil, err
+		}
+		return doc.hover(params.Position), nil
+	case "textDocument/documentSymbol":
+		var params documentParams
+		doc, err := s.documentOf(req.Params, &params, &params.TextDocument)
+		if err != nil {
+			return nil, err
+		}
+		return doc.documentSymbols(), nil
+	case "textDocument/semanticTokens/full":
+		var params documentParams
+		doc, err := s.documentOf(req.Params, &params, &params.TextDocument)
+		if err != nil {
+			return nil, err
+		}
+		return doc.semanticTokens(), nil
+	}
+	return nil, &responseError{Code: codeMethodNotFound, Message: fmt.Sprintf("method %q is not supported", req.Method)}
+}
+
+// documentOf decodes params and finds the open document they refer to.
+func (s *Server) documentOf(raw json.RawMessage, params any, id *textDocumentIdentifier) (*document, *responseError) {
+	if err := json.Unmarshal(raw, params); err != nil {
+		return nil, &responseError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	doc, ok := s.documents[id.URI]
+	if !ok {
+		return nil, &responseError{Code: codeInvalidParams, Message: fmt.Sprintf("document %s is not open", id.URI)}
+	}
+	return doc, nil
+}
+
+func (s *Server) replyError(id json.RawMessage, code int, message string) error {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	return s.conn.Write(errorResponse{
+		JSONRPC: "2.0",
+		ID:      id,
+		Error:   responseError{Code: code, Message: message},
+	})
+}
+
+func initializeResult() any {
+	return map[string]any{
+		"capabilities": map[string]any{
+			// Full synchronization, clients send whole documents on every change.
+			"textDocumentSync":       1,
+			"definitionProvider":     true,
+			"referencesProvider":     true,
+			"hoverProvider":          true,
+			"documentSymbolProvider": true,
+			"semanticTokensProvider": map[string]any{
+				"legend": map[string]any{
+					"tokenTypes":     semanticTokenTypes,
+					"tokenModifiers": semanticTokenModifiers,
+				},
+				"full": true,
+			},
+		},
+		"serverInfo": map[string]any{"name": "loxgo"},
+	}
+}
@@ -0,0 +1,236 @@
+package lsp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/framing"
+)
+
+const testURI = "file:///test.lox"
+
+const testSources = `var greeting = "hi"; // comment
+fun greet(name) {
+  var message = greeting + name;
+  print message;
+}
+greet("bob");
+`
+
+// session sends messages to a fresh server and returns everything it wrote,
+// ids of requests are their indexes in messages.
+func session(t *testing.T, messages ...map[string]any) []map[string]any {
+	t.Helper()
+	input := bytes.Buffer{}
+	for index, msg := range messages {
+		msg["jsonrpc"] = "2.0"
+		if _, isRequest := msg["id"]; isRequest {
+			msg["id"] = index
+		}
+		body, _ := json.Marshal(msg)
+		fmt.Fprintf(&input, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	}
+	output := bytes.Buffer{}
+	if err := NewServer(&input, &output).Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	var written []map[string]any
+	conn := framing.NewConn(&output, nil)
+	for {
+		body, err := conn.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		var msg map[string]any
+		_ = json.Unmarshal(body, &msg)
+		written = append(written, msg)
+	}
+	return written
+}
+
+// opened is a session with the test document opened, its messages are after initialize and didOpen.
+func opened(t *testing.T, messages ...map[string]any) []map[string]any {
+	t.Helper()
+	all := append([]map[string]any{
+		{"id": nil, "method": "initialize", "params": map[string]any{}},
+		{"method": "textDocument/didOpen", "params": map[string]any{
+			"textDocument": map[string]any{"uri": testURI, "version": 1, "text": testSources},
+		}},
+	}, messages...)
+	return session(t, all...)[2:]
+}
+
+func at(method string, line, character int) map[string]any {
+	return map[string]any{"id": nil, "method": method, "params": map[string]any{
+		"textDocument": map[string]any{"uri": testURI},
+		"position":     map[string]any{"line": line, "character": character},
+		"context":      map[string]any{"includeDeclaration": true},
+	}}
+}
+
+// starts extracts start lines and characters of locations.
+func starts(value any) []string {
+	var result []string
+	locations, _ := value.([]any)
+	for _, location := range locations {
+		start := location.(map[string]any)["range"].(map[string]any)["start"].(map[string]any)
+		result = append(result, fmt.Sprintf("%v:%v", start["line"], start["character"]))
+	}
+	return result
+}
+
+func TestServer_Lifecycle(t *testing.T) {
+	written := session(t,
+		map[string]any{"id": nil, "method": "textDocument/hover"},
+		map[string]any{"id": nil, "method": "initialize", "params": map[string]any{}},
+		map[string]any{"method": "initialized", "params": map[string]any{}},
+		map[string]any{"id": nil, "method": "unknown/method"},
+		map[string]any{"id": nil, "method": "shutdown"},
+		map[string]any{"method": "exit"},
+	)
+	if len(written) != 4 {
+		t.Fatalf("got %d messages, want 4: %v", len(written), written)
+	}
+	if code := written[0]["error"].(map[string]any)["code"]; code != float64(codeServerNotInitialized) {
+		t.Errorf("request before initialize error code = %v", code)
+	}
+	capabilities := written[1]["result"].(map[string]any)["capabilities"].(map[string]any)
+	for _, capability := range []string{"definitionProvider", "referencesProvider", "hoverProvider", "documentSymbolProvider"} {
+		if capabilities[capability] != true {
+			t.Errorf("capability %s is not advertised", capability)
+		}
+	}
+	if code := written[2]["error"].(map[string]any)["code"]; code != float64(codeMethodNotFound) {
+		t.Errorf("unknown method error code = %v", code)
+	}
+	if result, ok := written[3]["result"]; !ok || result != nil {
+		t.Errorf("shutdown result = %v, want null", written[3])
+	}
+}
+
+func TestServer_Diagnostics(t *testing.T) {
+	written := opened(t, map[string]any{"method": "textDocument/didChange", "params": map[string]any{
+		"textDocument":   map[string]any{"uri": testURI, "version": 2},
+		"contentChanges": []any{map[string]any{"text": "var x = 1;\nprint (;\n"}},
+	}})
+	diagnostics := written[0]["params"].(map[string]any)["diagnostics"].([]any)
+	if len(diagnostics) != 1 {
+		t.Fatalf("got diagnostics %v, want one syntax error", diagnostics)
+	}
+	diagnostic := diagnostics[0].(map[string]any)
+	if diagnostic["message"] != "at ';'. Message: Expect expression." || diagnostic["severity"] != float64(SeverityError) {
+		t.Errorf("got diagnostic %v", diagnostic)
+	}
+	if line := diagnostic["range"].(map[string]any)["start"].(map[string]any)["line"]; line != float64(1) {
+		t.Errorf("got diagnostic line %v, want 1", line)
+	}
+
+	written = opened(t, map[string]any{"method": "textDocument/didChange", "params": map[string]any{
+		"textDocument":   map[string]any{"uri": testURI, "version": 3},
+		"contentChanges": []any{map[string]any{"text": "var unused = 1;\n"}},
+	}})
+	diagnostic = written[0]["params"].(map[string]any)["diagnostics"].([]any)[0].(map[string]any)
+	if diagnostic["code"] != "unused-variable" || diagnostic["severity"] != float64(SeverityWarning) {
+		t.Errorf("got lint diagnostic %v", diagnostic)
+	}
+}
+
+func TestServer_Navigation(t *testing.T) {
+	written := opened(t,
+		// "greeting" read inside greet.
+		at("textDocument/definition", 2, 18),
+		// "name" parameter.
+		at("textDocument/references", 1, 11),
+		// "greet" call.
+		at("textDocument/references", 5, 2),
+		// "print" keyword is not a name.
+		at("textDocument/definition", 3, 3),
+	)
+	if got := starts([]any{written[0]["result"]}); !reflect.DeepEqual(got, []string{"0:4"}) {
+		t.Errorf("definition = %v, want 0:4", got)
+	}
+	if got := starts(written[1]["result"]); !reflect.DeepEqual(got, []string{"1:10", "2:27"}) {
+		t.Errorf("parameter references = %v", got)
+	}
+	if got := starts(written[2]["result"]); !reflect.DeepEqual(got, []string{"1:4", "5:0"}) {
+		t.Errorf("function references = %v", got)
+	}
+	if result := written[3]["result"]; result != nil {
+		t.Errorf("definition of keyword = %v, want null", result)
+	}
+}
+
+func TestServer_Hover(t *testing.T) {
+	written := opened(t, at("textDocument/hover", 3, 9), at("textDocument/hover", 5, 1))
+	tests := []string{
+		"```lox\nvar message = greeting + name;\n```\nDeclared on line 3.",
+		"```lox\nfun greet(name)\n```\nDeclared on line 2.",
+	}
+	for index, want := range tests {
+		contents := written[index]["result"].(map[string]any)["contents"].(map[string]any)
+		if contents["value"] != want {
+			t.Errorf("hover = %q, want %q", contents["value"], want)
+		}
+	}
+}
+
+func TestServer_DocumentSymbols(t *testing.T) {
+	written := opened(t, map[string]any{"id": nil, "method": "textDocument/documentSymbol", "params": map[string]any{
+		"textDocument": map[string]any{"uri": testURI},
+	}})
+	body, _ := json.Marshal(written[0]["result"])
+	var symbols []DocumentSymbol
+	_ = json.Unmarshal(body, &symbols)
+
+	var got []string
+	var collect func(prefix string, symbols []DocumentSymbol)
+	collect = func(prefix string, symbols []DocumentSymbol) {
+		for _, symbol := range symbols {
+			got = append(got, fmt.Sprintf("%s%s %d %d-%d", prefix, symbol.Name, symbol.Kind,
+				symbol.Range.Start.Line, symbol.Range.End.Line))
+			collect(prefix+symbol.Name+".", symbol.Children)
+		}
+	}
+	collect("", symbols)
+	want := []string{"greeting 13 0-0", "greet 12 1-4", "greet.message 13 2-2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("document symbols = %v, want %v", got, want)
+	}
+}
+
+func TestServer_SemanticTokens(t *testing.T) {
+	written := opened(t, map[string]any{"id": nil, "method": "textDocument/semanticTokens/full", "params": map[string]any{
+		"textDocument": map[string]any{"uri": testURI},
+	}})
+	body, _ := json.Marshal(written[0]["result"])
+	var tokens SemanticTokens
+	_ = json.Unmarshal(body, &tokens)
+
+	// Decode the first line: var greeting = "hi"; // comment
+	var got []string
+	line, character := 0, 0
+	for i := 0; i+5 <= len(tokens.Data); i += 5 {
+		if tokens.Data[i] > 0 {
+			line += tokens.Data[i]
+			character = 0
+		}
+		character += tokens.Data[i+1]
+		if line > 0 {
+			break
+		}
+		got = append(got, fmt.Sprintf("%d+%d %s %d", character, tokens.Data[i+2],
+			semanticTokenTypes[tokens.Data[i+3]], tokens.Data[i+4]))
+	}
+	want := []string{"0+3 keyword 0", "4+8 variable 1", "13+1 operator 0", "15+4 string 0", "21+10 comment 0"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("semantic tokens = %v, want %v", got, want)
+	}
+}
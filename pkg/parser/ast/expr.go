@@ -11,6 +11,9 @@ type Expr interface {
 type VisitorExpr interface {
 	VisitAssign(*Assign) any
 	VisitBinary(*Binary) any
+	VisitCall(*Call) any
+	VisitCoalesce(*Coalesce) any
+	VisitConditional(*Conditional) any
 	VisitGrouping(*Grouping) any
 	VisitLiteral(*Literal) any
 	VisitLogical(*Logical) any
@@ -23,6 +26,8 @@ type Assign struct {
 	Name scanner.Token
 	// Value field.
 	Value Expr
+	// Local field.
+	Local *Slot
 }
 
 func NewAssign(name scanner.Token, value Expr) *Assign {
@@ -57,6 +62,69 @@ func (b *Binary) Accept(visitor VisitorExpr) any {
 	return visitor.VisitBinary(b)
 }
 
+type Call struct {
+	// Callee field.
+	Callee Expr
+	// Paren field.
+	Paren scanner.Token
+	// Arguments field.
+	Arguments []Expr
+}
+
+func NewCall(callee Expr, paren scanner.Token, arguments []Expr) *Call {
+	this := Call{}
+	this.Callee = callee
+	this.Paren = paren
+	this.Arguments = arguments
+	return &this
+}
+
+func (c *Call) Accept(visitor VisitorExpr) any {
+	return visitor.VisitCall(c)
+}
+
+type Coalesce struct {
+	// Left field.
+	Left Expr
+	// Operator field.
+	Operator scanner.Token
+	// Right field.
+	Right Expr
+}
+
+func NewCoalesce(left Expr, operator scanner.Token, right Expr) *Coalesce {
+	this := Coalesce{}
+	this.Left = left
+	this.Operator = operator
+	this.Right = right
+	return &this
+}
+
+func (c *Coalesce) Accept(visitor VisitorExpr) any {
+	return visitor.VisitCoalesce(c)
+}
+
+type Conditional struct {
+	// Condition field.
+	Condition Expr
+	// ThenBranch field.
+	ThenBranch Expr
+	// ElseBranch field.
+	ElseBranch Expr
+}
+
+func NewConditional(condition Expr, thenBranch Expr, elseBranch Expr) *Conditional {
+	this := Conditional{}
+	this.Condition = condition
+	this.ThenBranch = thenBranch
+	this.ElseBranch = elseBranch
+	return &this
+}
+
+func (c *Conditional) Accept(visitor VisitorExpr) any {
+	return visitor.VisitConditional(c)
+}
+
 type Grouping struct {
 	// Expression field.
 	Expression Expr
@@ -129,6 +197,8 @@ func (u *Unary) Accept(visitor VisitorExpr) any {
 type Variable struct {
 	// Name field.
 	Name scanner.Token
+	// Local field.
+	Local *Slot
 }
 
 func NewVariable(name scanner.Token) *Variable {
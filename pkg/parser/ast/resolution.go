@@ -0,0 +1,15 @@
+package ast
+
+// Slot locates a local variable, Depth is how many scopes out of the current one
+// it is declared in and Index is its slot there. Nodes without slots are globals.
+type Slot struct {
+	Depth int
+	Index int
+}
+
+// Scope is the slots of a block or a function scope, environments of the scope share it.
+type Scope struct {
+	Names []string
+	// Params are slots of function parameters.
+	Params []int
+}
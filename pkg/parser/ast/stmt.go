@@ -11,8 +11,10 @@ type Stmt interface {
 type VisitorStmt interface {
 	VisitBlock(*Block)
 	VisitExpression(*Expression)
+	VisitFunction(*Function)
 	VisitIf(*If)
 	VisitPrint(*Print)
+	VisitReturn(*Return)
 	VisitVar(*Var)
 	VisitWhile(*While)
 }
@@ -20,6 +22,8 @@ type VisitorStmt interface {
 type Block struct {
 	// Statements field.
 	Statements []Stmt
+	// Scope field.
+	Scope *Scope
 }
 
 func NewBlock(statements []Stmt) *Block {
@@ -47,6 +51,31 @@ func (e *Expression) Accept(visitor VisitorStmt) {
 	visitor.VisitExpression(e)
 }
 
+type Function struct {
+	// Name field.
+	Name scanner.Token
+	// Params field.
+	Params []scanner.Token
+	// Body field.
+	Body []Stmt
+	// Local field.
+	Local *Slot
+	// Scope field.
+	Scope *Scope
+}
+
+func NewFunction(name scanner.Token, params []scanner.Token, body []Stmt) *Function {
+	this := Function{}
+	this.Name = name
+	this.Params = params
+	this.Body = body
+	return &this
+}
+
+func (f *Function) Accept(visitor VisitorStmt) {
+	visitor.VisitFunction(f)
+}
+
 type If struct {
 	// Condition field.
 	Condition Expr
@@ -83,11 +112,31 @@ func (p *Print) Accept(visitor VisitorStmt) {
 	visitor.VisitPrint(p)
 }
 
+type Return struct {
+	// Keyword field.
+	Keyword scanner.Token
+	// Value field.
+	Value Expr
+}
+
+func NewReturn(keyword scanner.Token, value Expr) *Return {
+	this := Return{}
+	this.Keyword = keyword
+	this.Value = value
+	return &this
+}
+
+func (r *Return) Accept(visitor VisitorStmt) {
+	visitor.VisitReturn(r)
+}
+
 type Var struct {
 	// Name field.
 	Name scanner.Token
 	// Initializer field.
 	Initializer Expr
+	// Local field.
+	Local *Slot
 }
 
 func NewVar(name scanner.Token, initializer Expr) *Var {
@@ -2,15 +2,28 @@ package parser
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/errors"
 	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/parser/ast"
 	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/scanner"
 )
 
+const maxArguments = 255
+
+// Span is a range of token indexes a node was parsed from, both ends are included.
+type Span struct {
+	First int
+	Last  int
+}
+
 type Parser struct {
 	tokens  []scanner.Token
 	current int
+	// functionDepth is used to reject return statements outside functions.
+	functionDepth int
+	// spans of parsed nodes, nodes synthesized by desugaring have no spans.
+	spans map[any]Span
 
 	errReporter errors.Reporter
 }
@@ -19,6 +32,7 @@ func NewParser(tokens []scanner.Token, errReporter errors.Reporter) *Parser {
 	return &Parser{
 		tokens:  tokens,
 		current: 0,
+		spans:   make(map[any]Span),
 
 		errReporter: errReporter,
 	}
@@ -37,6 +51,11 @@ func (p *Parser) Parse() (astTree []ast.Stmt) {
 	return statements
 }
 
+// Spans returns token spans of nodes produced by Parse, keyed by node pointers.
+func (p *Parser) Spans() map[any]Span {
+	return p.spans
+}
+
 func (p *Parser) expression() ast.Expr {
 	return p.assignment()
 }
@@ -48,13 +67,22 @@ func (p *Parser) declaration() (astTree ast.Stmt) {
 			p.synchronize()
 		}
 	}()
+	start := p.current
+	if p.match(scanner.FUN) {
+		return spanned(p, p.function("function"), start)
+	}
 	if p.match(scanner.VAR) {
-		return p.varDeclaration()
+		return spanned(p, p.varDeclaration(), start)
 	}
 	return p.statement()
 }
 
 func (p *Parser) statement() ast.Stmt {
+	start := p.current
+	return spanned(p, p.statementNode(), start)
+}
+
+func (p *Parser) statementNode() ast.Stmt {
 	if p.match(scanner.FOR) {
 		return p.forStatement()
 	}
@@ -64,6 +92,9 @@ func (p *Parser) statement() ast.Stmt {
 	if p.match(scanner.PRINT) {
 		return p.printStatement()
 	}
+	if p.match(scanner.RETURN) {
+		return p.returnStatement()
+	}
 	if p.match(scanner.WHILE) {
 		return p.whileStatement()
 	}
@@ -76,13 +107,14 @@ func (p *Parser) statement() ast.Stmt {
 func (p *Parser) forStatement() ast.Stmt {
 	p.consume(scanner.LEFTPAREN, "Expect '(' after 'for'.")
 	var initializer ast.Stmt
+	start := p.current
 	switch {
 	case p.match(scanner.SEMICOLON):
 		// passing, there is no initializer, leaving as nil
 	case p.match(scanner.VAR):
-		initializer = p.varDeclaration()
+		initializer = spanned(p, p.varDeclaration(), start)
 	default:
-		initializer = p.expressionStatement()
+		initializer = spanned(p, p.expressionStatement(), start)
 	}
 
 	// setting infinity loop by default
@@ -142,6 +174,44 @@ func (p *Parser) printStatement() ast.Stmt {
 	return ast.NewPrint(value)
 }
 
+func (p *Parser) returnStatement() ast.Stmt {
+	keyword := p.previous()
+	if p.functionDepth == 0 {
+		p.erro(keyword, "Can't return from top-level code.")
+	}
+	var value ast.Expr
+	if !p.check(scanner.SEMICOLON) {
+		value = p.expression()
+	}
+	p.consume(scanner.SEMICOLON, "Expect ';' after return value.")
+	return ast.NewReturn(keyword, value)
+}
+
+// function parses function declaration, kind is used in error messages.
+func (p *Parser) function(kind string) ast.Stmt {
+	name := p.consume(scanner.IDENTIFIER, "Expect "+kind+" name.")
+	p.consume(scanner.LEFTPAREN, "Expect '(' after "+kind+" name.")
+	var parameters []scanner.Token
+	if !p.check(scanner.RIGHTPAREN) {
+		parameters = append(parameters, p.consume(scanner.IDENTIFIER, "Expect parameter name."))
+		for p.match(scanner.COMMA) {
+			if len(parameters) >= maxArguments {
+				p.erro(p.peek(), fmt.Sprintf("Can't have more than %d parameters.", maxArguments))
+			}
+			parameters = append(parameters, p.consume(scanner.IDENTIFIER, "Expect parameter name."))
+		}
+	}
+	p.consume(scanner.RIGHTPAREN, "Expect ')' after parameters.")
+
+	p.consume(scanner.LEFTBRACE, "Expect '{' before "+kind+" body.")
+	p.functionDepth++
+	defer func() {
+		p.functionDepth--
+	}()
+	body := p.block()
+	return ast.NewFunction(name, parameters, body)
+}
+
 func (p *Parser) varDeclaration() ast.Stmt {
 	name := p.consume(scanner.IDENTIFIER, "Expect variable name.")
 	var initializer ast.Expr
@@ -168,7 +238,8 @@ func (p *Parser) block() []ast.Stmt {
 }
 
 func (p *Parser) assignment() ast.Expr {
-	expr := p.or()
+	start := p.current
+	expr := p.conditional()
 	if p.match(scanner.EQUAL) {
 		equals := p.previous()
 		value := p.assignment()
@@ -178,29 +249,85 @@ func (p *Parser) assignment() ast.Expr {
 			panic(p.erro(equals, "Invalid assignment target."))
 		}
 		name := variable.Name
-		return ast.NewAssign(name, value)
+		return spanned(p, ast.NewAssign(name, value), start)
+	}
+	if p.match(scanner.PLUSEQUAL, scanner.MINUSEQUAL, scanner.STAREQUAL, scanner.SLASHEQUAL) {
+		return p.compoundAssignment(expr, start)
+	}
+	return expr
+}
+
+// conditional is cond ? a : b, its branch after the colon is conditional too,
+// so a ? b : c ? d : e is a ? b : (c ? d : e).
+func (p *Parser) conditional() ast.Expr {
+	start := p.current
+	expr := p.coalesce()
+	if p.match(scanner.QUESTION) {
+		thenBranch := p.assignment()
+		p.consume(scanner.COLON, "Expect ':' after then branch of conditional expression.")
+		elseBranch := p.conditional()
+		return spanned(p, ast.NewConditional(expr, thenBranch, elseBranch), start)
+	}
+	return expr
+}
+
+// coalesce is a ?? b, it binds looser than or, so a or b ?? c is (a or b) ?? c.
+func (p *Parser) coalesce() ast.Expr {
+	start := p.current
+	expr := p.or()
+	for p.match(scanner.QUESTIONQUESTION) {
+		operator := p.previous()
+		right := p.or()
+		expr = spanned(p, ast.NewCoalesce(expr, operator, right), start)
 	}
 	return expr
 }
 
+// compoundOperators are binary operators of compound assignments.
+var compoundOperators = map[scanner.TokenType]scanner.TokenType{
+	scanner.PLUSEQUAL:  scanner.PLUS,
+	scanner.MINUSEQUAL: scanner.MINUS,
+	scanner.STAREQUAL:  scanner.STAR,
+	scanner.SLASHEQUAL: scanner.SLASH,
+}
+
+// compoundAssignment desugars a += b into a = a + b, the target is a variable
+// until properties and indexes exist, reading it twice has no side effects.
+// The operator token keeps the line of the compound one for runtime errors.
+func (p *Parser) compoundAssignment(target ast.Expr, start int) ast.Expr {
+	compound := p.previous()
+	value := p.assignment()
+
+	variable, ok := target.(*ast.Variable)
+	if !ok {
+		panic(p.erro(compound, "Invalid assignment target."))
+	}
+	kind := compoundOperators[compound.Kind()]
+	operator := scanner.NewToken(kind, strings.TrimSuffix(compound.Lexeme(), "="), nil, compound.Line())
+	binary := spanned(p, ast.NewBinary(variable, operator, value), start)
+	return spanned(p, ast.NewAssign(variable.Name, binary), start)
+}
+
 // Logical expressions.
 
 func (p *Parser) or() ast.Expr {
+	start := p.current
 	expr := p.and()
 	for p.match(scanner.OR) {
 		operator := p.previous()
 		right := p.and()
-		expr = ast.NewLogical(expr, operator, right)
+		expr = spanned(p, ast.NewLogical(expr, operator, right), start)
 	}
 	return expr
 }
 
 func (p *Parser) and() ast.Expr {
+	start := p.current
 	expr := p.equality()
 	for p.match(scanner.AND) {
 		operator := p.previous()
 		right := p.equality()
-		expr = ast.NewLogical(expr, operator, right)
+		expr = spanned(p, ast.NewLogical(expr, operator, right), start)
 	}
 	return expr
 }
@@ -208,41 +335,91 @@ func (p *Parser) and() ast.Expr {
 // Binary expressions.
 
 func (p *Parser) equality() ast.Expr {
+	start := p.current
 	expr := p.comparison()
 	for p.match(scanner.BANGEQUAL, scanner.EQUALEQUAL) {
 		operator := p.previous()
 		right := p.comparison()
-		expr = ast.NewBinary(expr, operator, right)
+		expr = spanned(p, ast.NewBinary(expr, operator, right), start)
 	}
 	return expr
 }
 
 func (p *Parser) comparison() ast.Expr {
-	expr := p.term()
+	start := p.current
+	expr := p.bitwiseOr()
 	for p.match(scanner.GREATER, scanner.GREATEREQUAL, scanner.LESS, scanner.LESSEQUAL) {
+		operator := p.previous()
+		right := p.bitwiseOr()
+		expr = spanned(p, ast.NewBinary(expr, operator, right), start)
+	}
+	return expr
+}
+
+// Bitwise operators bind tighter than comparisons, so x & 1 == 0 tests the lowest bit.
+
+func (p *Parser) bitwiseOr() ast.Expr {
+	start := p.current
+	expr := p.bitwiseXor()
+	for p.match(scanner.PIPE) {
+		operator := p.previous()
+		right := p.bitwiseXor()
+		expr = spanned(p, ast.NewBinary(expr, operator, right), start)
+	}
+	return expr
+}
+
+func (p *Parser) bitwiseXor() ast.Expr {
+	start := p.current
+	expr := p.bitwiseAnd()
+	for p.match(scanner.CARET) {
+		operator := p.previous()
+		right := p.bitwiseAnd()
+		expr = spanned(p, ast.NewBinary(expr, operator, right), start)
+	}
+	return expr
+}
+
+func (p *Parser) bitwiseAnd() ast.Expr {
+	start := p.current
+	expr := p.shift()
+	for p.match(scanner.AMPERSAND) {
+		operator := p.previous()
+		right := p.shift()
+		expr = spanned(p, ast.NewBinary(expr, operator, right), start)
+	}
+	return expr
+}
+
+func (p *Parser) shift() ast.Expr {
+	start := p.current
+	expr := p.term()
+	for p.match(scanner.LESSLESS, scanner.GREATERGREATER) {
 		operator := p.previous()
 		right := p.term()
-		expr = ast.NewBinary(expr, operator, right)
+		expr = spanned(p, ast.NewBinary(expr, operator, right), start)
 	}
 	return expr
 }
 
 func (p *Parser) term() ast.Expr {
+	start := p.current
 	expr := p.factor()
 	for p.match(scanner.MINUS, scanner.PLUS) {
 		operator := p.previous()
 		right := p.factor()
-		expr = ast.NewBinary(expr, operator, right)
+		expr = spanned(p, ast.NewBinary(expr, operator, right), start)
 	}
 	return expr
 }
 
 func (p *Parser) factor() ast.Expr {
+	start := p.current
 	expr := p.unary()
-	for p.match(scanner.SLASH, scanner.STAR) {
+	for p.match(scanner.SLASH, scanner.STAR, scanner.PERCENT, scanner.TILDESLASH) {
 		operator := p.previous()
 		right := p.unary()
-		expr = ast.NewBinary(expr, operator, right)
+		expr = spanned(p, ast.NewBinary(expr, operator, right), start)
 	}
 	return expr
 }
@@ -250,12 +427,53 @@ func (p *Parser) factor() ast.Expr {
 // Unary expression.
 
 func (p *Parser) unary() ast.Expr {
-	if p.match(scanner.BANG, scanner.MINUS) {
+	start := p.current
+	if p.match(scanner.BANG, scanner.MINUS, scanner.TILDE) {
+		operator := p.previous()
+		right := p.unary()
+		return spanned(p, ast.NewUnary(operator, right), start)
+	}
+	return p.power()
+}
+
+// power is right-associative and binds tighter than unary operators on its left,
+// so -2 ** 2 is -4 and 2 ** 3 ** 2 is 2 ** 9, its exponent may be unary, e.g. 2 ** -1.
+func (p *Parser) power() ast.Expr {
+	start := p.current
+	expr := p.call()
+	if p.match(scanner.STARSTAR) {
 		operator := p.previous()
 		right := p.unary()
-		return ast.NewUnary(operator, right)
+		return spanned(p, ast.NewBinary(expr, operator, right), start)
 	}
-	return p.primary()
+	return expr
+}
+
+// Call expression.
+
+func (p *Parser) call() ast.Expr {
+	start := p.current
+	expr := spanned(p, p.primary(), start)
+	for p.match(scanner.LEFTPAREN) {
+		expr = spanned(p, p.finishCall(expr), start)
+	}
+	return expr
+}
+
+func (p *Parser) finishCall(callee ast.Expr) ast.Expr {
+	var arguments []ast.Expr
+	if !p.check(scanner.RIGHTPAREN) {
+		arguments = append(arguments, p.expression())
+		for p.match(scanner.COMMA) {
+			if len(arguments) >= maxArguments {
+				// Reporting without panic: the parser is not in a confused state.
+				p.erro(p.peek(), fmt.Sprintf("Can't have more than %d arguments.", maxArguments))
+			}
+			arguments = append(arguments, p.expression())
+		}
+	}
+	paren := p.consume(scanner.RIGHTPAREN, "Expect ')' after arguments.")
+	return ast.NewCall(callee, paren, arguments)
 }
 
 // Primary expression.
@@ -286,6 +504,12 @@ func (p *Parser) primary() ast.Expr {
 
 // helpers.
 
+// spanned records tokens span of the node parsed since start token index.
+func spanned[T any](p *Parser, node T, start int) T {
+	p.spans[node] = Span{First: start, Last: p.current - 1}
+	return node
+}
+
 func (p *Parser) match(types ...scanner.TokenType) bool {
 	for _, kind := range types {
 		if p.check(kind) {
@@ -4,6 +4,7 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/parser/ast"
 	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/plugins"
 	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/scanner"
 )
@@ -139,4 +140,115 @@ func TestParser_Parse(t *testing.T) {
 			t.Errorf("Parse() = %v, want %v", got, want)
 		}
 	})
+
+	t.Run("Success power expression is right associative and binds tighter than unary minus", func(t *testing.T) {
+		pprinter := plugins.NewAstPrinter()
+		scannr := scanner.NewScanner("-2 ** 3 ** -1 * 4;", nil)
+		p := NewParser(scannr.ScanTokens(), nil)
+		want := "(* (- (** 2 (** 3 (- 1)))) 4);"
+		if got := pprinter.Sprint(p.Parse()); !reflect.DeepEqual(got, want) {
+			t.Errorf("Parse() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Success bitwise expressions precedence", func(t *testing.T) {
+		pprinter := plugins.NewAstPrinter()
+		scannr := scanner.NewScanner("a | b ^ c & 1 << 2 + 3 == ~d;", nil)
+		p := NewParser(scannr.ScanTokens(), nil)
+		want := "(== (| a (^ b (& c (<< 1 (+ 2 3))))) (~ d));"
+		if got := pprinter.Sprint(p.Parse()); !reflect.DeepEqual(got, want) {
+			t.Errorf("Parse() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Success compound assignments are desugared", func(t *testing.T) {
+		pprinter := plugins.NewAstPrinter()
+		scannr := scanner.NewScanner("a += 1; a -= b *= 2; a /= 2 + 2;", nil)
+		p := NewParser(scannr.ScanTokens(), nil)
+		want := "a = (+ a 1);;\na = (- a b = (* b 2););;\na = (/ a (+ 2 2));;"
+		if got := pprinter.Sprint(p.Parse()); !reflect.DeepEqual(got, want) {
+			t.Errorf("Parse() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Fail compound assignment to invalid target", func(t *testing.T) {
+		var messages []string
+		reporter := func(line int, message string) {
+			messages = append(messages, message)
+		}
+		scannr := scanner.NewScanner("(a) += 1;", nil)
+		NewParser(scannr.ScanTokens(), reporter).Parse()
+		want := []string{" at '+='. Message: Invalid assignment target."}
+		if !reflect.DeepEqual(messages, want) {
+			t.Errorf("Parse() errors = %v, want %v", messages, want)
+		}
+	})
+
+	t.Run("Success conditional expression is right associative", func(t *testing.T) {
+		pprinter := plugins.NewAstPrinter()
+		scannr := scanner.NewScanner("a or b ? c ?? d : e ? f : -g;", nil)
+		p := NewParser(scannr.ScanTokens(), nil)
+		want := "(?: (or a b) (?? c d) (?: e f (- g)));"
+		if got := pprinter.Sprint(p.Parse()); !reflect.DeepEqual(got, want) {
+			t.Errorf("Parse() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Success nil coalescing binds looser than or", func(t *testing.T) {
+		pprinter := plugins.NewAstPrinter()
+		scannr := scanner.NewScanner("a ?? b or c ?? d;", nil)
+		p := NewParser(scannr.ScanTokens(), nil)
+		want := "(?? (?? a (or b c)) d);"
+		if got := pprinter.Sprint(p.Parse()); !reflect.DeepEqual(got, want) {
+			t.Errorf("Parse() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Fail conditional expression without colon", func(t *testing.T) {
+		var messages []string
+		reporter := func(line int, message string) {
+			messages = append(messages, message)
+		}
+		scannr := scanner.NewScanner("a ? b;", nil)
+		NewParser(scannr.ScanTokens(), reporter).Parse()
+		want := []string{" at ';'. Message: Expect ':' after then branch of conditional expression."}
+		if !reflect.DeepEqual(messages, want) {
+			t.Errorf("Parse() errors = %v, want %v", messages, want)
+		}
+	})
+
+	t.Run("Success call expressions", func(t *testing.T) {
+		pprinter := plugins.NewAstPrinter()
+		scannr := scanner.NewScanner("f(1, g(2))(3)();", nil)
+		p := NewParser(scannr.ScanTokens(), nil)
+		want := "(call (call (call f 1 (call g 2)) 3));"
+		if got := pprinter.Sprint(p.Parse()); !reflect.DeepEqual(got, want) {
+			t.Errorf("Parse() = %v, want %v", got, want)
+		}
+	})
+}
+
+func TestParser_Spans(t *testing.T) {
+	scannr := scanner.NewScanner("var a = 1 + f(2);\nprint -a;", nil)
+	p := NewParser(scannr.ScanTokens(), nil)
+	statements := p.Parse()
+
+	varStmt := statements[0].(*ast.Var)
+	binary := varStmt.Initializer.(*ast.Binary)
+	call := binary.Right.(*ast.Call)
+	printStmt := statements[1].(*ast.Print)
+	want := map[any]Span{
+		varStmt:                                 {First: 0, Last: 9},
+		binary:                                  {First: 3, Last: 8},
+		binary.Left:                             {First: 3, Last: 3},
+		call:                                    {First: 5, Last: 8},
+		call.Callee:                             {First: 5, Last: 5},
+		call.Arguments[0]:                       {First: 7, Last: 7},
+		printStmt:                               {First: 10, Last: 13},
+		printStmt.Expression:                    {First: 11, Last: 12},
+		printStmt.Expression.(*ast.Unary).Right: {First: 12, Last: 12},
+	}
+	if got := p.Spans(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Spans() = %v, want %v", got, want)
+	}
 }
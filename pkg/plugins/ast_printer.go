@@ -44,6 +44,18 @@ func (p AstPrinter) VisitBinary(binary *ast.Binary) any {
 	return p.parenthesize(binary.Operator.Lexeme(), binary.Left, binary.Right)
 }
 
+func (p AstPrinter) VisitCall(call *ast.Call) any {
+	return p.parenthesize("call", append([]ast.Expr{call.Callee}, call.Arguments...)...)
+}
+
+func (p AstPrinter) VisitCoalesce(coalesce *ast.Coalesce) any {
+	return p.parenthesize(coalesce.Operator.Lexeme(), coalesce.Left, coalesce.Right)
+}
+
+func (p AstPrinter) VisitConditional(conditional *ast.Conditional) any {
+	return p.parenthesize("?:", conditional.Condition, conditional.ThenBranch, conditional.ElseBranch)
+}
+
 func (p AstPrinter) VisitLogical(logical *ast.Logical) any {
 	return p.parenthesize(logical.Operator.Lexeme(), logical.Left, logical.Right)
 }
@@ -74,6 +86,15 @@ func (p AstPrinter) VisitExpression(stmt *ast.Expression) {
 	p.addResult(value.(string) + ";")
 }
 
+func (p AstPrinter) VisitFunction(stmt *ast.Function) {
+	params := make([]string, 0, len(stmt.Params))
+	for _, param := range stmt.Params {
+		params = append(params, param.Lexeme())
+	}
+	p.addResult("fun " + stmt.Name.Lexeme() + "(" + strings.Join(params, ", ") + ")")
+	p.VisitBlock(ast.NewBlock(stmt.Body))
+}
+
 func (p AstPrinter) VisitIf(stmt *ast.If) {
 	value := stmt.Condition.Accept(p)
 	result := "if (" + value.(string) + ") then"
@@ -92,6 +113,15 @@ func (p AstPrinter) VisitPrint(stmt *ast.Print) {
 	p.addResult(result)
 }
 
+func (p AstPrinter) VisitReturn(stmt *ast.Return) {
+	if stmt.Value == nil {
+		p.addResult("return;")
+		return
+	}
+	value := stmt.Value.Accept(p)
+	p.addResult("return " + value.(string) + ";")
+}
+
 func (p AstPrinter) VisitVar(stmt *ast.Var) {
 	value := stmt.Initializer.Accept(p)
 	result := "var " + stmt.Name.Lexeme() + " = " + value.(string) + ";"
@@ -0,0 +1,189 @@
+package scanner
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/decimal"
+)
+
+type radix struct {
+	base int
+	name string
+}
+
+// radixes are prefixes of integer literals in bases other than 10, e.g. 0xFF.
+var radixes = map[rune]radix{
+	'x': {base: 16, name: "hexadecimal"},
+	'b': {base: 2, name: "binary"},
+	'o': {base: 8, name: "octal"},
+}
+
+// number scans a number literal starting with a digit:
+//   - integers, e.g. 42, 0xFF, 0b1010 and 0o17, are int64,
+//   - literals with a fraction or an exponent, e.g. 1.5 and 1.5e-3, are float64,
+//   - the n suffix makes *big.Int big integers, e.g. 123n and 0xFFn,
+//   - the d suffix makes decimal.Decimal decimals, e.g. 1.10d.
+//
+// Underscores separate digits, e.g. 1_000_000. A literal is reported once, with its first problem.
+func (s *Scanner) number() {
+	if s.sources[s.start] == '0' {
+		if radix, ok := radixes[s.peek()]; ok {
+			s.advance()
+			s.radixNumber(radix)
+			return
+		}
+	}
+	_, problem := s.digits(isDigit, 1)
+
+	// Look for fractional part.
+	fractional := s.peek() == '.' && isDigit(s.peekNext())
+	if fractional {
+		// Consume the ".".
+		s.advance()
+		problem = firstProblem(problem, s.digitsProblem(isDigit))
+	}
+	exponent := s.peek() == 'e' || s.peek() == 'E'
+	if exponent {
+		s.advance()
+		if s.peek() == '+' || s.peek() == '-' {
+			s.advance()
+		}
+		if !isDigit(s.peek()) {
+			problem = firstProblem(problem, "exponent has no digits.")
+		}
+		problem = firstProblem(problem, s.digitsProblem(isDigit))
+	}
+
+	text := strings.ReplaceAll(string(s.sources[s.start:s.current]), "_", "")
+	switch {
+	case s.match('n'):
+		if fractional || exponent {
+			problem = firstProblem(problem, "big integers can't have a fraction or an exponent.")
+		}
+		value, ok := new(big.Int).SetString(text, 10)
+		if !ok {
+			value = new(big.Int)
+		}
+		s.addNumber(value, problem)
+	case s.match('d'):
+		if exponent {
+			problem = firstProblem(problem, "decimals can't have an exponent.")
+		}
+		value, _ := decimal.Parse(text)
+		s.addNumber(value, problem)
+	case fractional || exponent:
+		value, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			problem = firstProblem(problem, "out of float range.")
+		}
+		s.addNumber(value, problem)
+	default:
+		value, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			problem = firstProblem(problem, "out of integer range, use the n suffix for big integers.")
+		}
+		s.addNumber(value, problem)
+	}
+}
+
+// radixNumber scans digits of an integer literal after its prefix.
+func (s *Scanner) radixNumber(radix radix) {
+	isRadixDigit := func(c rune) bool {
+		return digitValue(c) < radix.base
+	}
+	count, problem := s.digits(isRadixDigit, 0)
+	if invalid := s.peek(); isAlphaNumeric(invalid) && invalid != 'n' {
+		for isAlphaNumeric(s.peek()) {
+			s.advance()
+		}
+		problem = firstProblem(problem, fmt.Sprintf("'%c' is not %s %s digit.", invalid, article(radix.name), radix.name))
+	}
+	if count == 0 {
+		problem = firstProblem(problem, fmt.Sprintf("no digits after 0%c.", s.sources[s.start+1]))
+	}
+
+	text := strings.ReplaceAll(string(s.sources[s.start+2:s.current]), "_", "")
+	if s.match('n') {
+		value, ok := new(big.Int).SetString(text, radix.base)
+		if !ok {
+			value = new(big.Int)
+		}
+		s.addNumber(value, problem)
+		return
+	}
+	value, err := strconv.ParseInt(text, radix.base, 64)
+	if err != nil {
+		problem = firstProblem(problem, "out of integer range, use the n suffix for big integers.")
+	}
+	s.addNumber(value, problem)
+}
+
+// leadingDotNumber scans a literal like .5 for the error explaining how to write it.
+func (s *Scanner) leadingDotNumber() {
+	s.digitsProblem(isDigit)
+	s.addNumber(0.0, fmt.Sprintf("write 0%s instead.", string(s.sources[s.start:s.current])))
+}
+
+// digits scans digits accepted by isDigit with underscores between them, count is how many
+// digits are already scanned. It returns how many digits there are and a problem with underscores.
+func (s *Scanner) digits(isDigit func(rune) bool, count int) (int, string) {
+	problem := ""
+	for {
+		switch {
+		case isDigit(s.peek()):
+			count++
+		case s.peek() == '_':
+			if count == 0 || !isDigit(s.peekNext()) {
+				problem = firstProblem(problem, "'_' must be between digits.")
+			}
+		default:
+			return count, problem
+		}
+		s.advance()
+	}
+}
+
+func (s *Scanner) digitsProblem(isDigit func(rune) bool) string {
+	_, problem := s.digits(isDigit, 0)
+	return problem
+}
+
+// addNumber adds the literal, reporting its problem if there is one.
+func (s *Scanner) addNumber(value any, problem string) {
+	if problem != "" {
+		s.errReporter(s.line, fmt.Sprintf(
+			"Invalid number literal '%s': %s", string(s.sources[s.start:s.current]), problem,
+		))
+	}
+	s.addToken(NUMBER, value)
+}
+
+func firstProblem(problem, next string) string {
+	if problem != "" {
+		return problem
+	}
+	return next
+}
+
+// digitValue is the value of a digit in bases up to 16, 16 for other characters.
+func digitValue(c rune) int {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0')
+	case c >= 'a' && c <= 'f':
+		return int(c-'a') + 10
+	case c >= 'A' && c <= 'F':
+		return int(c-'A') + 10
+	}
+	return 16
+}
+
+func article(word string) string {
+	if strings.ContainsRune("aeiou", rune(word[0])) {
+		return "an"
+	}
+	return "a"
+}
@@ -2,7 +2,6 @@ package scanner
 
 import (
 	"fmt"
-	"strconv"
 
 	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/errors"
 )
@@ -27,36 +26,84 @@ var keywords = map[string]TokenType{
 }
 
 type Scanner struct {
-	sources []rune
-	tokens  []Token
-	start   int
-	current int
-	line    int
+	sources  []rune
+	tokens   []Token
+	comments []Comment
+	start    int
+	current  int
+	line     int
+
+	// Lossless mode state, trivia is nil when the mode is off.
+	trivia       []TokenTrivia
+	pending      []Trivia
+	trailingOpen bool
 
 	errReporter errors.Reporter
 }
 
 func NewScanner(sources string, errReporter errors.Reporter) *Scanner {
 	return &Scanner{
-		sources: []rune(sources),
-		tokens:  nil,
-		start:   0,
-		current: 0,
-		line:    1,
+		sources:  []rune(sources),
+		tokens:   nil,
+		comments: nil,
+		start:    0,
+		current:  0,
+		line:     1,
 
 		errReporter: errReporter,
 	}
 }
 
+// NewLosslessScanner keeps whitespace, newlines and comments as tokens trivia,
+// so sources can be reproduced byte-for-byte from tokens, see Trivia.
+func NewLosslessScanner(sources string, errReporter errors.Reporter) *Scanner {
+	s := NewScanner(sources, errReporter)
+	s.trivia = []TokenTrivia{}
+	return s
+}
+
 func (s *Scanner) ScanTokens() []Token {
 	for !s.isAtEnd() {
 		s.start = s.current
+		tokensCount := len(s.tokens)
 		s.scanToken()
+		if s.trivia != nil {
+			s.collectTrivia(tokensCount)
+		}
 	}
 	s.tokens = append(s.tokens, NewToken(EOF, "", nil, s.line))
+	if s.trivia != nil {
+		s.trivia = append(s.trivia, TokenTrivia{Leading: s.pending})
+		s.pending = nil
+	}
 	return s.tokens
 }
 
+// Trivia returns trivia of tokens by the same indexes in lossless mode and nil otherwise.
+func (s *Scanner) Trivia() []TokenTrivia {
+	return s.trivia
+}
+
+func (s *Scanner) collectTrivia(tokensCount int) {
+	if len(s.tokens) > tokensCount {
+		s.trivia = append(s.trivia, TokenTrivia{Leading: s.pending})
+		s.pending = nil
+		s.trailingOpen = true
+		return
+	}
+	text := string(s.sources[s.start:s.current])
+	piece := NewTrivia(triviaKind(text), text)
+	if piece.kind == NEWLINE {
+		s.trailingOpen = false
+	}
+	if s.trailingOpen && piece.kind != NEWLINE {
+		last := len(s.trivia) - 1
+		s.trivia[last].Trailing = appendTrivia(s.trivia[last].Trailing, piece)
+		return
+	}
+	s.pending = appendTrivia(s.pending, piece)
+}
+
 // scanToken is main token scanning function.
 // gocyclo considers this function too difficult, but scanners are written always
 // in this way.
@@ -76,15 +123,55 @@ func (s *Scanner) scanToken() {
 	case ',':
 		s.addNoLiteralToken(COMMA)
 	case '.':
-		s.addNoLiteralToken(DOT)
+		if isDigit(s.peek()) {
+			s.leadingDotNumber()
+		} else {
+			s.addNoLiteralToken(DOT)
+		}
 	case '-':
-		s.addNoLiteralToken(MINUS)
+		if s.match('=') {
+			s.addNoLiteralToken(MINUSEQUAL)
+		} else {
+			s.addNoLiteralToken(MINUS)
+		}
 	case '+':
-		s.addNoLiteralToken(PLUS)
+		if s.match('=') {
+			s.addNoLiteralToken(PLUSEQUAL)
+		} else {
+			s.addNoLiteralToken(PLUS)
+		}
 	case ';':
 		s.addNoLiteralToken(SEMICOLON)
 	case '*':
-		s.addNoLiteralToken(STAR)
+		if s.match('*') {
+			s.addNoLiteralToken(STARSTAR)
+		} else if s.match('=') {
+			s.addNoLiteralToken(STAREQUAL)
+		} else {
+			s.addNoLiteralToken(STAR)
+		}
+	case '%':
+		s.addNoLiteralToken(PERCENT)
+	case ':':
+		s.addNoLiteralToken(COLON)
+	case '?':
+		if s.match('?') {
+			s.addNoLiteralToken(QUESTIONQUESTION)
+		} else {
+			s.addNoLiteralToken(QUESTION)
+		}
+	case '&':
+		s.addNoLiteralToken(AMPERSAND)
+	case '|':
+		s.addNoLiteralToken(PIPE)
+	case '^':
+		s.addNoLiteralToken(CARET)
+	case '~':
+		if s.match('/') {
+			s.addNoLiteralToken(TILDESLASH)
+		} else {
+			s.addNoLiteralToken(TILDE)
+		}
 	case '!':
 		if s.match('=') {
 			s.addNoLiteralToken(BANGEQUAL)
@@ -100,12 +187,16 @@ func (s *Scanner) scanToken() {
 	case '<':
 		if s.match('=') {
 			s.addNoLiteralToken(LESSEQUAL)
+		} else if s.match('<') {
+			s.addNoLiteralToken(LESSLESS)
 		} else {
 			s.addNoLiteralToken(LESS)
 		}
 	case '>':
 		if s.match('=') {
 			s.addNoLiteralToken(GREATEREQUAL)
+		} else if s.match('>') {
+			s.addNoLiteralToken(GREATERGREATER)
 		} else {
 			s.addNoLiteralToken(GREATER)
 		}
@@ -115,6 +206,9 @@ func (s *Scanner) scanToken() {
 			for s.peek() != '\n' && !s.isAtEnd() {
 				s.advance()
 			}
+			s.addComment()
+		} else if s.match('=') {
+			s.addNoLiteralToken(SLASHEQUAL)
 		} else {
 			s.addNoLiteralToken(SLASH)
 		}
@@ -189,31 +283,6 @@ func (s *Scanner) str() {
 	s.addToken(STRING, string(value))
 }
 
-func (s *Scanner) number() {
-	for isDigit(s.peek()) {
-		s.advance()
-	}
-
-	// Look for fractional part.
-	if s.peek() == '.' && isDigit(s.peekNext()) {
-		// Consume the ".".
-		s.advance()
-
-		// Repeat the same as for integer part.
-		for isDigit(s.peek()) {
-			s.advance()
-		}
-	}
-	value, err := strconv.ParseFloat(
-		string(s.sources[s.start:s.current]),
-		64,
-	)
-	if err != nil {
-		s.errReporter(s.line, "Cannot parse float.")
-	}
-	s.addToken(NUMBER, value)
-}
-
 func (s *Scanner) identifier() {
 	for isAlphaNumeric(s.peek()) {
 		s.advance()
@@ -228,6 +297,16 @@ func (s *Scanner) identifier() {
 	s.addNoLiteralToken(kind)
 }
 
+// Comments returns line comments found by ScanTokens.
+func (s *Scanner) Comments() []Comment {
+	return s.comments
+}
+
+func (s *Scanner) addComment() {
+	text := string(s.sources[s.start:s.current])
+	s.comments = append(s.comments, NewComment(text, s.line, len(s.tokens)))
+}
+
 func (s *Scanner) addNoLiteralToken(kind TokenType) {
 	s.addToken(kind, nil)
 }
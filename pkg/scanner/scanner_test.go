@@ -1,6 +1,7 @@
 package scanner
 
 import (
+	"fmt"
 	"reflect"
 	"testing"
 
@@ -64,6 +65,10 @@ func TestScanner_ScanTokens(t *testing.T) {
 		sources :=
 			`!	=	<	>
 != == <= >=
+% ~/
+** & | ^ ~ << >>
++= -= *= /=
+? : ??
 `
 		s := NewScanner(sources, nil)
 		want := NewScanner("", nil)
@@ -76,7 +81,23 @@ func TestScanner_ScanTokens(t *testing.T) {
 			NewToken(EQUALEQUAL, "==", nil, 2),
 			NewToken(LESSEQUAL, "<=", nil, 2),
 			NewToken(GREATEREQUAL, ">=", nil, 2),
-			NewToken(EOF, "", nil, 3),
+			NewToken(PERCENT, "%", nil, 3),
+			NewToken(TILDESLASH, "~/", nil, 3),
+			NewToken(STARSTAR, "**", nil, 4),
+			NewToken(AMPERSAND, "&", nil, 4),
+			NewToken(PIPE, "|", nil, 4),
+			NewToken(CARET, "^", nil, 4),
+			NewToken(TILDE, "~", nil, 4),
+			NewToken(LESSLESS, "<<", nil, 4),
+			NewToken(GREATERGREATER, ">>", nil, 4),
+			NewToken(PLUSEQUAL, "+=", nil, 5),
+			NewToken(MINUSEQUAL, "-=", nil, 5),
+			NewToken(STAREQUAL, "*=", nil, 5),
+			NewToken(SLASHEQUAL, "/=", nil, 5),
+			NewToken(QUESTION, "?", nil, 6),
+			NewToken(COLON, ":", nil, 6),
+			NewToken(QUESTIONQUESTION, "??", nil, 6),
+			NewToken(EOF, "", nil, 7),
 		}
 		if got := s.ScanTokens(); !reflect.DeepEqual(got, want.tokens) {
 			t.Errorf("ScanTokens() = %v, want %v", got, want.tokens)
@@ -99,6 +120,15 @@ func TestScanner_ScanTokens(t *testing.T) {
 		if got := s.ScanTokens(); !reflect.DeepEqual(got, want.tokens) {
 			t.Errorf("ScanTokens() = %v, want %v", got, want.tokens)
 		}
+		wantComments := []Comment{
+			NewComment("// this is a comment", 1, 0),
+			NewComment("// tab", 2, 0),
+			NewComment("// there is \\r in previous line", 4, 0),
+			NewComment("// 5 spaces", 5, 0),
+		}
+		if got := s.Comments(); !reflect.DeepEqual(got, wantComments) {
+			t.Errorf("Comments() = %v, want %v", got, wantComments)
+		}
 
 		// TODO: add case 5 / 3. Not covered as division operator.
 	})
@@ -130,15 +160,13 @@ multi-line string"`, "some other\nmulti-line string", 3),
 		s := NewScanner(sources, nil)
 		want := NewScanner("", nil)
 		want.tokens = []Token{
-			NewToken(NUMBER, "1234567890", float64(1234567890), 1),
+			NewToken(NUMBER, "1234567890", int64(1234567890), 1),
 			NewToken(NUMBER, "1234.056789", 1234.056789, 2),
 			NewToken(EOF, "", nil, 3),
 		}
 		if got := s.ScanTokens(); !reflect.DeepEqual(got, want.tokens) {
 			t.Errorf("ScanTokens() = %v, want %v", got, want.tokens)
 		}
-
-		// TODO: add cases: .03434, 3434., 34234..3434 and so on
 	})
 
 	t.Run("test reserved words and identifiers symbols", func(t *testing.T) {
@@ -194,7 +222,7 @@ super_puper_var
 
 	t.Run("test unknown symbols", func(t *testing.T) {
 		sources := `@	#
- ^`
+ $ \`
 		savedErrors, reporter := getErrorReporterStub()
 		s := NewScanner(sources, reporter)
 		wantErrors := []interprtrErr{
@@ -208,7 +236,11 @@ super_puper_var
 			},
 			{
 				line:    2,
-				message: "Unexpected character: ^.",
+				message: "Unexpected character: $.",
+			},
+			{
+				line:    2,
+				message: "Unexpected character: \\.",
 			},
 		}
 		s.ScanTokens()
@@ -217,3 +249,156 @@ super_puper_var
 		}
 	})
 }
+
+func TestScanner_NumberLiterals(t *testing.T) {
+	tests := []struct {
+		sources string
+		// want is the literal as "type value".
+		want string
+	}{
+		{sources: "42", want: "int64 42"},
+		{sources: "1_000_000", want: "int64 1000000"},
+		{sources: "0xFF", want: "int64 255"},
+		{sources: "0xdead_BEEF", want: "int64 3735928559"},
+		{sources: "0b1010", want: "int64 10"},
+		{sources: "0b1111_0000", want: "int64 240"},
+		{sources: "0o17", want: "int64 15"},
+		{sources: "0", want: "int64 0"},
+		{sources: "1.5", want: "float64 1.5"},
+		{sources: "1.5e-3", want: "float64 0.0015"},
+		{sources: "2E+3", want: "float64 2000"},
+		{sources: "1e3", want: "float64 1000"},
+		{sources: "3.141_592", want: "float64 3.141592"},
+		{sources: "1_000n", want: "*big.Int 1000"},
+		{sources: "0xFFFF_FFFF_FFFF_FFFF_FFn", want: "*big.Int 4722366482869645213695"},
+		{sources: "1.10d", want: "decimal.Decimal 1.10"},
+		{sources: "1_000.50d", want: "decimal.Decimal 1000.50"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sources, func(t *testing.T) {
+			savedErrors, reporter := getErrorReporterStub()
+			tokens := NewScanner(tt.sources, reporter).ScanTokens()
+			if len(*savedErrors) > 0 {
+				t.Fatalf("ScanTokens() errors = %v", *savedErrors)
+			}
+			if len(tokens) != 2 || tokens[0].Kind() != NUMBER || tokens[0].Lexeme() != tt.sources {
+				t.Fatalf("ScanTokens() = %v, want one number", tokens)
+			}
+			if got := fmt.Sprintf("%T %v", tokens[0].Literal(), tokens[0].Literal()); got != tt.want {
+				t.Errorf("literal = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanner_InvalidNumberLiterals(t *testing.T) {
+	tests := []struct {
+		sources string
+		want    string
+	}{
+		{sources: ".5", want: "Invalid number literal '.5': write 0.5 instead."},
+		{sources: "1..5", want: "Invalid number literal '.5': write 0.5 instead."},
+		{sources: "0x", want: "Invalid number literal '0x': no digits after 0x."},
+		{sources: "0xFG", want: "Invalid number literal '0xFG': 'G' is not a hexadecimal digit."},
+		{sources: "0b102", want: "Invalid number literal '0b102': '2' is not a binary digit."},
+		{sources: "0o78", want: "Invalid number literal '0o78': '8' is not an octal digit."},
+		{sources: "0x_1", want: "Invalid number literal '0x_1': '_' must be between digits."},
+		{sources: "1__000", want: "Invalid number literal '1__000': '_' must be between digits."},
+		{sources: "1_", want: "Invalid number literal '1_': '_' must be between digits."},
+		{sources: "1.5_", want: "Invalid number literal '1.5_': '_' must be between digits."},
+		{sources: "1e", want: "Invalid number literal '1e': exponent has no digits."},
+		{sources: "1.5e+", want: "Invalid number literal '1.5e+': exponent has no digits."},
+		{sources: "1e999", want: "Invalid number literal '1e999': out of float range."},
+		{
+			sources: "9223372036854775808",
+			want:    "Invalid number literal '9223372036854775808': out of integer range, use the n suffix for big integers.",
+		},
+		{
+			sources: "0x8000000000000000",
+			want:    "Invalid number literal '0x8000000000000000': out of integer range, use the n suffix for big integers.",
+		},
+		{sources: "1.5n", want: "Invalid number literal '1.5n': big integers can't have a fraction or an exponent."},
+		{sources: "1e3d", want: "Invalid number literal '1e3d': decimals can't have an exponent."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sources, func(t *testing.T) {
+			savedErrors, reporter := getErrorReporterStub()
+			NewScanner(tt.sources, reporter).ScanTokens()
+			wantErrors := []interprtrErr{{line: 1, message: tt.want}}
+			if !reflect.DeepEqual(*savedErrors, wantErrors) {
+				t.Errorf("ScanTokens() errors = %v, want %v", *savedErrors, wantErrors)
+			}
+		})
+	}
+}
+
+func TestScanner_NumberLiteralsAroundDots(t *testing.T) {
+	tokens := NewScanner("3434. 1.foo", nil).ScanTokens()
+	var kinds []TokenType
+	for _, token := range tokens {
+		kinds = append(kinds, token.Kind())
+	}
+	want := []TokenType{NUMBER, DOT, NUMBER, DOT, IDENTIFIER, EOF}
+	if !reflect.DeepEqual(kinds, want) {
+		t.Errorf("ScanTokens() kinds = %v, want %v", kinds, want)
+	}
+}
+
+func TestScanner_Trivia(t *testing.T) {
+	t.Run("trivia is not kept by default", func(t *testing.T) {
+		s := NewScanner("var a; // comment", nil)
+		s.ScanTokens()
+		if got := s.Trivia(); got != nil {
+			t.Errorf("Trivia() = %v, want nil", got)
+		}
+	})
+
+	t.Run("leading and trailing trivia", func(t *testing.T) {
+		sources := "// header\n  var a;  // comment\n\t@ b\n"
+		savedErrors, reporter := getErrorReporterStub()
+		s := NewLosslessScanner(sources, reporter)
+		tokens := s.ScanTokens()
+		want := []TokenTrivia{
+			{ // var
+				Leading: []Trivia{
+					NewTrivia(COMMENT, "// header"),
+					NewTrivia(NEWLINE, "\n"),
+					NewTrivia(WHITESPACE, "  "),
+				},
+				Trailing: []Trivia{NewTrivia(WHITESPACE, " ")},
+			},
+			{}, // a
+			{ // ;
+				Trailing: []Trivia{
+					NewTrivia(WHITESPACE, "  "),
+					NewTrivia(COMMENT, "// comment"),
+				},
+			},
+			{ // b
+				Leading: []Trivia{
+					NewTrivia(NEWLINE, "\n"),
+					NewTrivia(WHITESPACE, "\t"),
+					NewTrivia(SKIPPED, "@"),
+					NewTrivia(WHITESPACE, " "),
+				},
+			},
+			{ // EOF
+				Leading: []Trivia{NewTrivia(NEWLINE, "\n")},
+			},
+		}
+		if got := s.Trivia(); !reflect.DeepEqual(got, want) {
+			t.Errorf("Trivia() = %v, want %v", got, want)
+		}
+		if len(*savedErrors) != 1 {
+			t.Errorf("ScanTokens() reported %v, want unexpected character error", *savedErrors)
+		}
+
+		text := ""
+		for i, token := range tokens {
+			text += s.Trivia()[i].Text(token)
+		}
+		if text != sources {
+			t.Errorf("tokens with trivia = %q, want %q", text, sources)
+		}
+	})
+}
@@ -16,17 +16,33 @@ const (
 	SEMICOLON  = TokenType("SEMICOLON")
 	SLASH      = TokenType("SLASH")
 	STAR       = TokenType("STAR")
+	PERCENT    = TokenType("PERCENT")
+	AMPERSAND  = TokenType("AMPERSAND")
+	PIPE       = TokenType("PIPE")
+	CARET      = TokenType("CARET")
+	TILDE      = TokenType("TILDE")
+	QUESTION   = TokenType("QUESTION")
+	COLON      = TokenType("COLON")
 
 	// One or two character tokens.
 
-	BANG         = TokenType("BANG")
-	BANGEQUAL    = TokenType("BANGEQUAL")
-	EQUAL        = TokenType("EQUAL")
-	EQUALEQUAL   = TokenType("EQUALEQUAL")
-	GREATER      = TokenType("GREATER")
-	GREATEREQUAL = TokenType("GREATEREQUAL")
-	LESS         = TokenType("LESS")
-	LESSEQUAL    = TokenType("LESSEQUAL")
+	BANG             = TokenType("BANG")
+	BANGEQUAL        = TokenType("BANGEQUAL")
+	EQUAL            = TokenType("EQUAL")
+	EQUALEQUAL       = TokenType("EQUALEQUAL")
+	GREATER          = TokenType("GREATER")
+	GREATEREQUAL     = TokenType("GREATEREQUAL")
+	LESS             = TokenType("LESS")
+	LESSEQUAL        = TokenType("LESSEQUAL")
+	TILDESLASH       = TokenType("TILDESLASH")
+	STARSTAR         = TokenType("STARSTAR")
+	LESSLESS         = TokenType("LESSLESS")
+	GREATERGREATER   = TokenType("GREATERGREATER")
+	PLUSEQUAL        = TokenType("PLUSEQUAL")
+	MINUSEQUAL       = TokenType("MINUSEQUAL")
+	STAREQUAL        = TokenType("STAREQUAL")
+	SLASHEQUAL       = TokenType("SLASHEQUAL")
+	QUESTIONQUESTION = TokenType("QUESTIONQUESTION")
 
 	// Literals.
 
@@ -91,3 +107,34 @@ func (t Token) Literal() any {
 func (t Token) Line() int {
 	return t.line
 }
+
+// Comment is a line comment, comments are not tokens, parser never sees them.
+// They are kept aside for tools reprinting sources like formatter.
+type Comment struct {
+	text string
+	line int
+	// next is the index of the token following the comment.
+	next int
+}
+
+func NewComment(text string, line int, next int) Comment {
+	return Comment{
+		text: text,
+		line: line,
+		next: next,
+	}
+}
+
+// Text is the comment including leading slashes.
+func (c Comment) Text() string {
+	return c.text
+}
+
+func (c Comment) Line() int {
+	return c.line
+}
+
+// Next is the index of the token following the comment in ScanTokens result.
+func (c Comment) Next() int {
+	return c.next
+}
@@ -0,0 +1,79 @@
+package scanner
+
+import (
+	"strings"
+)
+
+type TriviaKind string
+
+const (
+	WHITESPACE = TriviaKind("WHITESPACE")
+	NEWLINE    = TriviaKind("NEWLINE")
+	COMMENT    = TriviaKind("COMMENT")
+	// SKIPPED are characters the scanner reported as errors, e.g. unexpected ones.
+	SKIPPED = TriviaKind("SKIPPED")
+)
+
+// Trivia is a piece of sources between tokens.
+type Trivia struct {
+	kind TriviaKind
+	text string
+}
+
+func NewTrivia(kind TriviaKind, text string) Trivia {
+	return Trivia{
+		kind: kind,
+		text: text,
+	}
+}
+
+func (t Trivia) Kind() TriviaKind {
+	return t.kind
+}
+
+func (t Trivia) Text() string {
+	return t.text
+}
+
+// TokenTrivia is attached to a token in lossless mode.
+// Trailing trivia are whitespace and a comment up to the end of the token line,
+// leading trivia are everything else since the previous token, newlines included.
+type TokenTrivia struct {
+	Leading  []Trivia
+	Trailing []Trivia
+}
+
+// Text reproduces sources of the token with its trivia.
+func (tt TokenTrivia) Text(token Token) string {
+	builder := strings.Builder{}
+	for _, trivia := range tt.Leading {
+		builder.WriteString(trivia.text)
+	}
+	builder.WriteString(token.lexeme)
+	for _, trivia := range tt.Trailing {
+		builder.WriteString(trivia.text)
+	}
+	return builder.String()
+}
+
+func triviaKind(text string) TriviaKind {
+	switch {
+	case text == "\n":
+		return NEWLINE
+	case strings.HasPrefix(text, "//"):
+		return COMMENT
+	case strings.Trim(text, " \r\t") == "":
+		return WHITESPACE
+	}
+	return SKIPPED
+}
+
+// appendTrivia merges adjacent pieces of the same kind except newlines and comments.
+func appendTrivia(pieces []Trivia, piece Trivia) []Trivia {
+	last := len(pieces) - 1
+	if last >= 0 && pieces[last].kind == piece.kind && (piece.kind == WHITESPACE || piece.kind == SKIPPED) {
+		pieces[last].text += piece.text
+		return pieces
+	}
+	return append(pieces, piece)
+}
@@ -0,0 +1,221 @@
+// Package scope binds names of Lox programs statically the same way the interpreter does at runtime,
+// for tools like the linter and the language server.
+package scope
+
+import (
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/parser/ast"
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/scanner"
+)
+
+// Kind names what a declaration declares.
+type Kind string
+
+const (
+	Variable  = Kind("variable")
+	Parameter = Kind("parameter")
+	Function  = Kind("function")
+)
+
+// Declaration is a name declared by a var or a fun statement or a function parameter.
+type Declaration struct {
+	Name scanner.Token
+	Kind Kind
+	// Node is the *ast.Var or the *ast.Function declaring the name, the function for parameters.
+	Node ast.Stmt
+	// Param is the index of a parameter in the function parameters.
+	Param  int
+	Global bool
+	// Function is the declaration of the function which body declares the name, nil at top level.
+	Function *Declaration
+	// Shadowed is the declaration of enclosing scopes the local one hides, nil for globals.
+	Shadowed *Declaration
+}
+
+// Use is a read or an assignment of a name.
+type Use struct {
+	Name scanner.Token
+	// Node is the *ast.Variable or the *ast.Assign.
+	Node       ast.Expr
+	Assignment bool
+	// Declaration is the resolved declaration, nil for names never declared.
+	Declaration *Declaration
+}
+
+// Scopes are declarations and uses of a program in source order.
+type Scopes struct {
+	Declarations []*Declaration
+	Uses         []*Use
+}
+
+// Resolve binds locals by blocks and functions nesting and globals by name at any place,
+// so a use of a global resolves to its last declaration even when it is declared after the use.
+func Resolve(statements []ast.Stmt) *Scopes {
+	r := &resolver{stack: []map[string]*Declaration{{}}}
+	r.statements(statements)
+	for _, use := range r.globalUses {
+		use.Declaration = r.stack[0][use.Name.Lexeme()]
+	}
+	return &r.Scopes
+}
+
+type resolver struct {
+	Scopes
+	stack     []map[string]*Declaration
+	functions []*Declaration
+	// globalUses are resolved when all globals are declared.
+	globalUses []*Use
+}
+
+func (r *resolver) statements(statements []ast.Stmt) {
+	for _, statement := range statements {
+		if statement != nil {
+			statement.Accept(r)
+		}
+	}
+}
+
+func (r *resolver) expr(expr ast.Expr) {
+	if expr != nil {
+		expr.Accept(r)
+	}
+}
+
+func (r *resolver) begin() {
+	r.stack = append(r.stack, map[string]*Declaration{})
+}
+
+func (r *resolver) end() {
+	r.stack = r.stack[:len(r.stack)-1]
+}
+
+func (r *resolver) declare(decl *Declaration) *Declaration {
+	decl.Global = len(r.stack) == 1
+	if len(r.functions) > 0 {
+		decl.Function = r.functions[len(r.functions)-1]
+	}
+	if !decl.Global {
+		for i := len(r.stack) - 2; i >= 0; i-- {
+			if shadowed, ok := r.stack[i][decl.Name.Lexeme()]; ok {
+				decl.Shadowed = shadowed
+				break
+			}
+		}
+	}
+	r.stack[len(r.stack)-1][decl.Name.Lexeme()] = decl
+	r.Declarations = append(r.Declarations, decl)
+	return decl
+}
+
+func (r *resolver) use(use *Use) {
+	r.Uses = append(r.Uses, use)
+	for i := len(r.stack) - 1; i > 0; i-- {
+		if decl, ok := r.stack[i][use.Name.Lexeme()]; ok {
+			use.Declaration = decl
+			return
+		}
+	}
+	r.globalUses = append(r.globalUses, use)
+}
+
+func (r *resolver) VisitBlock(stmt *ast.Block) {
+	r.begin()
+	r.statements(stmt.Statements)
+	r.end()
+}
+
+func (r *resolver) VisitExpression(stmt *ast.Expression) {
+	r.expr(stmt.Expression)
+}
+
+func (r *resolver) VisitFunction(stmt *ast.Function) {
+	function := r.declare(&Declaration{Name: stmt.Name, Kind: Function, Node: stmt})
+	r.functions = append(r.functions, function)
+	r.begin()
+	for i, param := range stmt.Params {
+		r.declare(&Declaration{Name: param, Kind: Parameter, Node: stmt, Param: i})
+	}
+	r.statements(stmt.Body)
+	r.end()
+	r.functions = r.functions[:len(r.functions)-1]
+}
+
+func (r *resolver) VisitIf(stmt *ast.If) {
+	r.expr(stmt.Condition)
+	r.statements([]ast.Stmt{stmt.ThenBranch, stmt.ElseBranch})
+}
+
+func (r *resolver) VisitPrint(stmt *ast.Print) {
+	r.expr(stmt.Expression)
+}
+
+func (r *resolver) VisitReturn(stmt *ast.Return) {
+	r.expr(stmt.Value)
+}
+
+func (r *resolver) VisitVar(stmt *ast.Var) {
+	r.expr(stmt.Initializer)
+	r.declare(&Declaration{Name: stmt.Name, Kind: Variable, Node: stmt})
+}
+
+func (r *resolver) VisitWhile(stmt *ast.While) {
+	r.expr(stmt.Condition)
+	r.statements([]ast.Stmt{stmt.Body})
+}
+
+func (r *resolver) VisitAssign(expr *ast.Assign) any {
+	r.expr(expr.Value)
+	r.use(&Use{Name: expr.Name, Node: expr, Assignment: true})
+	return nil
+}
+
+func (r *resolver) VisitBinary(expr *ast.Binary) any {
+	r.expr(expr.Left)
+	r.expr(expr.Right)
+	return nil
+}
+
+func (r *resolver) VisitCall(expr *ast.Call) any {
+	r.expr(expr.Callee)
+	for _, argument := range expr.Arguments {
+		r.expr(argument)
+	}
+	return nil
+}
+
+func (r *resolver) VisitCoalesce(expr *ast.Coalesce) any {
+	r.expr(expr.Left)
+	r.expr(expr.Right)
+	return nil
+}
+
+func (r *resolver) VisitConditional(expr *ast.Conditional) any {
+	r.expr(expr.Condition)
+	r.expr(expr.ThenBranch)
+	r.expr(expr.ElseBranch)
+	return nil
+}
+
+func (r *resolver) VisitGrouping(expr *ast.Grouping) any {
+	r.expr(expr.Expression)
+	return nil
+}
+
+func (r *resolver) VisitLiteral(*ast.Literal) any {
+	return nil
+}
+
+func (r *resolver) VisitLogical(expr *ast.Logical) any {
+	r.expr(expr.Left)
+	r.expr(expr.Right)
+	return nil
+}
+
+func (r *resolver) VisitUnary(expr *ast.Unary) any {
+	r.expr(expr.Right)
+	return nil
+}
+
+func (r *resolver) VisitVariable(expr *ast.Variable) any {
+	r.use(&Use{Name: expr.Name, Node: expr})
+	return nil
+}
@@ -0,0 +1,65 @@
+package scope
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/parser"
+	"github.com/rpromyshlennikov/lox_tree_walk_interpretator/pkg/scanner"
+)
+
+// describe tells where a declaration is, e.g. "a@1" for a name declared on line 1.
+func describe(decl *Declaration) string {
+	if decl == nil {
+		return "undeclared"
+	}
+	return fmt.Sprintf("%s@%d", decl.Name.Lexeme(), decl.Name.Line())
+}
+
+func TestResolve(t *testing.T) {
+	sources := `fun f(a) {
+  print a + g;
+  {
+    var a = 1;
+    a = a + 1;
+  }
+}
+var g = 2;
+print missing;`
+	tokens := scanner.NewScanner(sources, nil).ScanTokens()
+	scopes := Resolve(parser.NewParser(tokens, nil).Parse())
+
+	var declarations []string
+	for _, decl := range scopes.Declarations {
+		declarations = append(declarations, fmt.Sprintf(
+			"%s %s global=%v in=%s shadows=%s",
+			decl.Kind, describe(decl), decl.Global, describe(decl.Function), describe(decl.Shadowed),
+		))
+	}
+	wantDeclarations := []string{
+		"function f@1 global=true in=undeclared shadows=undeclared",
+		"parameter a@1 global=false in=f@1 shadows=undeclared",
+		"variable a@4 global=false in=f@1 shadows=a@1",
+		"variable g@8 global=true in=undeclared shadows=undeclared",
+	}
+	if !reflect.DeepEqual(declarations, wantDeclarations) {
+		t.Errorf("declarations = %q, want %q", declarations, wantDeclarations)
+	}
+
+	var uses []string
+	for _, use := range scopes.Uses {
+		uses = append(uses, fmt.Sprintf("%s@%d assignment=%v -> %s",
+			use.Name.Lexeme(), use.Name.Line(), use.Assignment, describe(use.Declaration)))
+	}
+	wantUses := []string{
+		"a@2 assignment=false -> a@1",
+		"g@2 assignment=false -> g@8",
+		"a@5 assignment=false -> a@4",
+		"a@5 assignment=true -> a@4",
+		"missing@9 assignment=false -> undeclared",
+	}
+	if !reflect.DeepEqual(uses, wantUses) {
+		t.Errorf("uses = %q, want %q", uses, wantUses)
+	}
+}
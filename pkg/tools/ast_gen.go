@@ -106,7 +106,13 @@ type Visitor%s interface {
 //gocyclo:ignore
 func defineType(builder *strings.Builder, names []string, kind string) {
 	className, fieldsList := classProps(kind)
-	fields := strings.Split(fieldsList, ", ")
+	// Fields after ";" are filled in after parsing, e.g. by a resolver, constructors skip them.
+	fieldsList, laterList, _ := strings.Cut(fieldsList, ";")
+	fields := strings.Split(strings.TrimSpace(fieldsList), ", ")
+	var laterFields []string
+	if laterList = strings.TrimSpace(laterList); laterList != "" {
+		laterFields = strings.Split(laterList, ", ")
+	}
 	baseName, baseReturnType := baseNames(names)
 
 	// Producing type for Expression.
@@ -114,7 +120,7 @@ func defineType(builder *strings.Builder, names []string, kind string) {
 	if err != nil {
 		panic(err)
 	}
-	for _, field := range fields {
+	for _, field := range append(fields, laterFields...) {
 		name := strings.Split(field, " ")[0]
 		_, err = builder.WriteString(
 			"\t// " + name + " field.\n" +
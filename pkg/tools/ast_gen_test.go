@@ -63,6 +63,35 @@ func NewSomeOp(operator scanner.Token, right Literal) *SomeOp {
 func (s *SomeOp) Accept(visitor VisitorFoo) {
 	visitor.VisitSomeOp(s)
 }
+`
+		if got := builder.String(); got != want {
+			t.Errorf("String() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Fields filled in later", func(t *testing.T) {
+		builder := &strings.Builder{}
+		kind := "Variable : Name scanner.Token; Local *Slot"
+
+		defineType(builder, []string{"Expr", "any"}, kind)
+
+		want := `
+type Variable struct {
+	// Name field.
+	Name scanner.Token
+	// Local field.
+	Local *Slot
+}
+
+func NewVariable(name scanner.Token) *Variable {
+	this := Variable{}
+	this.Name = name
+	return &this
+}
+
+func (v *Variable) Accept(visitor VisitorExpr) any {
+	return visitor.VisitVariable(v)
+}
 `
 		if got := builder.String(); got != want {
 			t.Errorf("String() = %v, want %v", got, want)